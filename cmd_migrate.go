@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go-story/internal/config"
+	"go-story/internal/data"
+)
+
+// runMigrateCommand 實作 `go-story migrate <name>`：明確套用 go-story 需要、但 CMS migration 尚未包含的 schema。
+// 服務啟動時不會修改 schema。回傳值為 exit code：0 成功、1 套用失敗、2 參數錯誤。
+func runMigrateCommand(args []string, stderr io.Writer) int {
+	if len(args) != 1 || args[0] != "slug-history" {
+		fmt.Fprintln(stderr, "usage: go-story migrate slug-history")
+		return 2
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "migrate: %v\n", err)
+		return 2
+	}
+	db, err := data.NewDB(cfg.DatabaseURL)
+	if err != nil {
+		fmt.Fprintf(stderr, "migrate: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+	if err := data.NewRepo(db, nil, nil).MigrateSlugHistory(context.Background()); err != nil {
+		fmt.Fprintf(stderr, "migrate: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(stderr, "migrate: slug history applied")
+	return 0
+}
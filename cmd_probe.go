@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"go-story/internal/data"
+	"go-story/internal/probe"
+)
+
+// runProbeCommand 實作 `go-story probe`：在兩個 GraphQL endpoint 上執行 probe suite 並比對，
+// --mode schema 時改為比對兩邊 introspection 的 schema。
+// 回傳值為 exit code：0 全部一致、1 有不一致、2 參數或輸出錯誤。
+func runProbeCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("probe", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	target := fs.String("target", "", "reference GraphQL endpoint, e.g. the Keystone /api/graphql (required unless --replay)")
+	candidate := fs.String("candidate", "", "GraphQL endpoint under test, or local to serve this build in-process (required)")
+	mode := fs.String("mode", "queries", "queries: run a probe suite; schema: diff the introspection schemas")
+	suiteName := fs.String("suite", probe.DefaultSuite, "probe suite name: "+strings.Join(probe.SuiteNames(), ", "))
+	format := fs.String("format", "text", "report format: text or junit (schema mode also accepts json)")
+	output := fs.String("output", "", "write the report to this file instead of stdout")
+	samples := fs.Int("samples", probe.DefaultSamples, "entities sampled per type (post, external, topic, video)")
+	concurrency := fs.Int("concurrency", probe.DefaultConcurrency, "maximum number of requests in flight across both endpoints")
+	storeSpec := fs.String("store", "", "save the run to a probe store: a postgres:// DSN or file:<path>")
+	commit := fs.String("commit", os.Getenv("COMMIT_SHA"), "candidate version recorded with the stored run")
+	timeout := fs.Duration("timeout", 10*time.Second, "per-request timeout")
+	recordDir := fs.String("record", "", "save the target's responses (and the local candidate's repository results) as fixtures in this directory")
+	schemaIgnore := fs.String("schema-ignore", strings.Join(probe.DefaultSchemaIgnore, ","), "comma-separated types and Type.field paths skipped in schema mode (a trailing * matches a prefix)")
+	replayDir := fs.String("replay", "", "replay the target from fixtures in this directory; --candidate local then runs offline")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *samples < 1 || *concurrency < 1 {
+		fmt.Fprintln(stderr, "probe: --samples and --concurrency must be at least 1")
+		return 2
+	}
+	if *mode != "queries" && *mode != "schema" {
+		fmt.Fprintf(stderr, "probe: unknown mode %q\n", *mode)
+		return 2
+	}
+	client := probe.NewClient()
+	client.Timeout = *timeout
+	endpoints, err := setupProbeEndpoints(*target, *candidate, *recordDir, *replayDir, client)
+	if err != nil {
+		fmt.Fprintf(stderr, "probe: %v\n", err)
+		fs.Usage()
+		return 2
+	}
+	defer endpoints.close()
+	if *mode == "schema" {
+		code := runSchemaDiff(endpoints, *format, *output, splitList(*schemaIgnore), stdout, stderr)
+		if err := endpoints.finish(*recordDir, *target, stderr); err != nil {
+			fmt.Fprintf(stderr, "probe: %v\n", err)
+			return 2
+		}
+		return code
+	}
+	write := probe.WriteText
+	switch *format {
+	case "text":
+	case "junit":
+		write = probe.WriteJUnit
+	default:
+		fmt.Fprintf(stderr, "probe: unknown format %q\n", *format)
+		return 2
+	}
+	suite, err := probe.LoadSuite(*suiteName)
+	if err != nil {
+		fmt.Fprintf(stderr, "probe: %v\n", err)
+		return 2
+	}
+
+	var store probe.Store
+	if *storeSpec != "" {
+		var db *sql.DB
+		if strings.HasPrefix(*storeSpec, "postgres") {
+			conn, err := data.NewDB(*storeSpec)
+			if err != nil {
+				fmt.Fprintf(stderr, "probe: %v\n", err)
+				return 2
+			}
+			defer conn.Close()
+			db = conn
+			*storeSpec = "postgres"
+		}
+		if store, err = openProbeStore(*storeSpec, db); err != nil {
+			fmt.Fprintf(stderr, "probe: %v\n", err)
+			return 2
+		}
+	}
+
+	started := time.Now()
+	report := probe.RunSuite(endpoints.targetClient(), suite, endpoints.target, endpoints.candidate, probe.Options{
+		Samples:     *samples,
+		Concurrency: *concurrency,
+		SelfClient:  client,
+	})
+	if err := endpoints.finish(*recordDir, *target, stderr); err != nil {
+		fmt.Fprintf(stderr, "probe: %v\n", err)
+		return 2
+	}
+
+	if store != nil {
+		if err := store.Save(context.Background(), probe.NewStoredRun(report, *commit, started)); err != nil {
+			fmt.Fprintf(stderr, "probe: save run: %v\n", err)
+			return 2
+		}
+	}
+
+	out, closeOut, err := openOutput(*output, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "probe: %v\n", err)
+		return 2
+	}
+	defer closeOut()
+	if err := write(out, report); err != nil {
+		fmt.Fprintf(stderr, "probe: write report: %v\n", err)
+		return 2
+	}
+	if report.Summary.Mismatch > 0 {
+		return 1
+	}
+	return 0
+}
+
+// openProbeStore 依 spec 開啟 probe store：postgres 使用 db 的 "ProbeRun" table，
+// file:<path> 使用 JSON Lines 檔案；spec 為空時回傳 nil（不保存）
+func openProbeStore(spec string, db *sql.DB) (probe.Store, error) {
+	switch {
+	case spec == "":
+		return nil, nil
+	case spec == "postgres":
+		if db == nil {
+			return nil, fmt.Errorf("postgres probe store needs a database connection")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return probe.NewPGStore(ctx, db)
+	case strings.HasPrefix(spec, "file:"):
+		return probe.NewFileStore(strings.TrimPrefix(spec, "file:")), nil
+	}
+	return nil, fmt.Errorf("unknown probe store %q", spec)
+}
+
+// runSchemaDiff 實作 --mode schema：有 missing 或 changed 時 exit code 為 1
+func runSchemaDiff(endpoints *probeEndpoints, format, output string, ignore []string, stdout, stderr io.Writer) int {
+	var write func(io.Writer, *probe.SchemaReport) error
+	switch format {
+	case "text":
+		write = probe.WriteSchemaText
+	case "junit":
+		write = probe.WriteSchemaJUnit
+	case "json":
+		write = func(w io.Writer, r *probe.SchemaReport) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(r)
+		}
+	default:
+		fmt.Fprintf(stderr, "probe: unknown format %q\n", format)
+		return 2
+	}
+	report, err := probe.RunSchemaDiff(endpoints.targetClient(), endpoints.target, endpoints.candidate, probe.Options{SelfClient: endpoints.client, SchemaIgnore: ignore})
+	if err != nil {
+		fmt.Fprintf(stderr, "probe: %v\n", err)
+		return 2
+	}
+	out, closeOut, err := openOutput(output, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "probe: %v\n", err)
+		return 2
+	}
+	defer closeOut()
+	if err := write(out, report); err != nil {
+		fmt.Fprintf(stderr, "probe: write report: %v\n", err)
+		return 2
+	}
+	if !report.Compatible() {
+		return 1
+	}
+	return 0
+}
+
+// openOutput 回傳報表的輸出位置；path 為空時使用 stdout
+func openOutput(path string, stdout io.Writer) (io.Writer, func(), error) {
+	if path == "" {
+		return stdout, func() {}, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func() { f.Close() }, nil
+}
+
+// splitList 拆開逗號分隔的清單；空字串回傳非 nil 的空清單，讓 --schema-ignore "" 可以關閉預設值
+func splitList(s string) []string {
+	out := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"go-story/internal/config"
+	"go-story/internal/data"
+	"go-story/internal/links"
+	"go-story/internal/probe"
+	"go-story/internal/redirect"
+	"go-story/internal/rendition"
+	"go-story/internal/repofake"
+	"go-story/internal/schema"
+	"go-story/internal/seo"
+	"go-story/internal/server"
+)
+
+// fixture 目錄中的檔名：target 的 GraphQL 回應與 local candidate 的 repository 查詢結果
+const (
+	targetFixtureFile = "target.json"
+	repoFixtureFile   = "repo.json"
+)
+
+// localCandidate 是 --candidate local 使用的值
+const localCandidate = "local"
+
+// probeEndpoints 是依 --record / --replay / --candidate local 準備好的兩個 endpoint
+type probeEndpoints struct {
+	target, candidate string
+	client            *http.Client
+
+	targetRecorder *probe.Recorder
+	repoRecorder   *repofake.Recorder
+	replay         *probe.ReplayHandler
+	fakeRepo       *repofake.Repo
+	closers        []func()
+}
+
+// setupProbeEndpoints 準備 target 與 candidate：
+// --replay 以錄好的 target 回應取代 target，並讓 local candidate 使用錄好的 repository 結果（不需資料庫）；
+// --record 記錄 target 的回應，local candidate 則以 DATABASE_URL 查詢並記錄 repository 結果。
+func setupProbeEndpoints(target, candidate, recordDir, replayDir string, client *http.Client) (*probeEndpoints, error) {
+	e := &probeEndpoints{target: target, candidate: candidate, client: client}
+	if recordDir != "" && replayDir != "" {
+		return nil, fmt.Errorf("--record and --replay are mutually exclusive")
+	}
+	if replayDir != "" {
+		f, err := probe.LoadFixture(filepath.Join(replayDir, targetFixtureFile))
+		if err != nil {
+			return nil, err
+		}
+		srv, h := probe.NewReplayServer(f)
+		e.closers = append(e.closers, srv.Close)
+		e.target, e.replay = srv.URL+"/api/graphql", h
+	}
+	if e.target == "" || e.candidate == "" {
+		e.close()
+		return nil, fmt.Errorf("--target (or --replay) and --candidate are required")
+	}
+	if recordDir != "" {
+		if err := os.MkdirAll(recordDir, 0o755); err != nil {
+			e.close()
+			return nil, err
+		}
+		e.targetRecorder = probe.NewRecorder(client.Transport)
+	}
+
+	if candidate == localCandidate {
+		var repo repofake.Source
+		siteURL, siteName := envOr("SITE_URL", config.DefaultSiteURL), envOr("SITE_NAME", config.DefaultSiteName)
+		trimBlocks := 0
+		if replayDir != "" {
+			fake, err := repofake.Load(filepath.Join(replayDir, repoFixtureFile))
+			if err != nil {
+				e.close()
+				return nil, err
+			}
+			repo, e.fakeRepo = fake, fake
+		} else {
+			cfg, err := config.Load()
+			if err != nil {
+				e.close()
+				return nil, fmt.Errorf("local candidate: %w", err)
+			}
+			db, err := data.NewDB(cfg.DatabaseURL)
+			if err != nil {
+				e.close()
+				return nil, fmt.Errorf("local candidate: %w", err)
+			}
+			e.closers = append(e.closers, func() { db.Close() })
+			// 不使用 cache，每個查詢都會經過 repository（錄製時才會完整）
+			dbRepo := data.NewRepo(db, rendition.New(cfg.StaticsHost, cfg.ImageRenditions), nil)
+			// 與服務啟動時相同，依資料庫實際的欄位與 table 決定查詢方式
+			if _, err := dbRepo.DetectCategoryMemberOnly(context.Background()); err != nil {
+				e.close()
+				return nil, fmt.Errorf("local candidate: %w", err)
+			}
+			if _, err := dbRepo.DetectSlugHistory(context.Background()); err != nil {
+				e.close()
+				return nil, fmt.Errorf("local candidate: %w", err)
+			}
+			repo = dbRepo
+			siteURL, siteName, trimBlocks = cfg.SiteURL, cfg.SiteName, cfg.PaywallTrimBlocks
+			if recordDir != "" {
+				e.repoRecorder = repofake.NewRecorder(repo)
+				repo = e.repoRecorder
+			}
+		}
+		srv, err := newLocalCandidate(repo, siteURL, siteName, trimBlocks)
+		if err != nil {
+			e.close()
+			return nil, err
+		}
+		e.closers = append(e.closers, srv.Close)
+		e.candidate = srv.URL + "/api/graphql"
+	}
+	return e, nil
+}
+
+// targetClient 回傳送往 target 的 client；錄製時會經過 Recorder
+func (e *probeEndpoints) targetClient() *http.Client {
+	if e.targetRecorder == nil {
+		return e.client
+	}
+	c := *e.client
+	c.Transport = e.targetRecorder
+	return &c
+}
+
+// finish 寫出錄製的 fixture，並回報重播時沒有錄到的請求
+func (e *probeEndpoints) finish(recordDir, target string, stderr io.Writer) error {
+	if e.replay != nil {
+		if misses := e.replay.Misses(); len(misses) > 0 {
+			fmt.Fprintf(stderr, "probe: %d target requests were not recorded, e.g. %s\n", len(misses), truncate(misses[0], 200))
+		}
+	}
+	if e.fakeRepo != nil {
+		if misses := e.fakeRepo.Misses(); len(misses) > 0 {
+			fmt.Fprintf(stderr, "probe: %d repository queries were not recorded, e.g. %s\n", len(misses), truncate(misses[0], 200))
+		}
+	}
+	if e.targetRecorder != nil {
+		if err := probe.SaveFixture(filepath.Join(recordDir, targetFixtureFile), e.targetRecorder.Fixture(target)); err != nil {
+			return fmt.Errorf("save target fixture: %w", err)
+		}
+	}
+	if e.repoRecorder != nil {
+		if err := e.repoRecorder.Save(filepath.Join(recordDir, repoFixtureFile)); err != nil {
+			return fmt.Errorf("save repo fixture: %w", err)
+		}
+	}
+	return nil
+}
+
+func (e *probeEndpoints) close() {
+	for i := len(e.closers) - 1; i >= 0; i-- {
+		e.closers[i]()
+	}
+}
+
+// newLocalCandidate 以 repo 建立 schema，並以 httptest server 提供 /api/graphql（不啟用預覽與 shadow 模式）
+func newLocalCandidate(repo repofake.Source, siteURL, siteName string, trimBlocks int) (*httptest.Server, error) {
+	lb := links.New(siteURL)
+	gqlSchema, err := schema.Build(repo, schema.Options{
+		TrimBlocks: trimBlocks,
+		SEO:        seo.New(lb, siteName),
+		Redirects:  redirect.New(repo, lb),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("local candidate: build schema: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/api/graphql", server.NewGraphQLHandler(gqlSchema, nil, nil))
+	return httptest.NewServer(mux), nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
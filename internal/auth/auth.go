@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token expired")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+// Claims 是 go-story 會讀取的 JWT claims
+type Claims struct {
+	Subject   string   `json:"sub,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	Expiry    int64    `json:"exp"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	// MemberTier 為會員等級，例如 basic / premium；空字串或 none 代表非會員
+	MemberTier string `json:"member_tier,omitempty"`
+	// Role 為 CMS 角色，editor / admin 可以預覽未發佈內容
+	Role string `json:"role,omitempty"`
+	// PartnerID 為合作夥伴 ID，可以預覽該 partner 的 externals
+	PartnerID string `json:"partner_id,omitempty"`
+	// AgeVerified 表示 caller 已完成年齡驗證，可以讀取成人內容
+	AgeVerified bool `json:"age_verified,omitempty"`
+}
+
+// IsMember 回傳 caller 是否為有效會員；nil claims 代表匿名
+func (c *Claims) IsMember() bool {
+	return c != nil && c.MemberTier != "" && c.MemberTier != "none"
+}
+
+// IsEditor 回傳 caller 是否具有編輯權限
+func (c *Claims) IsEditor() bool {
+	return c != nil && (c.Role == "editor" || c.Role == "admin")
+}
+
+// HasPartner 回傳 caller 是否屬於指定的 partner
+func (c *Claims) HasPartner(partnerID string) bool {
+	return c != nil && c.PartnerID != "" && c.PartnerID == partnerID
+}
+
+// Audience 對應 JWT 的 aud，可以是單一字串或字串陣列
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// Contains 檢查 aud 是否包含指定值
+func (a Audience) Contains(v string) bool {
+	for _, s := range a {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+type ctxKey struct{}
+
+type ageKey struct{}
+
+// NewContext 將已驗證的 claims 放進 context
+func NewContext(ctx context.Context, c *Claims) context.Context {
+	return context.WithValue(ctx, ctxKey{}, c)
+}
+
+// FromContext 取出 claims；匿名 request 回傳 nil
+func FromContext(ctx context.Context) *Claims {
+	c, _ := ctx.Value(ctxKey{}).(*Claims)
+	return c
+}
+
+// WithAgeVerified 標記此 request 已透過簽名的 header 完成年齡驗證
+func WithAgeVerified(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ageKey{}, true)
+}
+
+// AgeVerified 回傳 caller 是否已完成年齡驗證（header 或 token claim）
+func AgeVerified(ctx context.Context) bool {
+	if v, _ := ctx.Value(ageKey{}).(bool); v {
+		return true
+	}
+	c := FromContext(ctx)
+	return c != nil && c.AgeVerified
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// minRefetchInterval 限制遇到未知 kid 時重新抓取 JWKS 的頻率
+const minRefetchInterval = time.Minute
+
+// RemoteKeySet 從 JWKS URL 取得公鑰並快取，超過 ttl 或遇到未知 kid 時重新抓取。
+// 抓取在鎖外進行且同時只有一個；抓取期間已知 kid 繼續使用舊的 keys，不會等待網路。
+type RemoteKeySet struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu   sync.RWMutex
+	keys *KeySet
+	// fetchedAt 是最近一次開始抓取的時間（不論成功與否），用來限制抓取頻率
+	fetchedAt time.Time
+	// inflight 在抓取進行中不為 nil，抓取完成時關閉
+	inflight chan struct{}
+}
+
+// NewRemoteKeySet 建立 RemoteKeySet 並先抓取一次，確認 URL 可用
+func NewRemoteKeySet(url string, ttl time.Duration) (*RemoteKeySet, error) {
+	rks := &RemoteKeySet{
+		url:    url,
+		ttl:    ttl,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	keys, err := rks.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rks.keys, rks.fetchedAt = keys, time.Now()
+	return rks, nil
+}
+
+// Key 以 kid 取得公鑰
+func (r *RemoteKeySet) Key(kid string) (crypto.PublicKey, bool) {
+	keys := r.current()
+	// 超過 ttl 時在背景更新，這次先使用舊的 keys
+	r.refresh(r.ttl)
+	if k, ok := keys.Key(kid); ok {
+		return k, true
+	}
+	// 簽發端可能剛輪替 key，未知 kid 時等待重新抓取（有頻率限制，且與其他 caller 共用同一次抓取）
+	<-r.refresh(minRefetchInterval)
+	return r.current().Key(kid)
+}
+
+func (r *RemoteKeySet) current() *KeySet {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.keys
+}
+
+// refresh 在上次抓取超過 minAge 時於背景重新抓取 JWKS，失敗時保留舊的 keys。
+// 回傳的 channel 在這次（或進行中的）抓取完成時關閉；不需要抓取時回傳已關閉的 channel。
+func (r *RemoteKeySet) refresh(minAge time.Duration) <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.inflight != nil {
+		return r.inflight
+	}
+	if time.Since(r.fetchedAt) <= minAge {
+		return closedChan
+	}
+	done := make(chan struct{})
+	r.inflight = done
+	r.fetchedAt = time.Now()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		keys, err := r.fetch(ctx)
+		if err != nil {
+			log.Printf("[Auth] JWKS fetch failed: %v", err)
+		}
+		r.mu.Lock()
+		if err == nil {
+			r.keys = keys
+		}
+		r.inflight = nil
+		r.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+func (r *RemoteKeySet) fetch(ctx context.Context) (*KeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: status %d", resp.StatusCode)
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read jwks: %w", err)
+	}
+	return ParseJWKS(raw)
+}
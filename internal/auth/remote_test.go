@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// jwksServer 回傳以 kid 改名的 LocalIssuer JWKS；gate 不為 nil 時第二次以後的請求會等到 gate 關閉
+type jwksServer struct {
+	*httptest.Server
+	hits atomic.Int32
+	mu   sync.Mutex
+	kid  string
+	gate chan struct{}
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	t.Helper()
+	issuer, err := NewLocalIssuer("test", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := issuer.JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &jwksServer{kid: "local"}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := s.hits.Add(1)
+		s.mu.Lock()
+		kid, gate := s.kid, s.gate
+		s.mu.Unlock()
+		if gate != nil && n > 1 {
+			<-gate
+		}
+		w.Write([]byte(strings.Replace(string(raw), `"kid":"local"`, `"kid":"`+kid+`"`, 1)))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(kid string, gate chan struct{}) {
+	s.mu.Lock()
+	s.kid, s.gate = kid, gate
+	s.mu.Unlock()
+}
+
+// 超過 ttl 時在背景更新，抓取期間已知 kid 仍立即回傳舊的 key
+func TestRemoteKeySetServesStaleKeysDuringRefresh(t *testing.T) {
+	srv := newJWKSServer(t)
+	rks, err := NewRemoteKeySet(srv.URL, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gate := make(chan struct{})
+	srv.set("local", gate)
+	time.Sleep(5 * time.Millisecond)
+
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 20; i++ {
+			if _, ok := rks.Key("local"); !ok {
+				done <- false
+				return
+			}
+		}
+		done <- true
+	}()
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatal("known kid not found during refresh")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Key blocked on in-flight JWKS fetch")
+	}
+	close(gate)
+	<-rks.refresh(time.Hour)
+	if got := srv.hits.Load(); got != 2 {
+		t.Errorf("fetches = %d, want 2 (one initial, one shared background refresh)", got)
+	}
+}
+
+// 未知 kid 時重新抓取一次並共用結果；minRefetchInterval 內不再抓取
+func TestRemoteKeySetRefetchesOnUnknownKid(t *testing.T) {
+	srv := newJWKSServer(t)
+	rks, err := NewRemoteKeySet(srv.URL, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.set("rotated", nil)
+
+	if _, ok := rks.Key("rotated"); ok {
+		t.Fatal("unknown kid found before minRefetchInterval elapsed")
+	}
+	if got := srv.hits.Load(); got != 1 {
+		t.Fatalf("fetches = %d, want 1", got)
+	}
+
+	rks.mu.Lock()
+	rks.fetchedAt = time.Now().Add(-2 * minRefetchInterval)
+	rks.mu.Unlock()
+
+	var wg sync.WaitGroup
+	var found atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := rks.Key("rotated"); ok {
+				found.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if found.Load() != 10 {
+		t.Errorf("rotated kid found by %d of 10 callers", found.Load())
+	}
+	if got := srv.hits.Load(); got != 2 {
+		t.Errorf("fetches = %d, want 2", got)
+	}
+	if _, ok := rks.Key("local"); ok {
+		t.Error("old kid still present after rotation")
+	}
+}
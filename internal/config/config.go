@@ -6,6 +6,14 @@ import (
 	"os"
 	"strconv"
 	"strings"
+
+	"go-story/internal/rendition"
+)
+
+// SITE_URL 與 SITE_NAME 的預設值
+const (
+	DefaultSiteURL  = "https://www.mirrordaily.news"
+	DefaultSiteName = "鏡報"
 )
 
 // Config holds runtime configuration from environment.
@@ -24,6 +32,47 @@ type Config struct {
 	RedisURL string
 	// REDIS_TTL: Cache TTL (秒)，預設為 3600 (選填)
 	RedisTTL int
+	// PREVIEW_SECRET: 簽發/驗證預覽 token 的 HMAC 金鑰，未設定時停用預覽模式 (選填)
+	PreviewSecret string
+	// AGE_VERIFY_SECRET: 驗證 edge 簽發的 X-Age-Verified header 的 HMAC 金鑰，未設定時只採用 JWT 的 age_verified claim (選填)
+	AgeVerifySecret string
+	// JWKS_FILE: 驗證 bearer JWT 的 JWKS 檔案路徑 (選填)
+	JWKSFile string
+	// JWKS_URL: 驗證 bearer JWT 的 JWKS URL，與 JWKS_FILE 皆未設定時所有 caller 皆視為匿名 (選填)
+	JWKSURL string
+	// JWT_ISSUER / JWT_AUDIENCE: 驗證 JWT 時要求的 iss / aud，未設定時不檢查 (選填)
+	JWTIssuer   string
+	JWTAudience string
+	// PAYWALL_TRIM_BLOCKS: 會員文章 trimmedContent 保留的段落數，預設為 5 (選填)
+	PaywallTrimBlocks int
+	// SITE_URL: 前台網址，用於 feed 等對外連結，預設為 https://www.mirrordaily.news (選填)
+	SiteURL string
+	// SITE_NAME: 網站名稱，用於 feed 標題，預設為 鏡報 (選填)
+	SiteName string
+	// IMAGE_RENDITIONS: 圖片 rendition profiles 的 JSON 陣列（格式、尺寸、URL 樣板），未設定時使用原檔格式與 webP 的五個尺寸 (選填)
+	ImageRenditions []rendition.Profile
+	// SHADOW_URL: shadow 模式轉送的舊 GQL endpoint，未設定時停用 shadow 模式 (選填)
+	ShadowURL string
+	// SHADOW_SAMPLE_RATE: 轉送的請求比例（0–1），預設為 0.01 (選填)
+	ShadowSampleRate float64
+	// SHADOW_RPS / SHADOW_BURST: 送往舊 GQL 的速率上限（每秒請求數）與瞬間上限，預設為 5 / 10 (選填)
+	ShadowRPS   float64
+	ShadowBurst int
+	// SHADOW_LOG_FILE: 不一致紀錄的 JSON Lines 檔案路徑，未設定時寫入標準 log (選填)
+	ShadowLogFile string
+	// PROBE_STORE: 保存 /probe 執行結果的位置，postgres 表示使用 DATABASE_URL 的 "ProbeRun" table，
+	// file:<path> 表示 JSON Lines 檔案；未設定時不保存 (選填)
+	ProbeStore string
+	// COMMIT_SHA: 目前部署的版本，記錄在 probe 結果中 (選填)
+	CommitSHA string
+	// PROBE_ADMIN_TOKEN: 呼叫 /probe、/probe/runs、/probe/report 所需的 bearer token，未設定時停用這些端點 (選填)
+	ProbeAdminToken string
+	// PROBE_ALLOWED_TARGETS: /probe 可使用的 target，以逗號分隔的 URL 或 host，未設定時不允許任何 target (選填)
+	ProbeAllowedTargets []string
+	// PROBE_RATE_LIMIT: 每個 caller 每分鐘可呼叫 /probe 系列端點的次數，預設為 6 (選填)
+	ProbeRateLimit int
+	// PROBE_SELF_URL: /probe 比對的本服務 GraphQL endpoint，預設為 http://127.0.0.1:$PORT/api/graphql (選填)
+	ProbeSelfURL string
 }
 
 // Load reads required environment variables.
@@ -33,6 +82,15 @@ type Config struct {
 // REDIS_ENABLED is optional; defaults to false.
 // REDIS_URL is optional; required if REDIS_ENABLED=true.
 // REDIS_TTL is optional; defaults to 3600 seconds.
+// PREVIEW_SECRET is optional; preview mode is disabled when empty.
+// AGE_VERIFY_SECRET is optional; the X-Age-Verified header is ignored when empty.
+// JWKS_FILE, JWKS_URL, JWT_ISSUER and JWT_AUDIENCE are optional; JWT auth is disabled without a JWKS source.
+// PAYWALL_TRIM_BLOCKS is optional; defaults to 5.
+// SITE_URL and SITE_NAME are optional; default to the Mirror Daily site.
+// IMAGE_RENDITIONS is optional; defaults to rendition.Default().
+// SHADOW_URL, SHADOW_SAMPLE_RATE, SHADOW_RPS, SHADOW_BURST and SHADOW_LOG_FILE are optional; shadow mode is disabled without SHADOW_URL.
+// PROBE_STORE is optional; must be "postgres" or "file:<path>". COMMIT_SHA is optional.
+// PROBE_ADMIN_TOKEN, PROBE_ALLOWED_TARGETS, PROBE_RATE_LIMIT and PROBE_SELF_URL are optional; /probe is disabled without PROBE_ADMIN_TOKEN.
 func Load() (Config, error) {
 	cfg := Config{
 		DatabaseURL: os.Getenv("DATABASE_URL"),
@@ -40,6 +98,22 @@ func Load() (Config, error) {
 		Port:        os.Getenv("PORT"),
 		GoEnv:       os.Getenv("GO_ENV"),
 		RedisURL:    os.Getenv("REDIS_URL"),
+
+		PreviewSecret:   os.Getenv("PREVIEW_SECRET"),
+		AgeVerifySecret: os.Getenv("AGE_VERIFY_SECRET"),
+		JWKSFile:        os.Getenv("JWKS_FILE"),
+		JWKSURL:         os.Getenv("JWKS_URL"),
+		JWTIssuer:       os.Getenv("JWT_ISSUER"),
+		JWTAudience:     os.Getenv("JWT_AUDIENCE"),
+		SiteURL:         os.Getenv("SITE_URL"),
+		SiteName:        os.Getenv("SITE_NAME"),
+		ShadowURL:       os.Getenv("SHADOW_URL"),
+		ShadowLogFile:   os.Getenv("SHADOW_LOG_FILE"),
+		ProbeStore:      os.Getenv("PROBE_STORE"),
+		CommitSHA:       os.Getenv("COMMIT_SHA"),
+
+		ProbeAdminToken: os.Getenv("PROBE_ADMIN_TOKEN"),
+		ProbeSelfURL:    os.Getenv("PROBE_SELF_URL"),
 	}
 
 	if cfg.DatabaseURL == "" {
@@ -62,6 +136,12 @@ func Load() (Config, error) {
 	if cfg.GoEnv == "" {
 		cfg.GoEnv = "dev"
 	}
+	if cfg.SiteURL == "" {
+		cfg.SiteURL = DefaultSiteURL
+	}
+	if cfg.SiteName == "" {
+		cfg.SiteName = DefaultSiteName
+	}
 
 	// 解析 REDIS_ENABLED，預設為 false
 	redisEnabledStr := os.Getenv("REDIS_ENABLED")
@@ -85,6 +165,85 @@ func Load() (Config, error) {
 		cfg.RedisTTL = 3600 // 預設 1 小時
 	}
 
+	if cfg.JWKSFile != "" && cfg.JWKSURL != "" {
+		return Config{}, fmt.Errorf("JWKS_FILE and JWKS_URL are mutually exclusive")
+	}
+
+	// 解析 PAYWALL_TRIM_BLOCKS，預設為 5
+	trimBlocksStr := os.Getenv("PAYWALL_TRIM_BLOCKS")
+	if trimBlocksStr != "" {
+		n, err := strconv.Atoi(trimBlocksStr)
+		if err != nil || n <= 0 {
+			return Config{}, fmt.Errorf("invalid PAYWALL_TRIM_BLOCKS value: %q", trimBlocksStr)
+		}
+		cfg.PaywallTrimBlocks = n
+	} else {
+		cfg.PaywallTrimBlocks = 5
+	}
+
+	if raw := os.Getenv("IMAGE_RENDITIONS"); raw != "" {
+		profiles, err := rendition.Parse([]byte(raw))
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid IMAGE_RENDITIONS value: %v", err)
+		}
+		cfg.ImageRenditions = profiles
+	} else {
+		cfg.ImageRenditions = rendition.Default()
+	}
+
+	if cfg.ShadowURL != "" {
+		if u, err := url.Parse(cfg.ShadowURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Config{}, fmt.Errorf("invalid SHADOW_URL value: %q", cfg.ShadowURL)
+		}
+	}
+	if cfg.ProbeStore != "" && cfg.ProbeStore != "postgres" && !strings.HasPrefix(cfg.ProbeStore, "file:") {
+		return Config{}, fmt.Errorf("invalid PROBE_STORE value: %q (want postgres or file:<path>)", cfg.ProbeStore)
+	}
+
+	for _, t := range strings.Split(os.Getenv("PROBE_ALLOWED_TARGETS"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			cfg.ProbeAllowedTargets = append(cfg.ProbeAllowedTargets, t)
+		}
+	}
+	cfg.ProbeRateLimit = 6
+	if raw := os.Getenv("PROBE_RATE_LIMIT"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return Config{}, fmt.Errorf("invalid PROBE_RATE_LIMIT value: %q", raw)
+		}
+		cfg.ProbeRateLimit = n
+	}
+	if cfg.ProbeSelfURL == "" {
+		cfg.ProbeSelfURL = "http://127.0.0.1:" + cfg.Port + "/api/graphql"
+	} else if u, err := url.Parse(cfg.ProbeSelfURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Config{}, fmt.Errorf("invalid PROBE_SELF_URL value: %q", cfg.ProbeSelfURL)
+	}
+
+	cfg.ShadowSampleRate = 0.01
+	if raw := os.Getenv("SHADOW_SAMPLE_RATE"); raw != "" {
+		rate, err := strconv.ParseFloat(raw, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return Config{}, fmt.Errorf("invalid SHADOW_SAMPLE_RATE value: %q", raw)
+		}
+		cfg.ShadowSampleRate = rate
+	}
+	cfg.ShadowRPS = 5
+	if raw := os.Getenv("SHADOW_RPS"); raw != "" {
+		rps, err := strconv.ParseFloat(raw, 64)
+		if err != nil || rps <= 0 {
+			return Config{}, fmt.Errorf("invalid SHADOW_RPS value: %q", raw)
+		}
+		cfg.ShadowRPS = rps
+	}
+	cfg.ShadowBurst = 10
+	if raw := os.Getenv("SHADOW_BURST"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return Config{}, fmt.Errorf("invalid SHADOW_BURST value: %q", raw)
+		}
+		cfg.ShadowBurst = n
+	}
+
 	return cfg, nil
 }
 
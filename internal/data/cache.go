@@ -119,8 +119,18 @@ func (c *Cache) Get(ctx context.Context, key string, dest interface{}) (bool, er
 	return true, nil
 }
 
+// TTL returns the default expiration used by Set.
+func (c *Cache) TTL() time.Duration {
+	return c.ttl
+}
+
 // Set stores a value in cache.
 func (c *Cache) Set(ctx context.Context, key string, value interface{}) error {
+	return c.SetWithTTL(ctx, key, value, c.ttl)
+}
+
+// SetWithTTL stores a value in cache with a custom expiration.
+func (c *Cache) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
 	if !c.Enabled() {
 		return nil
 	}
@@ -131,14 +141,14 @@ func (c *Cache) Set(ctx context.Context, key string, value interface{}) error {
 		return fmt.Errorf("marshal cache value: %w", err)
 	}
 
-	if err := c.client.Set(ctx, key, data, c.ttl).Err(); err != nil {
+	if err := c.client.Set(ctx, key, data, ttl).Err(); err != nil {
 		c.logError("[Redis] Set error for key %s: %v (disabling cache)", key, err)
 		// 如果寫入失敗，可能是連線問題，將 enabled 設為 false
 		c.enabled = false
 		return nil // 不返回錯誤，讓查詢繼續進行
 	}
 
-	c.logInfo("[Redis] Cache set: %s (TTL: %v)", key, c.ttl)
+	c.logInfo("[Redis] Cache set: %s (TTL: %v)", key, ttl)
 	return nil
 }
 
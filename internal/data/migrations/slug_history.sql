@@ -0,0 +1,36 @@
+-- slug history：slug 被修改時記錄舊 slug，讓 /resolve 可以把舊網址 301 到新的 slug。
+-- 這些 table 由 CMS（Keystone / Prisma）的 migration 管理，請在 CMS 的 migration 中加入這個檔案的內容；
+-- go-story 只讀取 "SlugHistory"，不會在啟動時修改 schema。
+-- 沒有 CMS migration 的環境可以明確執行 `go-story migrate slug-history` 套用（會短暫鎖住下列 table）。
+-- history 只從套用之後開始累積。
+
+CREATE TABLE IF NOT EXISTS "SlugHistory" (
+	id bigserial PRIMARY KEY,
+	kind text NOT NULL,
+	slug text NOT NULL,
+	"targetId" integer NOT NULL,
+	"createdAt" timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS "SlugHistory_kind_slug_idx" ON "SlugHistory" (kind, slug);
+
+CREATE OR REPLACE FUNCTION "recordSlugHistory"() RETURNS trigger AS $$
+BEGIN
+	IF OLD.slug IS NOT NULL AND OLD.slug <> '' AND OLD.slug IS DISTINCT FROM NEW.slug THEN
+		INSERT INTO "SlugHistory" (kind, slug, "targetId") VALUES (TG_ARGV[0], OLD.slug, OLD.id);
+	END IF;
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS "Post_slugHistory" ON "Post";
+CREATE TRIGGER "Post_slugHistory" AFTER UPDATE OF slug ON "Post"
+	FOR EACH ROW EXECUTE FUNCTION "recordSlugHistory"('post');
+DROP TRIGGER IF EXISTS "External_slugHistory" ON "External";
+CREATE TRIGGER "External_slugHistory" AFTER UPDATE OF slug ON "External"
+	FOR EACH ROW EXECUTE FUNCTION "recordSlugHistory"('external');
+DROP TRIGGER IF EXISTS "Topic_slugHistory" ON "Topic";
+CREATE TRIGGER "Topic_slugHistory" AFTER UPDATE OF slug ON "Topic"
+	FOR EACH ROW EXECUTE FUNCTION "recordSlugHistory"('topic');
+DROP TRIGGER IF EXISTS "Section_slugHistory" ON "Section";
+CREATE TRIGGER "Section_slugHistory" AFTER UPDATE OF slug ON "Section"
+	FOR EACH ROW EXECUTE FUNCTION "recordSlugHistory"('section');
@@ -0,0 +1,131 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// PathRecord 是轉址解析所需的最小欄位
+type PathRecord struct {
+	ID       string
+	Slug     string
+	State    string
+	Redirect string // 只有 Post 有 redirect 欄位
+	// Visible 表示內容目前對外公開（已發佈且不在排程中）
+	Visible bool
+}
+
+type pathSource struct {
+	table    string
+	redirect string
+	visible  string
+}
+
+// pathSources 定義可被解析的路徑種類；公開條件與 sitemap 一致
+var pathSources = map[string]pathSource{
+	"post":     {table: "Post", redirect: `COALESCE(redirect, '')`, visible: `state = 'published' AND ("publishedDate" IS NULL OR "publishedDate" <= now())`},
+	"external": {table: "External", redirect: `''`, visible: `state = 'published' AND ("publishedDate" IS NULL OR "publishedDate" <= now())`},
+	"topic":    {table: "Topic", redirect: `''`, visible: `state = 'published'`},
+	"section":  {table: "Section", redirect: `''`, visible: `state = 'active'`},
+}
+
+// QueryPathRecord 以 slug 查詢轉址解析所需的資料；找不到時回傳 nil。
+// 與單筆查詢不同，這裡不套用 published 過濾，由呼叫端依 State 決定 404 或 410。
+func (r *Repo) QueryPathRecord(ctx context.Context, kind, slug string) (*PathRecord, error) {
+	src, ok := pathSources[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown path kind: %s", kind)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT id, slug, COALESCE(state, ''), %s, (%s) FROM %q WHERE slug = $1 LIMIT 1`, src.redirect, src.visible, src.table)
+	var (
+		id  int
+		rec PathRecord
+	)
+	err := r.db.QueryRowContext(ctx, query, slug).Scan(&id, &rec.Slug, &rec.State, &rec.Redirect, &rec.Visible)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rec.ID = strconv.Itoa(id)
+	return &rec, nil
+}
+
+// slugHistoryMigration 建立 "SlugHistory" table 與各內容 table 的 trigger。
+// 這些 table 屬於 CMS 的 schema，應由 CMS 的 migration 套用；服務啟動時只檢查 table 是否存在。
+//
+//go:embed migrations/slug_history.sql
+var slugHistoryMigration string
+
+// MigrateSlugHistory 套用 migrations/slug_history.sql，只由 `go-story migrate slug-history` 明確執行。
+// 套用時會短暫鎖住 Post、External、Topic 與 Section。
+func (r *Repo) MigrateSlugHistory(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, slugHistoryMigration); err != nil {
+		return fmt.Errorf("migrate slug history: %w", err)
+	}
+	return tx.Commit()
+}
+
+// DetectSlugHistory 檢查 "SlugHistory" table 是否存在；不存在時 QuerySlugHistory 不再查詢資料庫
+func (r *Repo) DetectSlugHistory(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var ok bool
+	if err := r.db.QueryRowContext(ctx, `SELECT to_regclass('"SlugHistory"') IS NOT NULL`).Scan(&ok); err != nil {
+		return false, err
+	}
+	r.noSlugHistory = !ok
+	return ok, nil
+}
+
+// QuerySlugHistory 查詢舊 slug 目前對應的 slug。
+// 舊 slug 記錄在 migrations/slug_history.sql 建立的 "SlugHistory"(kind, slug, "targetId") table；
+// table 不存在或沒有紀錄時回傳空字串。
+func (r *Repo) QuerySlugHistory(ctx context.Context, kind, slug string) (string, error) {
+	src, ok := pathSources[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown path kind: %s", kind)
+	}
+	if r.noSlugHistory {
+		return "", nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT t.slug FROM "SlugHistory" h
+		JOIN %q t ON t.id = h."targetId"
+		WHERE h.kind = $1 AND h.slug = $2 AND t.slug <> $2
+		ORDER BY h.id DESC LIMIT 1`, src.table)
+	var current string
+	err := r.db.QueryRowContext(ctx, query, kind, slug).Scan(&current)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "42P01" {
+		// undefined_table：尚未建立 slug history
+		return "", nil
+	}
+	return current, err
+}
@@ -7,8 +7,13 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"go-story/internal/auth"
+	"go-story/internal/preview"
+	"go-story/internal/rendition"
+
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/mitchellh/mapstructure"
@@ -30,13 +35,15 @@ type Resized struct {
 }
 
 type Photo struct {
-	ID            string         `json:"id"`
-	Name          string         `json:"name"`
-	TopicKeywords string         `json:"topicKeywords"`
-	ImageFile     ImageFile      `json:"imageFile"`
-	Resized       Resized        `json:"resized"`
-	ResizedWebp   Resized        `json:"resizedWebp"`
-	Metadata      map[string]any `json:"-"`
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	TopicKeywords string    `json:"topicKeywords"`
+	ImageFile     ImageFile `json:"imageFile"`
+	Resized       Resized   `json:"resized"`
+	ResizedWebp   Resized   `json:"resizedWebp"`
+	// Renditions 是依設定的 rendition profiles 產生的所有尺寸 / 格式
+	Renditions []rendition.Rendition `json:"renditions"`
+	Metadata   map[string]any        `json:"-"`
 }
 
 type Section struct {
@@ -170,7 +177,6 @@ type Post struct {
 	// ApiData / ApiDataBrief 對應 Lilith hooks 中 draftConverter 產生的 JSON 結構
 	ApiDataBrief         interface{}    `json:"apiDataBrief"`
 	ApiData              interface{}    `json:"apiData"`
-	TrimmedContent       map[string]any `json:"trimmedContent"`
 	Content              map[string]any `json:"content"`
 	Relateds             []Post         `json:"relateds"`
 	RelatedsInInputOrder []Post         `json:"relatedsInInputOrder"`
@@ -187,7 +193,26 @@ type Post struct {
 	Topics               *Topic         `json:"topics"`
 	Warning              *Warning       `json:"warning"`
 	Warnings             []Warning      `json:"warnings"`
-	Metadata             map[string]any `json:"-"`
+	// ReadingTimeMinutes / WordCount / ExcerptSource 在 enrichPosts 時計算，隨 Post 一起存入 cache；
+	// ExcerptFromBody 表示摘要來源取自全文（沒有 apiDataBrief），付費文章不可直接給非會員
+	ReadingTimeMinutes int            `json:"readingTimeMinutes"`
+	WordCount          int            `json:"wordCount"`
+	ExcerptSource      string         `json:"excerptSource"`
+	ExcerptFromBody    bool           `json:"excerptFromBody"`
+	Metadata           map[string]any `json:"-"`
+}
+
+// Paywalled 回傳文章是否為會員文章：Post.isMember 或所屬分類為會員限定
+func (p Post) Paywalled() bool {
+	if p.IsMember {
+		return true
+	}
+	for _, c := range p.Categories {
+		if c.IsMemberOnly {
+			return true
+		}
+	}
+	return false
 }
 
 type External struct {
@@ -212,9 +237,10 @@ type External struct {
 
 // Filters
 type StringFilter struct {
-	Equals *string       `mapstructure:"equals"`
-	In     []string      `mapstructure:"in"`
-	Not    *StringFilter `mapstructure:"not"`
+	Equals   *string       `mapstructure:"equals"`
+	In       []string      `mapstructure:"in"`
+	Contains *string       `mapstructure:"contains"`
+	Not      *StringFilter `mapstructure:"not"`
 }
 
 type BooleanFilter struct {
@@ -241,21 +267,41 @@ type CategoryManyRelationFilter struct {
 }
 
 type PartnerWhereInput struct {
-	Slug *StringFilter `mapstructure:"slug"`
+	Slug        *StringFilter  `mapstructure:"slug"`
+	ShowOnIndex *BooleanFilter `mapstructure:"showOnIndex"`
 }
 
 type DateTimeNullableFilter struct {
 	Equals *string                 `mapstructure:"equals"`
+	Lt     *string                 `mapstructure:"lt"`
+	Lte    *string                 `mapstructure:"lte"`
+	Gt     *string                 `mapstructure:"gt"`
+	Gte    *string                 `mapstructure:"gte"`
 	Not    *DateTimeNullableFilter `mapstructure:"not"`
 }
 
+type ContactWhereInput struct {
+	ID *IDFilter `mapstructure:"id"`
+}
+
+type ContactManyRelationFilter struct {
+	Some *ContactWhereInput `mapstructure:"some"`
+}
+
 type PostWhereInput struct {
-	Slug       *StringFilter               `mapstructure:"slug"`
-	Sections   *SectionManyRelationFilter  `mapstructure:"sections"`
-	Categories *CategoryManyRelationFilter `mapstructure:"categories"`
-	State      *StringFilter               `mapstructure:"state"`
-	IsAdult    *BooleanFilter              `mapstructure:"isAdult"`
-	IsMember   *BooleanFilter              `mapstructure:"isMember"`
+	ID            *IDFilter                   `mapstructure:"id"`
+	Slug          *StringFilter               `mapstructure:"slug"`
+	Sections      *SectionManyRelationFilter  `mapstructure:"sections"`
+	Categories    *CategoryManyRelationFilter `mapstructure:"categories"`
+	Tags          *TagManyRelationFilter      `mapstructure:"tags"`
+	Writers       *ContactManyRelationFilter  `mapstructure:"writers"`
+	Topics        *TopicWhereInput            `mapstructure:"topics"`
+	State         *StringFilter               `mapstructure:"state"`
+	Style         *StringFilter               `mapstructure:"style"`
+	PublishedDate *DateTimeNullableFilter     `mapstructure:"publishedDate"`
+	IsAdult       *BooleanFilter              `mapstructure:"isAdult"`
+	IsMember      *BooleanFilter              `mapstructure:"isMember"`
+	IsFeatured    *BooleanFilter              `mapstructure:"isFeatured"`
 }
 
 type PostWhereUniqueInput struct {
@@ -264,14 +310,25 @@ type PostWhereUniqueInput struct {
 }
 
 type ExternalWhereInput struct {
-	Slug          *StringFilter           `mapstructure:"slug"`
-	State         *StringFilter           `mapstructure:"state"`
-	Partner       *PartnerWhereInput      `mapstructure:"partner"`
-	PublishedDate *DateTimeNullableFilter `mapstructure:"publishedDate"`
+	Slug          *StringFilter               `mapstructure:"slug"`
+	State         *StringFilter               `mapstructure:"state"`
+	Partner       *PartnerWhereInput          `mapstructure:"partner"`
+	Sections      *SectionManyRelationFilter  `mapstructure:"sections"`
+	Categories    *CategoryManyRelationFilter `mapstructure:"categories"`
+	Tags          *TagManyRelationFilter      `mapstructure:"tags"`
+	PublishedDate *DateTimeNullableFilter     `mapstructure:"publishedDate"`
+	UpdatedAt     *DateTimeNullableFilter     `mapstructure:"updatedAt"`
 }
 
 type TopicWhereInput struct {
-	State *StringFilter `mapstructure:"state"`
+	ID            *IDFilter                  `mapstructure:"id"`
+	State         *StringFilter              `mapstructure:"state"`
+	Type          *StringFilter              `mapstructure:"type"`
+	Style         *StringFilter              `mapstructure:"style"`
+	IsFeatured    *BooleanFilter             `mapstructure:"isFeatured"`
+	Sections      *SectionManyRelationFilter `mapstructure:"sections"`
+	Tags          *TagManyRelationFilter     `mapstructure:"tags"`
+	PublishedDate *DateTimeNullableFilter    `mapstructure:"publishedDate"`
 }
 
 type TopicWhereUniqueInput struct {
@@ -281,11 +338,19 @@ type TopicWhereUniqueInput struct {
 }
 
 type VideoWhereInput struct {
-	State        *StringFilter          `mapstructure:"state"`
-	IsShorts     *BooleanFilter         `mapstructure:"isShorts"`
-	VideoSection *StringFilter          `mapstructure:"videoSection"`
-	YoutubeUrl   *StringFilter          `mapstructure:"youtubeUrl"`
-	Tags         *TagManyRelationFilter `mapstructure:"tags"`
+	Name          *StringFilter           `mapstructure:"name"`
+	State         *StringFilter           `mapstructure:"state"`
+	IsShorts      *BooleanFilter          `mapstructure:"isShorts"`
+	IsFeed        *BooleanFilter          `mapstructure:"isFeed"`
+	VideoSection  *StringFilter           `mapstructure:"videoSection"`
+	YoutubeUrl    *StringFilter           `mapstructure:"youtubeUrl"`
+	Tags          *TagManyRelationFilter  `mapstructure:"tags"`
+	RelatedPosts  *PostManyRelationFilter `mapstructure:"related_posts"`
+	PublishedDate *DateTimeNullableFilter `mapstructure:"publishedDate"`
+}
+
+type PostManyRelationFilter struct {
+	Some *PostWhereInput `mapstructure:"some"`
 }
 
 type TagManyRelationFilter struct {
@@ -293,11 +358,14 @@ type TagManyRelationFilter struct {
 }
 
 type TagWhereInput struct {
-	ID *IDFilter `mapstructure:"id"`
+	ID   *IDFilter     `mapstructure:"id"`
+	Slug *StringFilter `mapstructure:"slug"`
 }
 
 type IDFilter struct {
-	Equals *string `mapstructure:"equals"`
+	Equals *string  `mapstructure:"equals"`
+	In     []string `mapstructure:"in"`
+	NotIn  []string `mapstructure:"notIn"`
 }
 
 type VideoWhereUniqueInput struct {
@@ -311,11 +379,28 @@ type OrderRule struct {
 
 // Repo wraps DB access.
 type Repo struct {
-	db          *sql.DB
-	staticsHost string
-	cache       *Cache
+	db     *sql.DB
+	images *rendition.Builder
+	cache  *Cache
+	// noSlugHistory 由 DetectSlugHistory 在啟動時設定，"SlugHistory" table 不存在時為 true
+	noSlugHistory bool
+	// memberOnlyColumn 由 DetectCategoryMemberOnly 在啟動時設定，"Category" 有 "isMemberOnly" 欄位時為 true
+	memberOnlyColumn bool
+
+	// nextPublish 快取各 table 下一筆排程內容的上線時間，避免每次寫入 cache 都查詢一次
+	nextPublishMu sync.Mutex
+	nextPublish   map[string]nextPublishEntry
 }
 
+// nextPublishEntry 是 nextPublish 的一筆快取；At 為零值代表沒有排程中的內容
+type nextPublishEntry struct {
+	At        time.Time
+	FetchedAt time.Time
+}
+
+// nextPublishTTL 是 nextPublish 快取的有效時間；期間內新增的排程內容最多晚這麼久才會截短 TTL
+const nextPublishTTL = time.Minute
+
 const timeLayoutMilli = "2006-01-02T15:04:05.000Z07:00"
 
 func NewDB(dsn string) (*sql.DB, error) {
@@ -335,8 +420,124 @@ func NewDB(dsn string) (*sql.DB, error) {
 	return conn, nil
 }
 
-func NewRepo(db *sql.DB, staticsHost string, cache *Cache) *Repo {
-	return &Repo{db: db, staticsHost: staticsHost, cache: cache}
+func NewRepo(db *sql.DB, images *rendition.Builder, cache *Cache) *Repo {
+	if images == nil {
+		images = rendition.New("", nil)
+	}
+	return &Repo{db: db, images: images, cache: cache}
+}
+
+// DetectCategoryMemberOnly 檢查 "Category" 是否有 "isMemberOnly" 欄位。
+// Lilith schema 的 Category 沒有這個欄位；沒有時 Category.IsMemberOnly 一律為 false，
+// 會員文章只由 Post.isMember 判斷，isMemberOnly 過濾條件也會略過。
+func (r *Repo) DetectCategoryMemberOnly(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'Category' AND column_name = 'isMemberOnly')`).Scan(&ok)
+	if err != nil {
+		return false, err
+	}
+	r.memberOnlyColumn = ok
+	return ok, nil
+}
+
+// newConds 回傳依資料庫欄位設定的 sqlConds
+func (r *Repo) newConds() *sqlConds {
+	return &sqlConds{memberOnlyColumn: r.memberOnlyColumn}
+}
+
+// categoryMemberOnly 回傳 SELECT 中 Category.isMemberOnly 的欄位運算式，欄位不存在時為 false
+func (r *Repo) categoryMemberOnly(alias string) string {
+	if r.memberOnlyColumn {
+		return fmt.Sprintf(`COALESCE(%s."isMemberOnly", false)`, alias)
+	}
+	return "false"
+}
+
+// cacheEnabled 回傳此次查詢是否可以使用 cache；預覽模式下完全不讀寫 cache
+func (r *Repo) cacheEnabled(ctx context.Context) bool {
+	return r.cache != nil && r.cache.Enabled() && !preview.Active(ctx)
+}
+
+// visibleUnique 判斷單筆查詢的結果是否可以回傳：
+// 已發佈且 publishedDate 已到的內容都可以回傳，
+// 未發佈或排程中的內容只有在預覽授權範圍內才回傳。
+func visibleUnique(ctx context.Context, kind, state string, publishedAt sql.NullTime, id, slug string) bool {
+	if state == "published" && (!publishedAt.Valid || !publishedAt.Time.After(time.Now())) {
+		return true
+	}
+	return preview.Allows(ctx, kind, id, slug)
+}
+
+// visibleExternal 在 visibleUnique 之外允許 partner 讀取自家未公開的 external；
+// 這樣的讀取視為預覽，回應會帶 Cache-Control: private, no-store，也不讀寫 cache
+func visibleExternal(ctx context.Context, state string, publishedAt sql.NullTime, id, slug string, partner bool) bool {
+	if visibleUnique(ctx, "external", state, publishedAt, id, slug) {
+		return true
+	}
+	if !partner {
+		return false
+	}
+	preview.Grant(ctx, preview.Claims{Kind: "external", ID: id})
+	return true
+}
+
+// cacheTTL 回傳列表 cache 的 TTL：若 table 中有排程發佈的內容，
+// TTL 會被截短到下一筆內容上線的時間點，讓 cache 剛好在那時過期。
+func (r *Repo) cacheTTL(ctx context.Context, table string) time.Duration {
+	return r.CacheTTL(ctx, r.cache.TTL(), table)
+}
+
+// CacheTTL 將 ttl 截短到 tables 中下一筆排程內容上線的時間點；
+// 供 repository 以外、輸出已發佈內容的 cache（feed、sitemap）使用。
+// 下一筆的時間以整個 table 計算、不考慮列表的 where 條件，TTL 只會比需要的短。
+func (r *Repo) CacheTTL(ctx context.Context, ttl time.Duration, tables ...string) time.Duration {
+	for _, table := range tables {
+		next, ok := r.nextPublishAt(ctx, table)
+		if !ok {
+			continue
+		}
+		if until := time.Until(next); until < ttl {
+			ttl = until
+		}
+	}
+	if ttl < time.Second {
+		return time.Second
+	}
+	return ttl
+}
+
+// nextPublishAt 回傳 table 中下一筆排程內容的上線時間，結果快取 nextPublishTTL；
+// 快取的時間已經過去時重新查詢，查詢失敗不快取
+func (r *Repo) nextPublishAt(ctx context.Context, table string) (time.Time, bool) {
+	now := time.Now()
+	r.nextPublishMu.Lock()
+	e, ok := r.nextPublish[table]
+	r.nextPublishMu.Unlock()
+	if ok && now.Sub(e.FetchedAt) < nextPublishTTL && (e.At.IsZero() || e.At.After(now)) {
+		return e.At, !e.At.IsZero()
+	}
+
+	var next sql.NullTime
+	query := fmt.Sprintf(`SELECT MIN("publishedDate") FROM %s WHERE state = 'published' AND "publishedDate" > now()`, pgx.Identifier{table}.Sanitize())
+	if err := r.db.QueryRowContext(ctx, query).Scan(&next); err != nil {
+		return time.Time{}, false
+	}
+	e = nextPublishEntry{FetchedAt: now}
+	if next.Valid {
+		e.At = next.Time
+	}
+	r.nextPublishMu.Lock()
+	if r.nextPublish == nil {
+		r.nextPublish = map[string]nextPublishEntry{}
+	}
+	r.nextPublish[table] = e
+	r.nextPublishMu.Unlock()
+	return e.At, next.Valid
 }
 
 // Decode helpers
@@ -447,9 +648,10 @@ func (r *Repo) QueryPosts(ctx context.Context, where *PostWhereInput, orders []O
 	defer cancel()
 
 	where = ensurePostPublished(where)
+	where = ensureAdultPolicy(ctx, where)
 
 	// 嘗試從 cache 讀取
-	if r.cache != nil && r.cache.Enabled() {
+	if r.cacheEnabled(ctx) {
 		cacheKey := GenerateCacheKey("posts", map[string]interface{}{
 			"where":  where,
 			"orders": orders,
@@ -465,81 +667,10 @@ func (r *Repo) QueryPosts(ctx context.Context, where *PostWhereInput, orders []O
 	sb := strings.Builder{}
 	sb.WriteString(`SELECT id, slug, title, subtitle, state, style, "isMember", "isAdult", "publishedDate", "updatedAt", COALESCE("heroCaption",'') as heroCaption, COALESCE("extend_byline",'') as extend_byline, "heroImage", "heroVideo", brief, "apiDataBrief", "apiData", content, COALESCE(redirect,'') as redirect, COALESCE(og_title,'') as og_title, COALESCE(og_description,'') as og_description, "hiddenAdvertised", "isAdvertised", "isFeatured", topics, "og_image", "relatedsOne", "relatedsTwo", "relatedsThree" FROM "Post" p`)
 
-	conds := []string{}
-	args := []interface{}{}
-	argIdx := 1
-
-	buildStringFilter := func(field string, f *StringFilter) {
-		if f == nil {
-			return
-		}
-		if f.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`%s = $%d`, field, argIdx))
-			args = append(args, *f.Equals)
-			argIdx++
-		}
-		if len(f.In) > 0 {
-			conds = append(conds, fmt.Sprintf(`%s = ANY($%d)`, field, argIdx))
-			args = append(args, f.In)
-			argIdx++
-		}
-	}
-
-	if where != nil {
-		buildStringFilter("slug", where.Slug)
-		buildStringFilter("state", where.State)
-		if where.IsAdult != nil && where.IsAdult.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`"isAdult" = $%d`, argIdx))
-			args = append(args, *where.IsAdult.Equals)
-			argIdx++
-		}
-		if where.IsMember != nil && where.IsMember.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`"isMember" = $%d`, argIdx))
-			args = append(args, *where.IsMember.Equals)
-			argIdx++
-		}
-		if where.Sections != nil && where.Sections.Some != nil {
-			sub := "EXISTS (SELECT 1 FROM \"_Post_sections\" ps JOIN \"Section\" s ON s.id = ps.\"B\" WHERE ps.\"A\" = p.id"
-			if where.Sections.Some.Slug != nil && where.Sections.Some.Slug.Equals != nil {
-				sub += fmt.Sprintf(" AND s.slug = $%d", argIdx)
-				args = append(args, *where.Sections.Some.Slug.Equals)
-				argIdx++
-			}
-			if where.Sections.Some.State != nil && where.Sections.Some.State.Equals != nil {
-				sub += fmt.Sprintf(" AND s.state = $%d", argIdx)
-				args = append(args, *where.Sections.Some.State.Equals)
-				argIdx++
-			}
-			sub += ")"
-			conds = append(conds, sub)
-		}
-		if where.Categories != nil && where.Categories.Some != nil {
-			sub := "EXISTS (SELECT 1 FROM \"_Category_posts\" cp JOIN \"Category\" c ON c.id = cp.\"A\" WHERE cp.\"B\" = p.id"
-			if where.Categories.Some.Slug != nil && where.Categories.Some.Slug.Equals != nil {
-				sub += fmt.Sprintf(" AND c.slug = $%d", argIdx)
-				args = append(args, *where.Categories.Some.Slug.Equals)
-				argIdx++
-			}
-			if where.Categories.Some.State != nil && where.Categories.Some.State.Equals != nil {
-				sub += fmt.Sprintf(" AND c.state = $%d", argIdx)
-				args = append(args, *where.Categories.Some.State.Equals)
-				argIdx++
-			}
-			// isMemberOnly 欄位在資料庫中不存在，跳過此過濾條件
-			// if where.Categories.Some.IsMemberOnly != nil && where.Categories.Some.IsMemberOnly.Equals != nil {
-			// 	sub += fmt.Sprintf(" AND c.\"isMemberOnly\" = $%d", argIdx)
-			// 	args = append(args, *where.Categories.Some.IsMemberOnly.Equals)
-			// 	argIdx++
-			// }
-			sub += ")"
-			conds = append(conds, sub)
-		}
-	}
-
-	if len(conds) > 0 {
-		sb.WriteString(" WHERE ")
-		sb.WriteString(strings.Join(conds, " AND "))
-	}
+	conds := r.newConds()
+	buildPostConds(conds, "p", where)
+	conds.embargo("p")
+	sb.WriteString(conds.where())
 
 	if len(orders) > 0 {
 		sb.WriteString(" ORDER BY ")
@@ -555,7 +686,7 @@ func (r *Repo) QueryPosts(ctx context.Context, where *PostWhereInput, orders []O
 		sb.WriteString(fmt.Sprintf(" OFFSET %d", skip))
 	}
 
-	rows, err := r.db.QueryContext(ctx, sb.String(), args...)
+	rows, err := r.db.QueryContext(ctx, sb.String(), conds.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -624,7 +755,6 @@ func (r *Repo) QueryPosts(ctx context.Context, where *PostWhereInput, orders []O
 		p.ApiDataBrief = decodeJSONBytesAny(apiDataBrief)
 		p.ApiData = decodeJSONBytesAny(apiData)
 		p.Content = decodeJSONBytes(contentRaw)
-		p.TrimmedContent = p.Content
 		p.Metadata = map[string]any{
 			"heroImageID":     nullableInt(heroImageID),
 			"ogImageID":       nullableInt(ogImageID),
@@ -648,14 +778,14 @@ func (r *Repo) QueryPosts(ctx context.Context, where *PostWhereInput, orders []O
 	}
 
 	// 寫入 cache
-	if r.cache != nil && r.cache.Enabled() {
+	if r.cacheEnabled(ctx) {
 		cacheKey := GenerateCacheKey("posts", map[string]interface{}{
 			"where":  where,
 			"orders": orders,
 			"take":   take,
 			"skip":   skip,
 		})
-		_ = r.cache.Set(ctx, cacheKey, posts)
+		_ = r.cache.SetWithTTL(ctx, cacheKey, posts, r.cacheTTL(ctx, "Post"))
 	}
 
 	return posts, nil
@@ -666,80 +796,18 @@ func (r *Repo) QueryPostsCount(ctx context.Context, where *PostWhereInput) (int,
 	defer cancel()
 
 	where = ensurePostPublished(where)
+	where = ensureAdultPolicy(ctx, where)
 
 	sb := strings.Builder{}
 	sb.WriteString(`SELECT COUNT(*) FROM "Post" p`)
 
-	conds := []string{}
-	args := []interface{}{}
-	argIdx := 1
-	buildStringFilter := func(field string, f *StringFilter) {
-		if f == nil {
-			return
-		}
-		if f.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`%s = $%d`, field, argIdx))
-			args = append(args, *f.Equals)
-			argIdx++
-		}
-	}
-	if where != nil {
-		buildStringFilter("slug", where.Slug)
-		buildStringFilter("state", where.State)
-		if where.IsAdult != nil && where.IsAdult.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`"isAdult" = $%d`, argIdx))
-			args = append(args, *where.IsAdult.Equals)
-			argIdx++
-		}
-		if where.IsMember != nil && where.IsMember.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`"isMember" = $%d`, argIdx))
-			args = append(args, *where.IsMember.Equals)
-			argIdx++
-		}
-		if where.Sections != nil && where.Sections.Some != nil {
-			sub := "EXISTS (SELECT 1 FROM \"_Post_sections\" ps JOIN \"Section\" s ON s.id = ps.\"B\" WHERE ps.\"A\" = p.id"
-			if where.Sections.Some.Slug != nil && where.Sections.Some.Slug.Equals != nil {
-				sub += fmt.Sprintf(" AND s.slug = $%d", argIdx)
-				args = append(args, *where.Sections.Some.Slug.Equals)
-				argIdx++
-			}
-			if where.Sections.Some.State != nil && where.Sections.Some.State.Equals != nil {
-				sub += fmt.Sprintf(" AND s.state = $%d", argIdx)
-				args = append(args, *where.Sections.Some.State.Equals)
-				argIdx++
-			}
-			sub += ")"
-			conds = append(conds, sub)
-		}
-		if where.Categories != nil && where.Categories.Some != nil {
-			sub := "EXISTS (SELECT 1 FROM \"_Category_posts\" cp JOIN \"Category\" c ON c.id = cp.\"A\" WHERE cp.\"B\" = p.id"
-			if where.Categories.Some.Slug != nil && where.Categories.Some.Slug.Equals != nil {
-				sub += fmt.Sprintf(" AND c.slug = $%d", argIdx)
-				args = append(args, *where.Categories.Some.Slug.Equals)
-				argIdx++
-			}
-			if where.Categories.Some.State != nil && where.Categories.Some.State.Equals != nil {
-				sub += fmt.Sprintf(" AND c.state = $%d", argIdx)
-				args = append(args, *where.Categories.Some.State.Equals)
-				argIdx++
-			}
-			// isMemberOnly 欄位在資料庫中不存在，跳過此過濾條件
-			// if where.Categories.Some.IsMemberOnly != nil && where.Categories.Some.IsMemberOnly.Equals != nil {
-			// 	sub += fmt.Sprintf(" AND c.\"isMemberOnly\" = $%d", argIdx)
-			// 	args = append(args, *where.Categories.Some.IsMemberOnly.Equals)
-			// 	argIdx++
-			// }
-			sub += ")"
-			conds = append(conds, sub)
-		}
-	}
-	if len(conds) > 0 {
-		sb.WriteString(" WHERE ")
-		sb.WriteString(strings.Join(conds, " AND "))
-	}
+	conds := r.newConds()
+	buildPostConds(conds, "p", where)
+	conds.embargo("p")
+	sb.WriteString(conds.where())
 
 	var count int
-	if err := r.db.QueryRowContext(ctx, sb.String(), args...).Scan(&count); err != nil {
+	if err := r.db.QueryRowContext(ctx, sb.String(), conds.args...).Scan(&count); err != nil {
 		return 0, err
 	}
 	return count, nil
@@ -753,7 +821,7 @@ func (r *Repo) QueryPostByUnique(ctx context.Context, where *PostWhereUniqueInpu
 	defer cancel()
 
 	// 嘗試從 cache 讀取
-	if r.cache != nil && r.cache.Enabled() {
+	if r.cacheEnabled(ctx) {
 		cacheKey := GenerateCacheKey("post:unique", where)
 		var cachedPost *Post
 		if found, _ := r.cache.Get(ctx, cacheKey, &cachedPost); found {
@@ -834,6 +902,9 @@ func (r *Repo) QueryPostByUnique(ctx context.Context, where *PostWhereUniqueInpu
 		return nil, err
 	}
 	p.ID = strconv.Itoa(dbID)
+	if !visibleUnique(ctx, "post", p.State, publishedAt, p.ID, p.Slug) {
+		return nil, nil
+	}
 	if publishedAt.Valid {
 		p.PublishedDate = publishedAt.Time.UTC().Format(timeLayoutMilli)
 	}
@@ -844,7 +915,6 @@ func (r *Repo) QueryPostByUnique(ctx context.Context, where *PostWhereUniqueInpu
 	p.ApiDataBrief = decodeJSONBytesAny(apiDataBrief)
 	p.ApiData = decodeJSONBytesAny(apiData)
 	p.Content = decodeJSONBytes(contentRaw)
-	p.TrimmedContent = p.Content
 	p.Metadata = map[string]any{
 		"heroImageID":     nullableInt(heroImageID),
 		"ogImageID":       nullableInt(ogImageID),
@@ -861,7 +931,7 @@ func (r *Repo) QueryPostByUnique(ctx context.Context, where *PostWhereUniqueInpu
 	p = posts[0]
 
 	// 寫入 cache
-	if r.cache != nil && r.cache.Enabled() {
+	if r.cacheEnabled(ctx) {
 		cacheKey := GenerateCacheKey("post:unique", where)
 		_ = r.cache.Set(ctx, cacheKey, &p)
 	}
@@ -876,7 +946,7 @@ func (r *Repo) QueryExternals(ctx context.Context, where *ExternalWhereInput, or
 	where = ensureExternalPublished(where)
 
 	// 嘗試從 cache 讀取
-	if r.cache != nil && r.cache.Enabled() {
+	if r.cacheEnabled(ctx) {
 		cacheKey := GenerateCacheKey("externals", map[string]interface{}{
 			"where":  where,
 			"orders": orders,
@@ -892,54 +962,14 @@ func (r *Repo) QueryExternals(ctx context.Context, where *ExternalWhereInput, or
 	sb := strings.Builder{}
 	sb.WriteString(`SELECT e.id, e.slug, e.title, e.state, e."publishedDate", e."extend_byline", e.thumb, e."thumbCaption", e.brief, e.content, e.partner, e."updatedAt" FROM "External" e`)
 
-	conds := []string{}
-	args := []interface{}{}
-	argIdx := 1
+	conds := r.newConds()
 	orderUsesPublished := len(orders) == 0 || (len(orders) > 0 && orders[0].Field == "publishedDate")
 	if orderUsesPublished {
-		conds = append(conds, `e."publishedDate" IS NOT NULL`)
-	}
-
-	buildStringFilter := func(field string, f *StringFilter) {
-		if f == nil {
-			return
-		}
-		if f.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`%s = $%d`, field, argIdx))
-			args = append(args, *f.Equals)
-			argIdx++
-		}
-	}
-	if where != nil {
-		buildStringFilter("e.slug", where.Slug)
-		buildStringFilter("e.state", where.State)
-		if where.PublishedDate != nil {
-			if where.PublishedDate.Equals != nil {
-				conds = append(conds, fmt.Sprintf(`e."publishedDate" = $%d`, argIdx))
-				args = append(args, *where.PublishedDate.Equals)
-				argIdx++
-			}
-			if where.PublishedDate.Not != nil {
-				if where.PublishedDate.Not.Equals == nil {
-					conds = append(conds, `e."publishedDate" IS NOT NULL`)
-				} else {
-					conds = append(conds, fmt.Sprintf(`e."publishedDate" <> $%d`, argIdx))
-					args = append(args, *where.PublishedDate.Not.Equals)
-					argIdx++
-				}
-			}
-		}
-		if where.Partner != nil && where.Partner.Slug != nil && where.Partner.Slug.Equals != nil {
-			sb.WriteString(` JOIN "Partner" p ON p.id = e.partner`)
-			conds = append(conds, fmt.Sprintf(`p.slug = $%d`, argIdx))
-			args = append(args, *where.Partner.Slug.Equals)
-			argIdx++
-		}
-	}
-	if len(conds) > 0 {
-		sb.WriteString(" WHERE ")
-		sb.WriteString(strings.Join(conds, " AND "))
+		conds.add(`e."publishedDate" IS NOT NULL`)
 	}
+	buildExternalConds(conds, "e", where)
+	conds.embargo("e")
+	sb.WriteString(conds.where())
 	if len(orders) > 0 {
 		sb.WriteString(" ORDER BY ")
 		sb.WriteString(buildExternalOrder(orders[0]))
@@ -953,7 +983,7 @@ func (r *Repo) QueryExternals(ctx context.Context, where *ExternalWhereInput, or
 		sb.WriteString(fmt.Sprintf(" OFFSET %d", skip))
 	}
 
-	rows, err := r.db.QueryContext(ctx, sb.String(), args...)
+	rows, err := r.db.QueryContext(ctx, sb.String(), conds.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -1021,14 +1051,14 @@ func (r *Repo) QueryExternals(ctx context.Context, where *ExternalWhereInput, or
 	}
 
 	// 寫入 cache
-	if r.cache != nil && r.cache.Enabled() {
+	if r.cacheEnabled(ctx) {
 		cacheKey := GenerateCacheKey("externals", map[string]interface{}{
 			"where":  where,
 			"orders": orders,
 			"take":   take,
 			"skip":   skip,
 		})
-		_ = r.cache.Set(ctx, cacheKey, result)
+		_ = r.cache.SetWithTTL(ctx, cacheKey, result, r.cacheTTL(ctx, "External"))
 	}
 
 	return result, nil
@@ -1040,35 +1070,12 @@ func (r *Repo) QueryExternalsCount(ctx context.Context, where *ExternalWhereInpu
 	where = ensureExternalPublished(where)
 	sb := strings.Builder{}
 	sb.WriteString(`SELECT COUNT(*) FROM "External" e`)
-	conds := []string{}
-	args := []interface{}{}
-	argIdx := 1
-	buildStringFilter := func(field string, f *StringFilter) {
-		if f == nil {
-			return
-		}
-		if f.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`%s = $%d`, field, argIdx))
-			args = append(args, *f.Equals)
-			argIdx++
-		}
-	}
-	if where != nil {
-		buildStringFilter("e.slug", where.Slug)
-		buildStringFilter("e.state", where.State)
-		if where.Partner != nil && where.Partner.Slug != nil && where.Partner.Slug.Equals != nil {
-			sb.WriteString(` JOIN "Partner" p ON p.id = e.partner`)
-			conds = append(conds, fmt.Sprintf(`p.slug = $%d`, argIdx))
-			args = append(args, *where.Partner.Slug.Equals)
-			argIdx++
-		}
-	}
-	if len(conds) > 0 {
-		sb.WriteString(" WHERE ")
-		sb.WriteString(strings.Join(conds, " AND "))
-	}
+	conds := r.newConds()
+	buildExternalConds(conds, "e", where)
+	conds.embargo("e")
+	sb.WriteString(conds.where())
 	var count int
-	if err := r.db.QueryRowContext(ctx, sb.String(), args...).Scan(&count); err != nil {
+	if err := r.db.QueryRowContext(ctx, sb.String(), conds.args...).Scan(&count); err != nil {
 		return 0, err
 	}
 	return count, nil
@@ -1102,7 +1109,23 @@ func (r *Repo) QueryExternalByID(ctx context.Context, id string) (*External, err
 		}
 	}
 
-	query := `SELECT e.id, e.slug, e.title, e.state, e."publishedDate", e."extend_byline", e.thumb, e."thumbCaption", e.brief, e.content, e.partner, e."updatedAt" FROM "External" e WHERE e.id = $1 LIMIT 1`
+	return r.queryExternalOne(ctx, `e.id = $1`, idInt)
+}
+
+// QueryExternalBySlug 依照 slug 取得單一 External，對應 external(where: { slug: $slug }) 查詢。
+func (r *Repo) QueryExternalBySlug(ctx context.Context, slug string) (*External, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if slug == "" {
+		return nil, nil
+	}
+	return r.queryExternalOne(ctx, `e.slug = $1`, slug)
+}
+
+// queryExternalOne 以單一條件查詢 External 並補上關聯資料
+func (r *Repo) queryExternalOne(ctx context.Context, cond string, arg interface{}) (*External, error) {
+	query := `SELECT e.id, e.slug, e.title, e.state, e."publishedDate", e."extend_byline", e.thumb, e."thumbCaption", e.brief, e.content, e.partner, e."updatedAt" FROM "External" e WHERE ` + cond + ` LIMIT 1`
 
 	var (
 		ext       External
@@ -1112,7 +1135,7 @@ func (r *Repo) QueryExternalByID(ctx context.Context, id string) (*External, err
 		partnerID sql.NullInt64
 	)
 
-	if err := r.db.QueryRowContext(ctx, query, idInt).Scan(
+	if err := r.db.QueryRowContext(ctx, query, arg).Scan(
 		&dbID,
 		&ext.Slug,
 		&ext.Title,
@@ -1133,6 +1156,11 @@ func (r *Repo) QueryExternalByID(ctx context.Context, id string) (*External, err
 	}
 
 	ext.ID = strconv.Itoa(dbID)
+	// partner 帳號可以預覽自家的 externals
+	partner := partnerID.Valid && auth.FromContext(ctx).HasPartner(strconv.FormatInt(partnerID.Int64, 10))
+	if !visibleExternal(ctx, ext.State, pubAt, ext.ID, ext.Slug, partner) {
+		return nil, nil
+	}
 	if pubAt.Valid {
 		ext.PublishedDate = pubAt.Time.UTC().Format(timeLayoutMilli)
 	}
@@ -1205,6 +1233,16 @@ func ensurePostPublished(where *PostWhereInput) *PostWhereInput {
 	return where
 }
 
+// ensureAdultPolicy 在 caller 未完成年齡驗證時排除成人文章（isAdult = false）
+func ensureAdultPolicy(ctx context.Context, where *PostWhereInput) *PostWhereInput {
+	if auth.AgeVerified(ctx) {
+		return where
+	}
+	f := false
+	where.IsAdult = &BooleanFilter{Equals: &f}
+	return where
+}
+
 func ensureExternalPublished(where *ExternalWhereInput) *ExternalWhereInput {
 	if where == nil {
 		where = &ExternalWhereInput{}
@@ -1294,6 +1332,10 @@ func buildExternalOrder(rule OrderRule) string {
 		return fmt.Sprintf(`e."publishedDate" %s`, dir)
 	case "updatedAt":
 		return fmt.Sprintf(`e."updatedAt" %s`, dir)
+	case "title":
+		return fmt.Sprintf(`e.title %s`, dir)
+	case "id":
+		return fmt.Sprintf(`e.id %s`, dir)
 	default:
 		return `e."publishedDate" DESC`
 	}
@@ -1400,6 +1442,7 @@ func (r *Repo) enrichPosts(ctx context.Context, posts []Post) error {
 	for i := range posts {
 		p := &posts[i]
 		id, _ := strconv.Atoi(p.ID)
+		applyReadingStats(p)
 		p.Sections = sectionsMap[id]
 		p.SectionsInInputOrder = sectionsMap[id]
 		p.Categories = categoriesMap[id]
@@ -1479,7 +1522,7 @@ func (r *Repo) fetchCategories(ctx context.Context, postIDs []int) (map[int][]Ca
 	if len(postIDs) == 0 {
 		return result, nil
 	}
-	query := `SELECT cp."B" as post_id, c.id, c.name, c.slug, c.state FROM "_Category_posts" cp JOIN "Category" c ON c.id = cp."A" WHERE cp."B" = ANY($1)`
+	query := `SELECT cp."B" as post_id, c.id, c.name, c.slug, c.state, ` + r.categoryMemberOnly("c") + ` FROM "_Category_posts" cp JOIN "Category" c ON c.id = cp."A" WHERE cp."B" = ANY($1)`
 	rows, err := r.db.QueryContext(ctx, query, pqIntArray(postIDs))
 	if err != nil {
 		return result, err
@@ -1488,11 +1531,9 @@ func (r *Repo) fetchCategories(ctx context.Context, postIDs []int) (map[int][]Ca
 	for rows.Next() {
 		var pid int
 		var c Category
-		if err := rows.Scan(&pid, &c.ID, &c.Name, &c.Slug, &c.State); err != nil {
+		if err := rows.Scan(&pid, &c.ID, &c.Name, &c.Slug, &c.State, &c.IsMemberOnly); err != nil {
 			return result, err
 		}
-		// isMemberOnly 欄位在資料庫中不存在，設為預設值 false
-		c.IsMemberOnly = false
 		result[pid] = append(result[pid], c)
 	}
 	return result, rows.Err()
@@ -1575,24 +1616,36 @@ func (r *Repo) fetchPostWarnings(ctx context.Context, postIDs []int) (map[int][]
 	return result, rows.Err()
 }
 
-func (r *Repo) fetchRelatedPosts(ctx context.Context, postIDs []int) (map[int][]Post, []int, error) {
-	result := map[int][]Post{}
-	imageIDs := []int{}
-	if len(postIDs) == 0 {
-		return result, imageIDs, nil
-	}
-	query := `
+// 相關文章只列出已發佈且不在排程中的文章，與 topic / video 的 posts 相同，避免草稿與排程文章的標題、slug 外流
+const (
+	relatedPostsQuery = `
 		SELECT r."A" as post_id, p.id, p.slug, p.title, p."heroImage"
 		FROM "_Post_relateds" r
 		JOIN "Post" p ON p.id = r."B"
-		WHERE r."A" = ANY($1)
+		WHERE r."A" = ANY($1) AND p.state = 'published' AND (p."publishedDate" IS NULL OR p."publishedDate" <= now())
 		UNION
 		SELECT r."B" as post_id, p.id, p.slug, p.title, p."heroImage"
 		FROM "_Post_relateds" r
 		JOIN "Post" p ON p.id = r."A"
-		WHERE r."B" = ANY($1)
+		WHERE r."B" = ANY($1) AND p.state = 'published' AND (p."publishedDate" IS NULL OR p."publishedDate" <= now())
 	`
-	rows, err := r.db.QueryContext(ctx, query, pqIntArray(postIDs))
+	// postsByIDsQuery 用於 relatedsOne / relatedsTwo / relatedsThree
+	postsByIDsQuery       = `SELECT p.id, p.slug, p.title, p."heroImage" FROM "Post" p WHERE p.id = ANY($1) AND p.state = 'published' AND (p."publishedDate" IS NULL OR p."publishedDate" <= now())`
+	externalRelatedsQuery = `
+		SELECT er."A" as external_id, p.id, p.slug, p.title, p."heroImage"
+		FROM "_External_relateds" er
+		JOIN "Post" p ON p.id = er."B"
+		WHERE er."A" = ANY($1) AND p.state = 'published' AND (p."publishedDate" IS NULL OR p."publishedDate" <= now())
+	`
+)
+
+func (r *Repo) fetchRelatedPosts(ctx context.Context, postIDs []int) (map[int][]Post, []int, error) {
+	result := map[int][]Post{}
+	imageIDs := []int{}
+	if len(postIDs) == 0 {
+		return result, imageIDs, nil
+	}
+	rows, err := r.db.QueryContext(ctx, relatedPostsQuery, pqIntArray(postIDs))
 	if err != nil {
 		return result, imageIDs, err
 	}
@@ -1621,7 +1674,7 @@ func (r *Repo) fetchPostsByIDs(ctx context.Context, ids []int) ([]Post, []int, e
 	if len(ids) == 0 {
 		return result, imageIDs, nil
 	}
-	rows, err := r.db.QueryContext(ctx, `SELECT id, slug, title, "heroImage" FROM "Post" WHERE id = ANY($1)`, pqIntArray(ids))
+	rows, err := r.db.QueryContext(ctx, postsByIDsQuery, pqIntArray(ids))
 	if err != nil {
 		return result, imageIDs, err
 	}
@@ -1726,8 +1779,7 @@ func (r *Repo) fetchImages(ctx context.Context, ids []int) (map[int]*Photo, erro
 				Height: int(im.height.Int64),
 			},
 		}
-		photo.Resized = r.buildResizedURLs(im.fileID, im.ext)
-		photo.ResizedWebp = r.buildResizedURLs(im.fileID, "webP")
+		r.setImageURLs(&photo, im.fileID, im.ext)
 		result[im.id] = &photo
 	}
 	return result, rows.Err()
@@ -1772,6 +1824,24 @@ func (r *Repo) QueryPartnerByID(ctx context.Context, id string) (*Partner, error
 	return nil, nil
 }
 
+// QueryNameBySlug 以 slug 查詢 Section / Category / Tag / Partner 的名稱，找不到時回傳空字串
+func (r *Repo) QueryNameBySlug(ctx context.Context, table, slug string) (string, error) {
+	switch table {
+	case "Section", "Category", "Tag", "Partner":
+	default:
+		return "", fmt.Errorf("unsupported table: %s", table)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var name string
+	err := r.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COALESCE(name, '') FROM %q WHERE slug = $1 LIMIT 1`, table), slug).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return name, err
+}
+
 func (r *Repo) fetchExternalSections(ctx context.Context, externalIDs []int) (map[int][]Section, error) {
 	result := map[int][]Section{}
 	if len(externalIDs) == 0 {
@@ -1802,7 +1872,7 @@ func (r *Repo) fetchExternalCategories(ctx context.Context, externalIDs []int) (
 	// 根據實際表名，External 的 categories 是直接關聯 _Category_externals 表
 	// 其中 A 是 Category ID，B 是 External ID
 	query := `
-		SELECT DISTINCT ce."B" as external_id, c.id, c.name, c.slug, c.state
+		SELECT DISTINCT ce."B" as external_id, c.id, c.name, c.slug, c.state, ` + r.categoryMemberOnly("c") + `
 		FROM "_Category_externals" ce
 		JOIN "Category" c ON c.id = ce."A"
 		WHERE ce."B" = ANY($1)
@@ -1817,11 +1887,9 @@ func (r *Repo) fetchExternalCategories(ctx context.Context, externalIDs []int) (
 	for rows.Next() {
 		var eid int
 		var c Category
-		if err := rows.Scan(&eid, &c.ID, &c.Name, &c.Slug, &c.State); err != nil {
+		if err := rows.Scan(&eid, &c.ID, &c.Name, &c.Slug, &c.State, &c.IsMemberOnly); err != nil {
 			return result, err
 		}
-		// isMemberOnly 欄位在資料庫中不存在，設為預設值 false
-		c.IsMemberOnly = false
 		result[eid] = append(result[eid], c)
 	}
 	return result, rows.Err()
@@ -1833,13 +1901,7 @@ func (r *Repo) fetchExternalRelateds(ctx context.Context, externalIDs []int) (ma
 	if len(externalIDs) == 0 {
 		return result, imageIDs, nil
 	}
-	query := `
-		SELECT er."A" as external_id, p.id, p.slug, p.title, p."heroImage"
-		FROM "_External_relateds" er
-		JOIN "Post" p ON p.id = er."B"
-		WHERE er."A" = ANY($1)
-	`
-	rows, err := r.db.QueryContext(ctx, query, pqIntArray(externalIDs))
+	rows, err := r.db.QueryContext(ctx, externalRelatedsQuery, pqIntArray(externalIDs))
 	if err != nil {
 		return result, imageIDs, err
 	}
@@ -1891,32 +1953,39 @@ func pqIntArray(ids []int) interface{} {
 	return arr
 }
 
-func (r *Repo) buildResizedURLs(fileID, ext string) Resized {
-	if fileID == "" {
-		return Resized{}
-	}
-	if ext == "" {
-		ext = "jpg"
-	}
-	host := strings.TrimSuffix(r.staticsHost, "/")
-	makeURL := func(size string, extension string) string {
-		// staticsHost 已經包含 images 路徑，不需要再加 images/ 前綴
-		// 如果 target 的 w1200 是空字串，表示可能不需要生成該尺寸的 URL
-		// 但我們還是生成，以保持一致性
-		filename := fileID
-		if size != "" {
-			filename = fmt.Sprintf("%s-%s", fileID, size)
+// setImageURLs 依 rendition profiles 產生 photo 的 renditions，
+// 並由原檔格式與 webp 的 renditions 填入既有的 resized / resizedWebp 欄位
+func (r *Repo) setImageURLs(photo *Photo, fileID, ext string) {
+	photo.Renditions = r.images.Build(fileID, ext, photo.ImageFile.Width)
+	photo.Resized = legacyResized(photo.Renditions, rendition.FormatOriginal)
+	photo.ResizedWebp = legacyResized(photo.Renditions, "webp")
+}
+
+// legacyResized 將指定格式的 renditions 對應到 Resized 的固定欄位；profile 沒有的尺寸為空字串
+func legacyResized(rs []rendition.Rendition, format string) Resized {
+	var out Resized
+	for _, rd := range rs {
+		if rd.Format != format {
+			continue
+		}
+		if rd.Original {
+			out.Original = rd.URL
+			continue
+		}
+		switch rd.Width {
+		case 480:
+			out.W480 = rd.URL
+		case 800:
+			out.W800 = rd.URL
+		case 1200:
+			out.W1200 = rd.URL
+		case 1600:
+			out.W1600 = rd.URL
+		case 2400:
+			out.W2400 = rd.URL
 		}
-		return fmt.Sprintf("%s/%s.%s", host, filename, extension)
-	}
-	return Resized{
-		Original: makeURL("", ext),
-		W480:     makeURL("w480", ext),
-		W800:     makeURL("w800", ext),
-		W1200:    makeURL("w1200", ext),
-		W1600:    makeURL("w1600", ext),
-		W2400:    makeURL("w2400", ext),
 	}
+	return out
 }
 
 // ensureTopicPublished 確保查詢只返回 published 的 topics
@@ -1959,29 +2028,10 @@ func (r *Repo) QueryTopics(ctx context.Context, where *TopicWhereInput, orders [
 	sb := strings.Builder{}
 	sb.WriteString(`SELECT id, name, slug, "sortOrder", state, "publishedDate", brief, "apiDataBrief", "leading", "heroImage", "heroUrl", "heroVideo", COALESCE(og_title, '') as og_title, COALESCE(og_description, '') as og_description, "og_image", COALESCE(type, 'list') as type, COALESCE(style, '') as style, "isFeatured", COALESCE("title_style", 'feature') as title_style, COALESCE(javascript, '') as javascript, COALESCE(dfp, '') as dfp, COALESCE("mobile_dfp", '') as mobile_dfp, "createdAt" FROM "Topic" t`)
 
-	conds := []string{}
-	args := []interface{}{}
-	argIdx := 1
-
-	buildStringFilter := func(field string, f *StringFilter) {
-		if f == nil {
-			return
-		}
-		if f.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`%s = $%d`, field, argIdx))
-			args = append(args, *f.Equals)
-			argIdx++
-		}
-	}
-
-	if where != nil {
-		buildStringFilter("t.state", where.State)
-	}
-
-	if len(conds) > 0 {
-		sb.WriteString(" WHERE ")
-		sb.WriteString(strings.Join(conds, " AND "))
-	}
+	conds := r.newConds()
+	buildTopicConds(conds, "t", where)
+	conds.embargo("t")
+	sb.WriteString(conds.where())
 
 	if len(orders) > 0 {
 		sb.WriteString(" ORDER BY ")
@@ -2016,7 +2066,7 @@ func (r *Repo) QueryTopics(ctx context.Context, where *TopicWhereInput, orders [
 		sb.WriteString(fmt.Sprintf(" OFFSET %d", skip))
 	}
 
-	rows, err := r.db.QueryContext(ctx, sb.String(), args...)
+	rows, err := r.db.QueryContext(ctx, sb.String(), conds.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -2103,32 +2153,13 @@ func (r *Repo) QueryTopicsCount(ctx context.Context, where *TopicWhereInput) (in
 	sb := strings.Builder{}
 	sb.WriteString(`SELECT COUNT(*) FROM "Topic" t`)
 
-	conds := []string{}
-	args := []interface{}{}
-	argIdx := 1
-
-	buildStringFilter := func(field string, f *StringFilter) {
-		if f == nil {
-			return
-		}
-		if f.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`%s = $%d`, field, argIdx))
-			args = append(args, *f.Equals)
-			argIdx++
-		}
-	}
-
-	if where != nil {
-		buildStringFilter("t.state", where.State)
-	}
-
-	if len(conds) > 0 {
-		sb.WriteString(" WHERE ")
-		sb.WriteString(strings.Join(conds, " AND "))
-	}
+	conds := r.newConds()
+	buildTopicConds(conds, "t", where)
+	conds.embargo("t")
+	sb.WriteString(conds.where())
 
 	var count int
-	err := r.db.QueryRowContext(ctx, sb.String(), args...).Scan(&count)
+	err := r.db.QueryRowContext(ctx, sb.String(), conds.args...).Scan(&count)
 	return count, err
 }
 
@@ -2155,7 +2186,7 @@ func (r *Repo) QueryTopicBySlug(ctx context.Context, slug string) (*Topic, error
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	query := `SELECT id, name, slug, "sortOrder", state, "publishedDate", brief, "apiDataBrief", "leading", "heroImage", "heroUrl", "heroVideo", COALESCE(og_title, '') as og_title, COALESCE(og_description, '') as og_description, "og_image", COALESCE(type, 'list') as type, COALESCE(style, '') as style, "isFeatured", COALESCE("title_style", 'feature') as title_style, COALESCE(javascript, '') as javascript, COALESCE(dfp, '') as dfp, COALESCE("mobile_dfp", '') as mobile_dfp, "createdAt" FROM "Topic" WHERE slug = $1 AND state = 'published'`
+	query := `SELECT id, name, slug, "sortOrder", state, "publishedDate", brief, "apiDataBrief", "leading", "heroImage", "heroUrl", "heroVideo", COALESCE(og_title, '') as og_title, COALESCE(og_description, '') as og_description, "og_image", COALESCE(type, 'list') as type, COALESCE(style, '') as style, "isFeatured", COALESCE("title_style", 'feature') as title_style, COALESCE(javascript, '') as javascript, COALESCE(dfp, '') as dfp, COALESCE("mobile_dfp", '') as mobile_dfp, "createdAt" FROM "Topic" WHERE slug = $1`
 
 	var t Topic
 	var dbID int
@@ -2183,6 +2214,9 @@ func (r *Repo) QueryTopicBySlug(ctx context.Context, slug string) (*Topic, error
 		return nil, err
 	}
 	t.ID = strconv.Itoa(dbID)
+	if !visibleUnique(ctx, "topic", t.State, pubAt, t.ID, t.Slug) {
+		return nil, nil
+	}
 	if sortOrder.Valid {
 		val := int(sortOrder.Int64)
 		t.SortOrder = &val
@@ -2236,7 +2270,7 @@ func (r *Repo) QueryTopicByID(ctx context.Context, id string) (*Topic, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	query := `SELECT id, name, slug, "sortOrder", state, "publishedDate", brief, "apiDataBrief", "leading", "heroImage", "heroUrl", "heroVideo", COALESCE(og_title, '') as og_title, COALESCE(og_description, '') as og_description, "og_image", COALESCE(type, 'list') as type, COALESCE(style, '') as style, "isFeatured", COALESCE("title_style", 'feature') as title_style, COALESCE(javascript, '') as javascript, COALESCE(dfp, '') as dfp, COALESCE("mobile_dfp", '') as mobile_dfp, "createdAt" FROM "Topic" WHERE id = $1 AND state = 'published'`
+	query := `SELECT id, name, slug, "sortOrder", state, "publishedDate", brief, "apiDataBrief", "leading", "heroImage", "heroUrl", "heroVideo", COALESCE(og_title, '') as og_title, COALESCE(og_description, '') as og_description, "og_image", COALESCE(type, 'list') as type, COALESCE(style, '') as style, "isFeatured", COALESCE("title_style", 'feature') as title_style, COALESCE(javascript, '') as javascript, COALESCE(dfp, '') as dfp, COALESCE("mobile_dfp", '') as mobile_dfp, "createdAt" FROM "Topic" WHERE id = $1`
 
 	var t Topic
 	var dbID int
@@ -2264,6 +2298,9 @@ func (r *Repo) QueryTopicByID(ctx context.Context, id string) (*Topic, error) {
 		return nil, err
 	}
 	t.ID = strconv.Itoa(dbID)
+	if !visibleUnique(ctx, "topic", t.State, pubAt, t.ID, t.Slug) {
+		return nil, nil
+	}
 	if sortOrder.Valid {
 		val := int(sortOrder.Int64)
 		t.SortOrder = &val
@@ -2433,8 +2470,7 @@ func (r *Repo) fetchTopicSlideshowImages(ctx context.Context, topicIDs []int) (m
 				Height: int(height.Int64),
 			},
 		}
-		photo.Resized = r.buildResizedURLs(fileID, ext)
-		photo.ResizedWebp = r.buildResizedURLs(fileID, "webP")
+		r.setImageURLs(&photo, fileID, ext)
 		result[topicID] = append(result[topicID], photo)
 	}
 	return result, rows.Err()
@@ -2482,7 +2518,7 @@ func (r *Repo) fetchTopicPosts(ctx context.Context, topicIDs []int) (map[int][]P
 	query := `
 		SELECT p.topics as topic_id, p.id, p.slug, p.title, p."heroImage"
 		FROM "Post" p
-		WHERE p.topics = ANY($1) AND p.state = 'published'
+		WHERE p.topics = ANY($1) AND p.state = 'published' AND (p."publishedDate" IS NULL OR p."publishedDate" <= now())
 		ORDER BY p.topics, p."publishedDate" DESC, p.id DESC
 	`
 	rows, err := r.db.QueryContext(ctx, query, pqIntArray(topicIDs))
@@ -2561,51 +2597,10 @@ func (r *Repo) QueryVideos(ctx context.Context, where *VideoWhereInput, orders [
 	sb := strings.Builder{}
 	sb.WriteString(`SELECT id, COALESCE(name, '') as name, "isShorts", COALESCE("youtubeUrl", '') as youtubeUrl, COALESCE("fileDuration", '') as fileDuration, COALESCE("youtubeDuration", '') as youtubeDuration, COALESCE(content, '') as content, "heroImage", COALESCE(uploader, '') as uploader, COALESCE("uploaderEmail", '') as uploaderEmail, "isFeed", COALESCE("videoSection", 'news') as videoSection, state, "publishedDate", COALESCE("publishedDateString", '') as publishedDateString, "updateTimeStamp", "createdAt", "file_filename" FROM "Video" v`)
 
-	conds := []string{}
-	args := []interface{}{}
-	argIdx := 1
-
-	buildStringFilter := func(field string, f *StringFilter) {
-		if f == nil {
-			return
-		}
-		if f.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`%s = $%d`, field, argIdx))
-			args = append(args, *f.Equals)
-			argIdx++
-		}
-		if f.Not != nil && f.Not.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`%s <> $%d`, field, argIdx))
-			args = append(args, *f.Not.Equals)
-			argIdx++
-		}
-	}
-
-	if where != nil {
-		buildStringFilter("v.state", where.State)
-		buildStringFilter("v.videoSection", where.VideoSection)
-		buildStringFilter("v.youtubeUrl", where.YoutubeUrl)
-		if where.IsShorts != nil && where.IsShorts.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`v."isShorts" = $%d`, argIdx))
-			args = append(args, *where.IsShorts.Equals)
-			argIdx++
-		}
-		if where.Tags != nil && where.Tags.Some != nil && where.Tags.Some.ID != nil && where.Tags.Some.ID.Equals != nil {
-			// 透過 _Video_tags 表查詢（Tag 是 A，Video 是 B）
-			sb.WriteString(` JOIN "_Video_tags" vt ON vt."B" = v.id`)
-			tagID, err := strconv.Atoi(*where.Tags.Some.ID.Equals)
-			if err == nil {
-				conds = append(conds, fmt.Sprintf(`vt."A" = $%d`, argIdx))
-				args = append(args, tagID)
-				argIdx++
-			}
-		}
-	}
-
-	if len(conds) > 0 {
-		sb.WriteString(" WHERE ")
-		sb.WriteString(strings.Join(conds, " AND "))
-	}
+	conds := r.newConds()
+	buildVideoConds(conds, "v", where)
+	conds.embargo("v")
+	sb.WriteString(conds.where())
 
 	if len(orders) > 0 {
 		sb.WriteString(" ORDER BY ")
@@ -2637,7 +2632,7 @@ func (r *Repo) QueryVideos(ctx context.Context, where *VideoWhereInput, orders [
 		sb.WriteString(fmt.Sprintf(" OFFSET %d", skip))
 	}
 
-	rows, err := r.db.QueryContext(ctx, sb.String(), args...)
+	rows, err := r.db.QueryContext(ctx, sb.String(), conds.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -2705,53 +2700,13 @@ func (r *Repo) QueryVideosCount(ctx context.Context, where *VideoWhereInput) (in
 	sb := strings.Builder{}
 	sb.WriteString(`SELECT COUNT(*) FROM "Video" v`)
 
-	conds := []string{}
-	args := []interface{}{}
-	argIdx := 1
-
-	buildStringFilter := func(field string, f *StringFilter) {
-		if f == nil {
-			return
-		}
-		if f.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`%s = $%d`, field, argIdx))
-			args = append(args, *f.Equals)
-			argIdx++
-		}
-		if f.Not != nil && f.Not.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`%s <> $%d`, field, argIdx))
-			args = append(args, *f.Not.Equals)
-			argIdx++
-		}
-	}
-
-	if where != nil {
-		buildStringFilter("v.state", where.State)
-		buildStringFilter("v.videoSection", where.VideoSection)
-		buildStringFilter("v.youtubeUrl", where.YoutubeUrl)
-		if where.IsShorts != nil && where.IsShorts.Equals != nil {
-			conds = append(conds, fmt.Sprintf(`v."isShorts" = $%d`, argIdx))
-			args = append(args, *where.IsShorts.Equals)
-			argIdx++
-		}
-		if where.Tags != nil && where.Tags.Some != nil && where.Tags.Some.ID != nil && where.Tags.Some.ID.Equals != nil {
-			sb.WriteString(` JOIN "_Video_tags" vt ON vt."A" = v.id`)
-			tagID, err := strconv.Atoi(*where.Tags.Some.ID.Equals)
-			if err == nil {
-				conds = append(conds, fmt.Sprintf(`vt."B" = $%d`, argIdx))
-				args = append(args, tagID)
-				argIdx++
-			}
-		}
-	}
-
-	if len(conds) > 0 {
-		sb.WriteString(" WHERE ")
-		sb.WriteString(strings.Join(conds, " AND "))
-	}
+	conds := r.newConds()
+	buildVideoConds(conds, "v", where)
+	conds.embargo("v")
+	sb.WriteString(conds.where())
 
 	var count int
-	err := r.db.QueryRowContext(ctx, sb.String(), args...).Scan(&count)
+	err := r.db.QueryRowContext(ctx, sb.String(), conds.args...).Scan(&count)
 	return count, err
 }
 
@@ -2778,7 +2733,7 @@ func (r *Repo) QueryVideoByID(ctx context.Context, id string) (*Video, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	query := `SELECT id, COALESCE(name, '') as name, "isShorts", COALESCE("youtubeUrl", '') as youtubeUrl, COALESCE("fileDuration", '') as fileDuration, COALESCE("youtubeDuration", '') as youtubeDuration, COALESCE(content, '') as content, "heroImage", COALESCE(uploader, '') as uploader, COALESCE("uploaderEmail", '') as uploaderEmail, "isFeed", COALESCE("videoSection", 'news') as videoSection, state, "publishedDate", COALESCE("publishedDateString", '') as publishedDateString, "updateTimeStamp", "createdAt", "file_filename" FROM "Video" WHERE id = $1 AND state = 'published'`
+	query := `SELECT id, COALESCE(name, '') as name, "isShorts", COALESCE("youtubeUrl", '') as youtubeUrl, COALESCE("fileDuration", '') as fileDuration, COALESCE("youtubeDuration", '') as youtubeDuration, COALESCE(content, '') as content, "heroImage", COALESCE(uploader, '') as uploader, COALESCE("uploaderEmail", '') as uploaderEmail, "isFeed", COALESCE("videoSection", 'news') as videoSection, state, "publishedDate", COALESCE("publishedDateString", '') as publishedDateString, "updateTimeStamp", "createdAt", "file_filename" FROM "Video" WHERE id = $1`
 
 	var v Video
 	var dbID int
@@ -2793,6 +2748,9 @@ func (r *Repo) QueryVideoByID(ctx context.Context, id string) (*Video, error) {
 		return nil, err
 	}
 	v.ID = strconv.Itoa(dbID)
+	if !visibleUnique(ctx, "video", v.State, pubAt, v.ID, "") {
+		return nil, nil
+	}
 	if pubAt.Valid {
 		v.PublishedDate = pubAt.Time.Format(timeLayoutMilli)
 	} else {
@@ -2921,7 +2879,7 @@ func (r *Repo) fetchVideoRelatedPosts(ctx context.Context, videoIDs []int) (map[
 		SELECT prv."B" as video_id, p.id, p.slug, p.title, p."heroImage"
 		FROM "_Post_related_videos" prv
 		JOIN "Post" p ON p.id = prv."A"
-		WHERE prv."B" = ANY($1) AND p.state = 'published'
+		WHERE prv."B" = ANY($1) AND p.state = 'published' AND (p."publishedDate" IS NULL OR p."publishedDate" <= now())
 		ORDER BY prv."B", p."publishedDate" DESC, p.id DESC
 	`
 	rows, err := r.db.QueryContext(ctx, query, pqIntArray(videoIDs))
@@ -0,0 +1,119 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go-story/internal/preview"
+)
+
+// partner 讀取自家未公開的 external 時要標記為預覽，讓 handler 送出 no-store
+func TestVisibleExternalMarksPartnerPreview(t *testing.T) {
+	past := sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true}
+	future := sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}
+	cases := []struct {
+		name      string
+		state     string
+		published sql.NullTime
+		partner   bool
+		visible   bool
+		preview   bool
+	}{
+		{"published", "published", past, false, true, false},
+		{"published for partner", "published", past, true, true, false},
+		{"draft", "draft", sql.NullTime{}, false, false, false},
+		{"draft for partner", "draft", sql.NullTime{}, true, true, true},
+		{"embargoed", "published", future, false, false, false},
+		{"embargoed for partner", "published", future, true, true, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := preview.NewContext(context.Background())
+			if got := visibleExternal(ctx, tc.state, tc.published, "7", "ext", tc.partner); got != tc.visible {
+				t.Errorf("visible = %v, want %v", got, tc.visible)
+			}
+			if got := preview.Active(ctx); got != tc.preview {
+				t.Errorf("preview.Active = %v, want %v", got, tc.preview)
+			}
+		})
+	}
+}
+
+// minConn 是只支援 QueryContext 的假連線，每次查詢都回傳 next 並記錄 SQL
+type minConn struct {
+	next    *time.Time
+	queries *[]string
+}
+
+func (c minConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c minConn) Close() error                        { return nil }
+func (c minConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+func (c minConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	*c.queries = append(*c.queries, query)
+	return &minRows{v: *c.next}, nil
+}
+
+func (c minConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c minConn) Driver() driver.Driver                        { return nil }
+
+type minRows struct {
+	v    time.Time
+	done bool
+}
+
+func (r *minRows) Columns() []string { return []string{"min"} }
+func (r *minRows) Close() error      { return nil }
+func (r *minRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.v
+	return nil
+}
+
+// 下一筆排程內容的時間依 table 快取，不必每次寫入 cache 都查詢
+func TestCacheTTLCachesNextPublish(t *testing.T) {
+	next := time.Now().Add(30 * time.Minute)
+	var queries []string
+	db := sql.OpenDB(minConn{next: &next, queries: &queries})
+	defer db.Close()
+	r := NewRepo(db, nil, nil)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if ttl := r.CacheTTL(ctx, time.Hour, "Post"); ttl > 30*time.Minute || ttl < 29*time.Minute {
+			t.Fatalf("ttl = %v, want about 30m", ttl)
+		}
+	}
+	if len(queries) != 1 || !strings.Contains(queries[0], `FROM "Post" WHERE`) {
+		t.Fatalf("queries = %q, want one query on \"Post\"", queries)
+	}
+
+	// 識別字以雙引號跳脫，而不是 Go 的 %q
+	r.CacheTTL(ctx, time.Hour, `Po"st`)
+	if last := queries[len(queries)-1]; !strings.Contains(last, `FROM "Po""st" WHERE`) {
+		t.Errorf("query = %s, want pq-style quoting", last)
+	}
+
+	// 快取的時間已經過去時重新查詢
+	r.nextPublish["Post"] = nextPublishEntry{At: time.Now().Add(-time.Second), FetchedAt: time.Now()}
+	n := len(queries)
+	r.CacheTTL(ctx, time.Hour, "Post")
+	if len(queries) != n+1 {
+		t.Errorf("passed next publish time was not refreshed")
+	}
+
+	// 超過 nextPublishTTL 也重新查詢
+	r.nextPublish["Post"] = nextPublishEntry{At: next, FetchedAt: time.Now().Add(-2 * nextPublishTTL)}
+	r.CacheTTL(ctx, time.Hour, "Post")
+	if len(queries) != n+2 {
+		t.Errorf("stale next publish time was not refreshed")
+	}
+}
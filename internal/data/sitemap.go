@@ -0,0 +1,172 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SitemapEntry 是 sitemap 中的一筆網址資料
+type SitemapEntry struct {
+	ID      int
+	Key     string // slug；video 以 id 作為網址
+	LastMod time.Time
+}
+
+// SitemapPage 描述一個子 sitemap：After 為 keyset cursor（前一頁最後一筆的 id 之後），
+// LastMod 為該頁內容最新的修改時間（沒有時間欄位的種類為零值）。
+type SitemapPage struct {
+	After   int
+	LastMod time.Time
+}
+
+// NewsEntry 是 Google News sitemap 的一筆文章
+type NewsEntry struct {
+	Slug          string
+	Title         string
+	PublishedDate time.Time
+}
+
+type sitemapSource struct {
+	table   string
+	key     string
+	lastMod string
+	cond    string
+}
+
+// sitemapEmbargo 排除 publishedDate 尚未到達的排程內容
+const sitemapEmbargo = `("publishedDate" IS NULL OR "publishedDate" <= now())`
+
+// sitemapSources 定義每一種 sitemap 對應的 table、網址 key、lastmod 與公開條件。
+// lastmod 只使用 repository 其他查詢也會讀取的欄位：Topic 與 Video 沒有 updatedAt
+// （Video 的 updateTimeStamp 是布林值），以發佈時間或建立時間代替；Section 與 Tag 不輸出 lastmod。
+var sitemapSources = map[string]sitemapSource{
+	"posts":     {table: "Post", key: "slug", lastMod: `"updatedAt"`, cond: `state = 'published' AND "isAdult" = false AND ` + sitemapEmbargo},
+	"externals": {table: "External", key: "slug", lastMod: `"updatedAt"`, cond: `state = 'published' AND ` + sitemapEmbargo},
+	"topics":    {table: "Topic", key: "slug", lastMod: `COALESCE("publishedDate", "createdAt")`, cond: `state = 'published' AND ` + sitemapEmbargo},
+	"videos":    {table: "Video", key: "id::text", lastMod: `COALESCE("publishedDate", "createdAt")`, cond: `state = 'published' AND ` + sitemapEmbargo},
+	"sections":  {table: "Section", key: "slug", lastMod: `NULL::timestamptz`, cond: `state = 'active'`},
+	"tags":      {table: "Tag", key: "slug", lastMod: `NULL::timestamptz`, cond: `TRUE`},
+}
+
+// SitemapKinds 回傳支援的 sitemap 種類（固定順序）
+func SitemapKinds() []string {
+	return []string{"posts", "externals", "topics", "videos", "sections", "tags"}
+}
+
+// SitemapPages 以單次掃描將符合條件的資料依 id 切成每頁 pageSize 筆，回傳每頁的 cursor 與 lastmod
+func (r *Repo) SitemapPages(ctx context.Context, kind string, pageSize int) ([]SitemapPage, error) {
+	src, ok := sitemapSources[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown sitemap kind: %s", kind)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT MIN(id), MAX("lastMod")
+		FROM (
+			SELECT id, %s AS "lastMod", row_number() OVER (ORDER BY id) AS rn
+			FROM %q WHERE %s
+		) s
+		GROUP BY (rn - 1) / $1
+		ORDER BY MIN(id)`, src.lastMod, src.table, src.cond)
+	rows, err := r.db.QueryContext(ctx, query, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pages := []SitemapPage{}
+	for rows.Next() {
+		var (
+			minID   int
+			lastMod sql.NullTime
+		)
+		if err := rows.Scan(&minID, &lastMod); err != nil {
+			return nil, err
+		}
+		page := SitemapPage{After: minID - 1}
+		if lastMod.Valid {
+			page.LastMod = lastMod.Time
+		}
+		pages = append(pages, page)
+	}
+	return pages, rows.Err()
+}
+
+// ScanSitemap 以 keyset（id > after）取得一頁 sitemap 資料
+func (r *Repo) ScanSitemap(ctx context.Context, kind string, after, limit int) ([]SitemapEntry, error) {
+	src, ok := sitemapSources[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown sitemap kind: %s", kind)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT id, %s, %s FROM %q WHERE %s AND id > $1 ORDER BY id LIMIT $2`, src.key, src.lastMod, src.table, src.cond)
+	rows, err := r.db.QueryContext(ctx, query, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []SitemapEntry{}
+	for rows.Next() {
+		var (
+			e       SitemapEntry
+			key     sql.NullString
+			lastMod sql.NullTime
+		)
+		if err := rows.Scan(&e.ID, &key, &lastMod); err != nil {
+			return nil, err
+		}
+		if !key.Valid || key.String == "" {
+			continue
+		}
+		e.Key = key.String
+		if lastMod.Valid {
+			e.LastMod = lastMod.Time
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// ScanNewsSitemap 取得 since 時間內發佈、非成人內容的文章，依發佈時間新到舊排序
+func (r *Repo) ScanNewsSitemap(ctx context.Context, since time.Duration, limit int) ([]NewsEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT slug, title, "publishedDate"
+		FROM "Post"
+		WHERE state = 'published' AND "isAdult" = false
+		  AND "publishedDate" > $1 AND "publishedDate" <= now()
+		ORDER BY "publishedDate" DESC
+		LIMIT $2`, time.Now().Add(-since), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []NewsEntry{}
+	for rows.Next() {
+		var (
+			e     NewsEntry
+			slug  sql.NullString
+			title sql.NullString
+		)
+		if err := rows.Scan(&slug, &title, &e.PublishedDate); err != nil {
+			return nil, err
+		}
+		if !slug.Valid || slug.String == "" {
+			continue
+		}
+		e.Slug = slug.String
+		e.Title = title.String
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,23 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+// sitemap 只列出公開內容：有 publishedDate 的種類都要排除排程中的內容，文章另外排除成人內容
+func TestSitemapSourcesHideUnpublished(t *testing.T) {
+	for _, kind := range SitemapKinds() {
+		src := sitemapSources[kind]
+		embargo := strings.Contains(src.cond, `"publishedDate" <= now()`)
+		if want := kind != "sections" && kind != "tags"; embargo != want {
+			t.Errorf("%s: embargo = %v, want %v (%s)", kind, embargo, want, src.cond)
+		}
+		if src.lastMod == "" {
+			t.Errorf("%s: lastMod is empty", kind)
+		}
+	}
+	if !strings.Contains(sitemapSources["posts"].cond, `"isAdult" = false`) {
+		t.Errorf("posts: adult posts are not excluded: %s", sitemapSources["posts"].cond)
+	}
+}
@@ -0,0 +1,92 @@
+package data
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"go-story/internal/render"
+)
+
+const (
+	// cjkCharsPerMinute / wordsPerMinute 為估算閱讀時間用的閱讀速度
+	cjkCharsPerMinute = 400
+	wordsPerMinute    = 200
+	// excerptMaxRunes 為存入 cache 的摘要來源長度上限，超過時截斷並加上刪節號
+	excerptMaxRunes = 500
+)
+
+// applyReadingStats 依 apiData 計算字數與閱讀時間，並以 apiDataBrief（沒有時退回 apiData）產生摘要來源
+func applyReadingStats(p *Post) {
+	body := render.Text(p.ApiData)
+	cjk, words := countWords(body)
+	p.WordCount = cjk + words
+	p.ReadingTimeMinutes = readingMinutes(cjk, words)
+
+	source := render.Text(p.ApiDataBrief)
+	p.ExcerptFromBody = strings.TrimSpace(source) == ""
+	if p.ExcerptFromBody {
+		source = body
+	}
+	p.ExcerptSource = ExcerptText(source, excerptMaxRunes)
+}
+
+// countWords 分別計算 CJK 字元數與其他語言的單字數：
+// 漢字、假名、諺文每個字元算一個字，其他連續的字母或數字算一個字。
+func countWords(text string) (cjk, words int) {
+	inWord := false
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+				inWord = true
+			}
+		default:
+			inWord = false
+		}
+	}
+	return cjk, words
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// readingMinutes 估算閱讀分鐘數，有內容時至少 1 分鐘
+func readingMinutes(cjk, words int) int {
+	if cjk == 0 && words == 0 {
+		return 0
+	}
+	minutes := float64(cjk)/cjkCharsPerMinute + float64(words)/wordsPerMinute
+	return int(math.Max(1, math.Ceil(minutes)))
+}
+
+// Excerpt 回傳長度不超過 length 個字元的摘要，截斷時加上刪節號
+func (p Post) Excerpt(length int) string {
+	return ExcerptText(p.ExcerptSource, length)
+}
+
+// ExcerptText 將 text 的空白合併後截斷為不超過 length 個字元，截斷時加上刪節號
+func ExcerptText(text string, length int) string {
+	if length <= 0 {
+		return ""
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if len([]rune(text)) <= length {
+		return text
+	}
+	return truncateRunes(text, length) + "…"
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:n]))
+}
@@ -0,0 +1,37 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyReadingStatsExcerptSource(t *testing.T) {
+	paragraph := func(text string) []interface{} {
+		return []interface{}{map[string]interface{}{"type": "unstyled", "content": []interface{}{text}}}
+	}
+
+	p := Post{ApiData: paragraph("body text"), ApiDataBrief: paragraph("brief  text")}
+	applyReadingStats(&p)
+	if p.ExcerptSource != "brief text" || p.ExcerptFromBody {
+		t.Errorf("with brief: source = %q, fromBody = %v", p.ExcerptSource, p.ExcerptFromBody)
+	}
+
+	p = Post{ApiData: paragraph("body text")}
+	applyReadingStats(&p)
+	if p.ExcerptSource != "body text" || !p.ExcerptFromBody {
+		t.Errorf("without brief: source = %q, fromBody = %v", p.ExcerptSource, p.ExcerptFromBody)
+	}
+
+	// 來源超過 excerptMaxRunes 時存入的摘要也要帶刪節號，excerpt(length:) 較長時才不會把截斷的文字當成完整內容
+	p = Post{ApiData: paragraph(strings.Repeat("字", excerptMaxRunes+10))}
+	applyReadingStats(&p)
+	if !strings.HasSuffix(p.ExcerptSource, "…") || len([]rune(p.ExcerptSource)) != excerptMaxRunes+1 {
+		t.Errorf("long source: %d runes, suffix ellipsis = %v", len([]rune(p.ExcerptSource)), strings.HasSuffix(p.ExcerptSource, "…"))
+	}
+	if got := p.Excerpt(1000); got != p.ExcerptSource {
+		t.Errorf("Excerpt(1000) = %d runes, want the stored source", len([]rune(got)))
+	}
+	if got := p.Excerpt(3); got != "字字字…" {
+		t.Errorf("Excerpt(3) = %q", got)
+	}
+}
@@ -0,0 +1,323 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sqlConds 累積 WHERE 條件與對應的參數，placeholder 編號依照 args 的長度遞增，
+// 讓列表查詢與 count 查詢可以共用同一套條件組裝邏輯。
+type sqlConds struct {
+	conds []string
+	args  []interface{}
+	// memberOnlyColumn 表示 "Category" 有 "isMemberOnly" 欄位；沒有時略過 isMemberOnly 過濾條件
+	memberOnlyColumn bool
+}
+
+// sub 回傳與 c 共用參數編號與設定的子條件
+func (c *sqlConds) sub() *sqlConds {
+	return &sqlConds{args: c.args, memberOnlyColumn: c.memberOnlyColumn}
+}
+
+// arg 加入一個參數並回傳對應的 placeholder（例如 $3）
+func (c *sqlConds) arg(v interface{}) string {
+	c.args = append(c.args, v)
+	return fmt.Sprintf("$%d", len(c.args))
+}
+
+func (c *sqlConds) add(cond string) {
+	c.conds = append(c.conds, cond)
+}
+
+// where 回傳 " WHERE ..." 子句，沒有條件時回傳空字串
+func (c *sqlConds) where() string {
+	if len(c.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(c.conds, " AND ")
+}
+
+// exists 以子查詢包住關聯條件，子查詢內的參數與外層共用編號
+func (c *sqlConds) exists(from, join string, build func(sub *sqlConds)) {
+	sub := c.sub()
+	build(sub)
+	c.args = sub.args
+	cond := fmt.Sprintf("EXISTS (SELECT 1 FROM %s WHERE %s", from, join)
+	for _, s := range sub.conds {
+		cond += " AND " + s
+	}
+	c.add(cond + ")")
+}
+
+func (c *sqlConds) stringFilter(field string, f *StringFilter) {
+	if f == nil {
+		return
+	}
+	if f.Equals != nil {
+		c.add(fmt.Sprintf(`%s = %s`, field, c.arg(*f.Equals)))
+	}
+	if len(f.In) > 0 {
+		c.add(fmt.Sprintf(`%s = ANY(%s)`, field, c.arg(f.In)))
+	}
+	if f.Contains != nil {
+		// 使用 strpos 避免處理 LIKE 的萬用字元跳脫
+		c.add(fmt.Sprintf(`strpos(%s, %s) > 0`, field, c.arg(*f.Contains)))
+	}
+	if f.Not != nil {
+		sub := c.sub()
+		sub.stringFilter(field, f.Not)
+		c.args = sub.args
+		if len(sub.conds) > 0 {
+			c.add(fmt.Sprintf(`NOT (%s)`, strings.Join(sub.conds, " AND ")))
+		}
+	}
+}
+
+func (c *sqlConds) booleanFilter(field string, f *BooleanFilter) {
+	if f == nil || f.Equals == nil {
+		return
+	}
+	c.add(fmt.Sprintf(`%s = %s`, field, c.arg(*f.Equals)))
+}
+
+// idFilter 處理數字型 id 欄位；無法轉成整數的 id 不可能符合任何資料
+func (c *sqlConds) idFilter(field string, f *IDFilter) {
+	if f == nil {
+		return
+	}
+	if f.Equals != nil {
+		id, err := strconv.Atoi(*f.Equals)
+		if err != nil {
+			c.add("FALSE")
+		} else {
+			c.add(fmt.Sprintf(`%s = %s`, field, c.arg(id)))
+		}
+	}
+	if f.In != nil {
+		c.add(fmt.Sprintf(`%s = ANY(%s)`, field, c.arg(pqIntArray(parseIDs(f.In)))))
+	}
+	if len(f.NotIn) > 0 {
+		c.add(fmt.Sprintf(`NOT (%s = ANY(%s))`, field, c.arg(pqIntArray(parseIDs(f.NotIn)))))
+	}
+}
+
+func (c *sqlConds) dateTimeFilter(field string, f *DateTimeNullableFilter) {
+	if f == nil {
+		return
+	}
+	if f.Equals != nil {
+		c.add(fmt.Sprintf(`%s = %s`, field, c.arg(*f.Equals)))
+	}
+	if f.Lt != nil {
+		c.add(fmt.Sprintf(`%s < %s`, field, c.arg(*f.Lt)))
+	}
+	if f.Lte != nil {
+		c.add(fmt.Sprintf(`%s <= %s`, field, c.arg(*f.Lte)))
+	}
+	if f.Gt != nil {
+		c.add(fmt.Sprintf(`%s > %s`, field, c.arg(*f.Gt)))
+	}
+	if f.Gte != nil {
+		c.add(fmt.Sprintf(`%s >= %s`, field, c.arg(*f.Gte)))
+	}
+	if f.Not != nil {
+		sub := c.sub()
+		sub.dateTimeFilter(field, f.Not)
+		c.args = sub.args
+		if len(sub.conds) == 0 {
+			// not: { equals: null } 代表 IS NOT NULL；decode 後無法區分 equals: null 與沒有 equals，
+			// 因此沒有其他條件的 not 都視為 IS NOT NULL
+			c.add(fmt.Sprintf(`%s IS NOT NULL`, field))
+		} else {
+			// 與 Prisma 相同，NOT 不會讓欄位為 NULL 的資料符合
+			c.add(fmt.Sprintf(`NOT (%s)`, strings.Join(sub.conds, " AND ")))
+		}
+	}
+}
+
+// embargo 排除 publishedDate 尚未到達的排程內容。列表與 count 一律套用，不論 state 過濾的寫法
+// （equals、in、not 等）；排程內容只能透過預覽授權的單筆查詢讀取。
+// 使用資料庫的 now()，時間不會進入 cache key。
+func (c *sqlConds) embargo(alias string) {
+	c.add(fmt.Sprintf(`(%s."publishedDate" IS NULL OR %s."publishedDate" <= now())`, alias, alias))
+}
+
+func parseIDs(ids []string) []int {
+	result := make([]int, 0, len(ids))
+	for _, s := range ids {
+		if id, err := strconv.Atoi(s); err == nil {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// buildPostConds 將 PostWhereInput 轉成以 alias 為前綴的 SQL 條件
+func buildPostConds(c *sqlConds, alias string, where *PostWhereInput) {
+	if where == nil {
+		return
+	}
+	col := func(name string) string { return alias + "." + name }
+
+	c.idFilter(col("id"), where.ID)
+	c.stringFilter(col("slug"), where.Slug)
+	c.stringFilter(col("state"), where.State)
+	c.stringFilter(col("style"), where.Style)
+	c.booleanFilter(col(`"isAdult"`), where.IsAdult)
+	c.booleanFilter(col(`"isMember"`), where.IsMember)
+	c.booleanFilter(col(`"isFeatured"`), where.IsFeatured)
+	c.dateTimeFilter(col(`"publishedDate"`), where.PublishedDate)
+
+	if where.Sections != nil && where.Sections.Some != nil {
+		c.exists(`"_Post_sections" ps JOIN "Section" s ON s.id = ps."B"`, `ps."A" = `+col("id"), func(sub *sqlConds) {
+			buildSectionConds(sub, "s", where.Sections.Some)
+		})
+	}
+	if where.Categories != nil && where.Categories.Some != nil {
+		c.exists(`"_Category_posts" cp JOIN "Category" c ON c.id = cp."A"`, `cp."B" = `+col("id"), func(sub *sqlConds) {
+			buildCategoryConds(sub, "c", where.Categories.Some)
+		})
+	}
+	if where.Tags != nil && where.Tags.Some != nil {
+		// _Post_tags：A 是 Post，B 是 Tag
+		c.exists(`"_Post_tags" pt JOIN "Tag" tg ON tg.id = pt."B"`, `pt."A" = `+col("id"), func(sub *sqlConds) {
+			buildTagConds(sub, "tg", where.Tags.Some)
+		})
+	}
+	if where.Writers != nil && where.Writers.Some != nil {
+		// _Post_writers：A 是 Contact，B 是 Post
+		c.exists(`"_Post_writers" pw`, `pw."B" = `+col("id"), func(sub *sqlConds) {
+			if where.Writers.Some.ID != nil {
+				sub.idFilter(`pw."A"`, where.Writers.Some.ID)
+			}
+		})
+	}
+	if where.Topics != nil {
+		c.exists(`"Topic" tp`, `tp.id = `+col("topics"), func(sub *sqlConds) {
+			buildTopicConds(sub, "tp", where.Topics)
+		})
+	}
+}
+
+// buildExternalConds 將 ExternalWhereInput 轉成以 alias 為前綴的 SQL 條件
+func buildExternalConds(c *sqlConds, alias string, where *ExternalWhereInput) {
+	if where == nil {
+		return
+	}
+	col := func(name string) string { return alias + "." + name }
+
+	c.stringFilter(col("slug"), where.Slug)
+	c.stringFilter(col("state"), where.State)
+	c.dateTimeFilter(col(`"publishedDate"`), where.PublishedDate)
+	c.dateTimeFilter(col(`"updatedAt"`), where.UpdatedAt)
+
+	if where.Partner != nil {
+		c.exists(`"Partner" pt`, `pt.id = `+col("partner"), func(sub *sqlConds) {
+			sub.stringFilter("pt.slug", where.Partner.Slug)
+			sub.booleanFilter(`pt."showOnIndex"`, where.Partner.ShowOnIndex)
+		})
+	}
+	if where.Sections != nil && where.Sections.Some != nil {
+		// _External_sections：A 是 External，B 是 Section
+		c.exists(`"_External_sections" es JOIN "Section" s ON s.id = es."B"`, `es."A" = `+col("id"), func(sub *sqlConds) {
+			buildSectionConds(sub, "s", where.Sections.Some)
+		})
+	}
+	if where.Categories != nil && where.Categories.Some != nil {
+		// _Category_externals：A 是 Category，B 是 External
+		c.exists(`"_Category_externals" ce JOIN "Category" c ON c.id = ce."A"`, `ce."B" = `+col("id"), func(sub *sqlConds) {
+			buildCategoryConds(sub, "c", where.Categories.Some)
+		})
+	}
+	if where.Tags != nil && where.Tags.Some != nil {
+		// _External_tags：A 是 External，B 是 Tag
+		c.exists(`"_External_tags" et JOIN "Tag" tg ON tg.id = et."B"`, `et."A" = `+col("id"), func(sub *sqlConds) {
+			buildTagConds(sub, "tg", where.Tags.Some)
+		})
+	}
+}
+
+func buildSectionConds(c *sqlConds, alias string, where *SectionWhereInput) {
+	if where == nil {
+		return
+	}
+	c.stringFilter(alias+".slug", where.Slug)
+	c.stringFilter(alias+".state", where.State)
+}
+
+func buildCategoryConds(c *sqlConds, alias string, where *CategoryWhereInput) {
+	if where == nil {
+		return
+	}
+	c.stringFilter(alias+".slug", where.Slug)
+	c.stringFilter(alias+".state", where.State)
+	if c.memberOnlyColumn {
+		c.booleanFilter(alias+`."isMemberOnly"`, where.IsMemberOnly)
+	}
+}
+
+func buildTagConds(c *sqlConds, alias string, where *TagWhereInput) {
+	if where == nil {
+		return
+	}
+	c.idFilter(alias+".id", where.ID)
+	c.stringFilter(alias+".slug", where.Slug)
+}
+
+// buildTopicConds 將 TopicWhereInput 轉成以 alias 為前綴的 SQL 條件
+func buildTopicConds(c *sqlConds, alias string, where *TopicWhereInput) {
+	if where == nil {
+		return
+	}
+	col := func(name string) string { return alias + "." + name }
+
+	c.idFilter(col("id"), where.ID)
+	c.stringFilter(col("state"), where.State)
+	c.stringFilter(col("type"), where.Type)
+	c.stringFilter(col("style"), where.Style)
+	c.booleanFilter(col(`"isFeatured"`), where.IsFeatured)
+	c.dateTimeFilter(col(`"publishedDate"`), where.PublishedDate)
+
+	if where.Sections != nil && where.Sections.Some != nil {
+		// _Section_topics：A 是 Section，B 是 Topic
+		c.exists(`"_Section_topics" st JOIN "Section" s ON s.id = st."A"`, `st."B" = `+col("id"), func(sub *sqlConds) {
+			buildSectionConds(sub, "s", where.Sections.Some)
+		})
+	}
+	if where.Tags != nil && where.Tags.Some != nil {
+		// _Tag_topics：A 是 Tag，B 是 Topic
+		c.exists(`"_Tag_topics" tt JOIN "Tag" tg ON tg.id = tt."A"`, `tt."B" = `+col("id"), func(sub *sqlConds) {
+			buildTagConds(sub, "tg", where.Tags.Some)
+		})
+	}
+}
+
+// buildVideoConds 將 VideoWhereInput 轉成以 alias 為前綴的 SQL 條件
+func buildVideoConds(c *sqlConds, alias string, where *VideoWhereInput) {
+	if where == nil {
+		return
+	}
+	col := func(name string) string { return alias + "." + name }
+
+	c.stringFilter(col("name"), where.Name)
+	c.stringFilter(col("state"), where.State)
+	c.stringFilter(col(`"videoSection"`), where.VideoSection)
+	c.stringFilter(col(`"youtubeUrl"`), where.YoutubeUrl)
+	c.booleanFilter(col(`"isShorts"`), where.IsShorts)
+	c.booleanFilter(col(`"isFeed"`), where.IsFeed)
+	c.dateTimeFilter(col(`"publishedDate"`), where.PublishedDate)
+
+	if where.Tags != nil && where.Tags.Some != nil {
+		// _Video_tags：A 是 Tag，B 是 Video
+		c.exists(`"_Video_tags" vt JOIN "Tag" tg ON tg.id = vt."A"`, `vt."B" = `+col("id"), func(sub *sqlConds) {
+			buildTagConds(sub, "tg", where.Tags.Some)
+		})
+	}
+	if where.RelatedPosts != nil && where.RelatedPosts.Some != nil {
+		// _Post_related_videos：A 是 Post，B 是 Video
+		c.exists(`"_Post_related_videos" prv JOIN "Post" rp ON rp.id = prv."A"`, `prv."B" = `+col("id"), func(sub *sqlConds) {
+			buildPostConds(sub, "rp", where.RelatedPosts.Some)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmbargoAppliesToEveryStateFilter(t *testing.T) {
+	published, draft := "published", "draft"
+	cases := map[string]*PostWhereInput{
+		"default":    ensurePostPublished(nil),
+		"equals":     {State: &StringFilter{Equals: &published}},
+		"in":         {State: &StringFilter{In: []string{"published"}}},
+		"not draft":  {State: &StringFilter{Not: &StringFilter{Equals: &draft}}},
+		"no state":   {},
+		"other only": {Slug: &StringFilter{Contains: &published}},
+	}
+	for name, where := range cases {
+		t.Run(name, func(t *testing.T) {
+			conds := &sqlConds{}
+			buildPostConds(conds, "p", where)
+			conds.embargo("p")
+			if sql := conds.where(); !strings.Contains(sql, `p."publishedDate" <= now()`) {
+				t.Errorf("embargo missing: %s", sql)
+			}
+		})
+	}
+}
+
+// Lilith schema 的 Category 沒有 isMemberOnly，只有偵測到欄位時才能產生對應的條件
+func TestCategoryMemberOnlyFilterNeedsColumn(t *testing.T) {
+	yes := true
+	where := &PostWhereInput{Categories: &CategoryManyRelationFilter{Some: &CategoryWhereInput{IsMemberOnly: &BooleanFilter{Equals: &yes}}}}
+	for _, column := range []bool{false, true} {
+		conds := &sqlConds{memberOnlyColumn: column}
+		buildPostConds(conds, "p", where)
+		if got := strings.Contains(conds.where(), `"isMemberOnly"`); got != column {
+			t.Errorf("memberOnlyColumn=%v: %s", column, conds.where())
+		}
+	}
+}
+
+// 相關文章的每個查詢分支都要排除草稿與排程中的文章
+func TestRelatedPostQueriesHideUnpublished(t *testing.T) {
+	const visible = `p.state = 'published' AND (p."publishedDate" IS NULL OR p."publishedDate" <= now())`
+	cases := map[string]struct {
+		query    string
+		branches int
+	}{
+		"relateds":              {relatedPostsQuery, 2},
+		"relatedsOne/Two/Three": {postsByIDsQuery, 1},
+		"external relateds":     {externalRelatedsQuery, 1},
+	}
+	for name, tc := range cases {
+		if got := strings.Count(tc.query, visible); got != tc.branches {
+			t.Errorf("%s: visibility condition appears %d times, want %d:\n%s", name, got, tc.branches, tc.query)
+		}
+	}
+}
+
+// not 要否定巢狀的所有條件，不能一律變成 IS NOT NULL
+func TestDateTimeFilterNot(t *testing.T) {
+	d1, d2 := "2024-01-01T00:00:00Z", "2024-02-01T00:00:00Z"
+	cases := []struct {
+		name   string
+		filter *DateTimeNullableFilter
+		want   string
+		args   int
+	}{
+		{"equals null", &DateTimeNullableFilter{Not: &DateTimeNullableFilter{}}, `d IS NOT NULL`, 0},
+		{"equals", &DateTimeNullableFilter{Not: &DateTimeNullableFilter{Equals: &d1}}, `NOT (d = $1)`, 1},
+		{"range", &DateTimeNullableFilter{Not: &DateTimeNullableFilter{Gte: &d1, Lt: &d2}}, `NOT (d < $1 AND d >= $2)`, 2},
+		{"with outer condition", &DateTimeNullableFilter{Gt: &d1, Not: &DateTimeNullableFilter{Lte: &d2}}, `d > $1 AND NOT (d <= $2)`, 2},
+		{"double not", &DateTimeNullableFilter{Not: &DateTimeNullableFilter{Not: &DateTimeNullableFilter{}}}, `NOT (d IS NOT NULL)`, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &sqlConds{}
+			c.dateTimeFilter("d", tc.filter)
+			if got := strings.Join(c.conds, " AND "); got != tc.want {
+				t.Errorf("conds = %s, want %s", got, tc.want)
+			}
+			if len(c.args) != tc.args {
+				t.Errorf("args = %v, want %d", c.args, tc.args)
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+// Package feed 將文章列表輸出為 RSS 2.0、Atom 1.0 與 JSON Feed 1.1。
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// Feed 是與輸出格式無關的 feed 內容
+type Feed struct {
+	Title       string
+	Link        string // 對應的網頁
+	FeedURL     string // feed 本身的網址
+	Description string
+	Language    string
+	Updated     time.Time
+	Items       []Item
+}
+
+// Item 是 feed 中的單一項目
+type Item struct {
+	ID          string
+	Title       string
+	Link        string
+	Summary     string
+	ContentHTML string
+	Published   time.Time
+	Updated     time.Time
+	Authors     []string
+	Categories  []string
+	Enclosure   *Enclosure
+}
+
+// Enclosure 是項目的附件（主圖）
+type Enclosure struct {
+	URL    string
+	Type   string
+	Length int64
+}
+
+// Format 是 feed 輸出格式
+type Format string
+
+const (
+	FormatRSS  Format = "rss"
+	FormatAtom Format = "atom"
+	FormatJSON Format = "json"
+)
+
+// ContentType 回傳格式對應的 Content-Type
+func (f Format) ContentType() string {
+	return f.mediaType() + "; charset=utf-8"
+}
+
+// Encode 依格式輸出 feed
+func Encode(f *Feed, format Format) ([]byte, error) {
+	switch format {
+	case FormatRSS:
+		return RSS(f)
+	case FormatAtom:
+		return Atom(f)
+	case FormatJSON:
+		return JSON(f)
+	default:
+		return nil, fmt.Errorf("unsupported feed format: %s", format)
+	}
+}
+
+// RSS 2.0
+
+type rssDoc struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	ContentNS string     `xml:"xmlns:content,attr"`
+	DCNS      string     `xml:"xmlns:dc,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	AtomLink      rssLink   `xml:"atom:link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	Description string        `xml:"description,omitempty"`
+	Content     *cdata        `xml:"content:encoded,omitempty"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Creators    []string      `xml:"dc:creator,omitempty"` // RSS 的 author 必須是 email，作者名稱改用 dc:creator
+	Categories  []string      `xml:"category,omitempty"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length int64  `xml:"length,attr"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+// RSS 輸出 RSS 2.0（含 content:encoded 全文）
+func RSS(f *Feed) ([]byte, error) {
+	doc := rssDoc{
+		Version:   "2.0",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		DCNS:      "http://purl.org/dc/elements/1.1/",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        f.Link,
+			AtomLink:    rssLink{Href: f.FeedURL, Rel: "self", Type: FormatRSS.mediaType()},
+			Description: f.Description,
+			Language:    f.Language,
+		},
+	}
+	if !f.Updated.IsZero() {
+		doc.Channel.LastBuildDate = f.Updated.Format(time.RFC1123Z)
+	}
+	for _, it := range f.Items {
+		item := rssItem{
+			Title:       it.Title,
+			Link:        it.Link,
+			GUID:        rssGUID{Value: it.Link, IsPermaLink: true},
+			Description: it.Summary,
+			Creators:    it.Authors,
+			Categories:  it.Categories,
+		}
+		if it.ContentHTML != "" {
+			item.Content = &cdata{Value: it.ContentHTML}
+		}
+		if !it.Published.IsZero() {
+			item.PubDate = it.Published.Format(time.RFC1123Z)
+		}
+		if it.Enclosure != nil {
+			item.Enclosure = &rssEnclosure{URL: it.Enclosure.URL, Type: it.Enclosure.Type, Length: it.Enclosure.Length}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+	return marshalXML(doc)
+}
+
+// Atom 1.0
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	NS      string      `xml:"xmlns,attr"`
+	Lang    string      `xml:"xml:lang,attr,omitempty"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Authors    []atomAuthor   `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category,omitempty"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+// Atom 輸出 Atom 1.0
+func Atom(f *Feed) ([]byte, error) {
+	doc := atomFeed{
+		NS:      "http://www.w3.org/2005/Atom",
+		Lang:    f.Language,
+		ID:      f.FeedURL,
+		Title:   f.Title,
+		Updated: atomTime(f.Updated),
+		Links: []atomLink{
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+			{Href: f.FeedURL, Rel: "self", Type: FormatAtom.mediaType()},
+		},
+	}
+	for _, it := range f.Items {
+		updated := it.Updated
+		if updated.IsZero() {
+			updated = it.Published
+		}
+		entry := atomEntry{
+			ID:      it.Link,
+			Title:   it.Title,
+			Updated: atomTime(updated),
+			Links:   []atomLink{{Href: it.Link, Rel: "alternate", Type: "text/html"}},
+		}
+		if !it.Published.IsZero() {
+			entry.Published = atomTime(it.Published)
+		}
+		for _, a := range it.Authors {
+			entry.Authors = append(entry.Authors, atomAuthor{Name: a})
+		}
+		for _, c := range it.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: c})
+		}
+		if it.Summary != "" {
+			entry.Summary = &atomText{Type: "text", Value: it.Summary}
+		}
+		if it.ContentHTML != "" {
+			entry.Content = &atomText{Type: "html", Value: it.ContentHTML}
+		}
+		if it.Enclosure != nil {
+			entry.Links = append(entry.Links, atomLink{Href: it.Enclosure.URL, Rel: "enclosure", Type: it.Enclosure.Type, Length: it.Enclosure.Length})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return marshalXML(doc)
+}
+
+// JSON Feed 1.1
+
+type jsonFeed struct {
+	Version     string     `json:"version"`
+	Title       string     `json:"title"`
+	HomePageURL string     `json:"home_page_url,omitempty"`
+	FeedURL     string     `json:"feed_url,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Language    string     `json:"language,omitempty"`
+	Items       []jsonItem `json:"items"`
+}
+
+type jsonItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url,omitempty"`
+	Title         string       `json:"title,omitempty"`
+	ContentHTML   string       `json:"content_html,omitempty"`
+	Summary       string       `json:"summary,omitempty"`
+	Image         string       `json:"image,omitempty"`
+	DatePublished string       `json:"date_published,omitempty"`
+	DateModified  string       `json:"date_modified,omitempty"`
+	Authors       []jsonAuthor `json:"authors,omitempty"`
+	Tags          []string     `json:"tags,omitempty"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+// JSON 輸出 JSON Feed 1.1
+func JSON(f *Feed) ([]byte, error) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Language:    f.Language,
+		Items:       []jsonItem{},
+	}
+	for _, it := range f.Items {
+		item := jsonItem{
+			ID:          it.Link,
+			URL:         it.Link,
+			Title:       it.Title,
+			ContentHTML: it.ContentHTML,
+			Summary:     it.Summary,
+			Tags:        it.Categories,
+		}
+		if it.Enclosure != nil {
+			item.Image = it.Enclosure.URL
+		}
+		if !it.Published.IsZero() {
+			item.DatePublished = it.Published.Format(time.RFC3339)
+		}
+		if !it.Updated.IsZero() {
+			item.DateModified = it.Updated.Format(time.RFC3339)
+		}
+		for _, a := range it.Authors {
+			item.Authors = append(item.Authors, jsonAuthor{Name: a})
+		}
+		doc.Items = append(doc.Items, item)
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func (f Format) mediaType() string {
+	switch f {
+	case FormatAtom:
+		return "application/atom+xml"
+	case FormatJSON:
+		return "application/feed+json"
+	default:
+		return "application/rss+xml"
+	}
+}
+
+func atomTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
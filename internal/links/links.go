@@ -0,0 +1,70 @@
+// Package links 依 SITE_URL 組出前台的公開網址，讓 feed、sitemap 與 SEO 欄位共用同一套規則。
+package links
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Builder 以網站根網址組出各類內容的 canonical URL
+type Builder struct {
+	base string
+}
+
+// New 建立 Builder；siteURL 結尾的斜線會被移除
+func New(siteURL string) *Builder {
+	return &Builder{base: strings.TrimRight(siteURL, "/")}
+}
+
+// Home 回傳網站首頁
+func (b *Builder) Home() string {
+	return b.base + "/"
+}
+
+// Abs 將站內路徑轉為絕對網址
+func (b *Builder) Abs(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return b.base + path
+}
+
+// Rel 將本站的絕對網址轉回站內路徑；u 已是站內路徑時原樣回傳，其他網站的網址回傳 false
+func (b *Builder) Rel(u string) (string, bool) {
+	switch {
+	case strings.HasPrefix(u, "/") && !strings.HasPrefix(u, "//"):
+		return u, true
+	case b.base != "" && (u == b.base || strings.HasPrefix(u, b.base+"/")):
+		return "/" + strings.TrimPrefix(strings.TrimPrefix(u, b.base), "/"), true
+	default:
+		return "", false
+	}
+}
+
+// Post 文章頁
+func (b *Builder) Post(slug string) string { return b.item("story", slug) }
+
+// External 合作夥伴文章頁
+func (b *Builder) External(slug string) string { return b.item("external", slug) }
+
+// Topic 專題頁
+func (b *Builder) Topic(slug string) string { return b.item("topic", slug) }
+
+// Video 影音頁（以 id 定位）
+func (b *Builder) Video(id string) string { return b.item("video", id) }
+
+// Section 大分類頁
+func (b *Builder) Section(slug string) string { return b.item("section", slug) }
+
+// Category 分類頁
+func (b *Builder) Category(slug string) string { return b.item("category", slug) }
+
+// Tag 標籤頁
+func (b *Builder) Tag(slug string) string { return b.item("tag", slug) }
+
+// Partner 合作夥伴列表頁
+func (b *Builder) Partner(slug string) string { return b.item("externals", slug) }
+
+func (b *Builder) item(prefix, key string) string {
+	return b.Abs("/" + prefix + "/" + url.PathEscape(key) + "/")
+}
@@ -0,0 +1,158 @@
+package preview
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HeaderName 是攜帶預覽 token 的 HTTP header
+const HeaderName = "X-Preview-Token"
+
+var (
+	ErrDisabled     = errors.New("preview disabled (PREVIEW_SECRET not set)")
+	ErrInvalidToken = errors.New("invalid preview token")
+	ErrExpiredToken = errors.New("preview token expired")
+)
+
+// Claims 描述一個預覽 token 授權的單一內容。
+// Kind 為 post / external / topic / video，ID 與 Slug 至少需有一個。
+type Claims struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id,omitempty"`
+	Slug string `json:"slug,omitempty"`
+	Exp  int64  `json:"exp"`
+}
+
+// Signer 以 HMAC-SHA256 簽發與驗證預覽 token。
+// token 格式：base64url(JSON claims) + "." + base64url(HMAC(secret, 前段))
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner 建立 Signer；secret 為空時回傳 nil，代表不啟用預覽模式。
+func NewSigner(secret string) *Signer {
+	if secret == "" {
+		return nil
+	}
+	return &Signer{secret: []byte(secret)}
+}
+
+// Sign 簽發 token，ttl 決定到期時間
+func (s *Signer) Sign(c Claims, ttl time.Duration) (string, error) {
+	if s == nil {
+		return "", ErrDisabled
+	}
+	if c.Kind == "" || (c.ID == "" && c.Slug == "") {
+		return "", fmt.Errorf("preview claims need kind and id or slug")
+	}
+	c.Exp = time.Now().Add(ttl).Unix()
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	return body + "." + s.sign(body), nil
+}
+
+// Verify 驗證簽章與到期時間，成功時回傳 claims
+func (s *Signer) Verify(token string) (*Claims, error) {
+	if s == nil {
+		return nil, ErrDisabled
+	}
+	body, sig, ok := strings.Cut(token, ".")
+	if !ok || body == "" || sig == "" {
+		return nil, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.sign(body))) {
+		return nil, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var c Claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if c.Kind == "" || (c.ID == "" && c.Slug == "") {
+		return nil, ErrInvalidToken
+	}
+	if time.Now().Unix() >= c.Exp {
+		return nil, ErrExpiredToken
+	}
+	return &c, nil
+}
+
+func (s *Signer) sign(body string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// state 記錄單一 request 內被授權的預覽內容；
+// 由 handler 建立，resolver 也可以在解析 preview 參數後加入授權。
+type state struct {
+	mu     sync.Mutex
+	grants []Claims
+}
+
+type ctxKey struct{}
+
+// NewContext 在 context 中建立空的預覽狀態
+func NewContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &state{})
+}
+
+func fromContext(ctx context.Context) *state {
+	st, _ := ctx.Value(ctxKey{}).(*state)
+	return st
+}
+
+// Grant 將已驗證的 claims 加入目前 request 的預覽授權
+func Grant(ctx context.Context, c Claims) {
+	st := fromContext(ctx)
+	if st == nil {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.grants = append(st.grants, c)
+}
+
+// Active 回傳目前 request 是否處於預覽模式（至少有一個授權）
+func Active(ctx context.Context) bool {
+	st := fromContext(ctx)
+	if st == nil {
+		return false
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return len(st.grants) > 0
+}
+
+// Allows 檢查指定內容是否在預覽授權範圍內
+func Allows(ctx context.Context, kind, id, slug string) bool {
+	st := fromContext(ctx)
+	if st == nil {
+		return false
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, c := range st.grants {
+		if c.Kind != kind {
+			continue
+		}
+		if (c.ID != "" && c.ID == id) || (c.Slug != "" && c.Slug == slug) {
+			return true
+		}
+	}
+	return false
+}
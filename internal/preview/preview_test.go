@@ -0,0 +1,136 @@
+package preview
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	s := NewSigner("secret")
+	token, err := s.Sign(Claims{Kind: "post", ID: "12"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.Verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Kind != "post" || c.ID != "12" || c.Slug != "" {
+		t.Errorf("claims = %+v", c)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	s := NewSigner("secret")
+	valid, err := s.Sign(Claims{Kind: "post", ID: "12"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := s.Sign(Claims{Kind: "post", ID: "12"}, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewSigner("other").Sign(Claims{Kind: "post", ID: "12"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, sig, _ := strings.Cut(valid, ".")
+	flipped := "A"
+	if strings.HasSuffix(sig, "A") {
+		flipped = "B"
+	}
+	// 改寫 claims 但沿用原本的簽章
+	tampered := base64.RawURLEncoding.EncodeToString([]byte(`{"kind":"post","id":"13","exp":9999999999}`)) + "." + sig
+	// 以正確的 secret 簽章、但缺少 id 與 slug 的 claims
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"kind":"post","exp":9999999999}`))
+	noTarget := payload + "." + s.sign(payload)
+	notJSON := base64.RawURLEncoding.EncodeToString([]byte("post:12")) + "." + s.sign(base64.RawURLEncoding.EncodeToString([]byte("post:12")))
+
+	cases := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"expired", expired, ErrExpiredToken},
+		{"wrong secret", other, ErrInvalidToken},
+		{"tampered payload", tampered, ErrInvalidToken},
+		{"tampered signature", body + "." + sig[:len(sig)-1] + flipped, ErrInvalidToken},
+		{"missing signature", body, ErrInvalidToken},
+		{"empty body", "." + sig, ErrInvalidToken},
+		{"no id or slug", noTarget, ErrInvalidToken},
+		{"payload is not json", notJSON, ErrInvalidToken},
+		{"empty", "", ErrInvalidToken},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := s.Verify(tc.token)
+			if !errors.Is(err, tc.want) || c != nil {
+				t.Errorf("Verify = %+v, %v; want %v", c, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestSignRequiresTarget(t *testing.T) {
+	s := NewSigner("secret")
+	for _, c := range []Claims{{ID: "12"}, {Kind: "post"}} {
+		if _, err := s.Sign(c, time.Hour); err == nil {
+			t.Errorf("Sign(%+v) succeeded", c)
+		}
+	}
+}
+
+// 沒有設定 PREVIEW_SECRET 時 signer 為 nil，簽發與驗證都回傳 ErrDisabled
+func TestNilSigner(t *testing.T) {
+	s := NewSigner("")
+	if s != nil {
+		t.Fatal("NewSigner(\"\") should return nil")
+	}
+	if _, err := s.Sign(Claims{Kind: "post", ID: "12"}, time.Hour); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Sign = %v, want ErrDisabled", err)
+	}
+	token, _ := NewSigner("secret").Sign(Claims{Kind: "post", ID: "12"}, time.Hour)
+	if _, err := s.Verify(token); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Verify = %v, want ErrDisabled", err)
+	}
+}
+
+func TestAllowsScope(t *testing.T) {
+	if Active(context.Background()) || Allows(context.Background(), "post", "12", "") {
+		t.Fatal("context without preview state allows preview")
+	}
+	ctx := NewContext(context.Background())
+	if Active(ctx) {
+		t.Fatal("empty preview state is active")
+	}
+	Grant(ctx, Claims{Kind: "post", ID: "12"})
+	Grant(ctx, Claims{Kind: "topic", Slug: "election"})
+	if !Active(ctx) {
+		t.Fatal("preview state with grants is not active")
+	}
+	cases := []struct {
+		kind, id, slug string
+		want           bool
+	}{
+		{"post", "12", "", true},
+		{"post", "12", "any-slug", true},
+		{"post", "13", "", false},
+		{"external", "12", "", false},
+		{"video", "12", "", false},
+		{"topic", "", "election", true},
+		{"topic", "99", "election", true},
+		{"topic", "", "other", false},
+		{"post", "", "election", false},
+		// 沒有 slug 的授權不能以空字串的 slug 比對成功
+		{"post", "", "", false},
+	}
+	for _, tc := range cases {
+		if got := Allows(ctx, tc.kind, tc.id, tc.slug); got != tc.want {
+			t.Errorf("Allows(%s, %q, %q) = %v, want %v", tc.kind, tc.id, tc.slug, got, tc.want)
+		}
+	}
+}
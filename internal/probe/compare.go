@@ -0,0 +1,390 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxNoteOps 是 note 中摘要列出的差異數量
+const maxNoteOps = 3
+
+// PatchOp 是 JSON Patch（RFC 6902）格式的一筆差異，套用到 target 的 data 後會得到 self 的 data。
+// Old 為 target 原本的值，僅供閱讀，不屬於 JSON Patch 標準。
+type PatchOp struct {
+	Op    string
+	Path  string
+	Value interface{}
+	Old   interface{}
+}
+
+// MarshalJSON 依 op 輸出欄位：remove 沒有 value，add 沒有 old
+func (p PatchOp) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{"op": p.Op, "path": p.Path}
+	if p.Op != "remove" {
+		m["value"] = p.Value
+	}
+	if p.Op != "add" {
+		m["old"] = p.Old
+	}
+	return json.Marshal(m)
+}
+
+func (p PatchOp) String() string {
+	switch p.Op {
+	case "add":
+		return fmt.Sprintf("add %s = %s", p.Path, preview(p.Value))
+	case "remove":
+		return fmt.Sprintf("remove %s (was %s)", p.Path, preview(p.Old))
+	default:
+		return fmt.Sprintf("replace %s: %s -> %s", p.Path, preview(p.Old), preview(p.Value))
+	}
+}
+
+// Compare 比對 target 與 self 的回應，回傳是否一致、摘要說明與完整的 data 差異
+func Compare(target Result, self Result, opts CompareOptions) (bool, string, []PatchOp) {
+	// If either has transport error
+	if target.Error != "" || self.Error != "" {
+		return target.Error == "" && self.Error == "", "transport error", nil
+	}
+	if target.StatusCode != self.StatusCode {
+		return false, "status code differ", nil
+	}
+
+	// 解析 GraphQL response 結構
+	type gqlResponse struct {
+		Data   interface{} `json:"data"`
+		Errors interface{} `json:"errors"`
+	}
+
+	var targetResp, selfResp gqlResponse
+	if err := decodeJSON(target.Body, &targetResp); err != nil {
+		return false, fmt.Sprintf("target JSON parse error: %v", err), nil
+	}
+	if err := decodeJSON(self.Body, &selfResp); err != nil {
+		return false, fmt.Sprintf("self JSON parse error: %v", err), nil
+	}
+
+	// 檢查 errors：如果兩邊都有 errors 或都沒有 errors，繼續比對 data
+	// 如果一邊有 errors 另一邊沒有，則不 match
+	targetHasErrors := targetResp.Errors != nil && !isEmptyValue(targetResp.Errors)
+	selfHasErrors := selfResp.Errors != nil && !isEmptyValue(selfResp.Errors)
+	if targetHasErrors != selfHasErrors {
+		return false, fmt.Sprintf("errors mismatch: target has errors=%v, self has errors=%v", targetHasErrors, selfHasErrors), nil
+	}
+
+	ops := Diff(targetResp.Data, selfResp.Data, opts)
+	if len(ops) == 0 {
+		return true, "", nil
+	}
+	parts := make([]string, 0, maxNoteOps)
+	for i, op := range ops {
+		if i == maxNoteOps {
+			break
+		}
+		parts = append(parts, op.String())
+	}
+	note := fmt.Sprintf("data differ (%d changes): %s", len(ops), strings.Join(parts, "; "))
+	if len(ops) > maxNoteOps {
+		note += "; ..."
+	}
+	return false, note, ops
+}
+
+// decodeJSON 以 json.Number 保留數字原文，避免大整數 ID 失去精度
+func decodeJSON(raw []byte, dest interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	return dec.Decode(dest)
+}
+
+// isEmptyValue 檢查值是否為空（nil, 空陣列, 空 map）
+func isEmptyValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() == 0
+	case reflect.String:
+		return rv.Len() == 0
+	}
+	return false
+}
+
+// Diff 依 opts 的路徑規則比對兩份 data，回傳把 a 轉成 b 的 JSON Patch
+func Diff(a, b interface{}, opts CompareOptions) []PatchOp {
+	d := &differ{rules: compileRules(opts.rules())}
+	d.diff(a, b, nil, "")
+	return d.ops
+}
+
+type compiledRule struct {
+	PathRule
+	pattern []string
+}
+
+func compileRules(rules []PathRule) []compiledRule {
+	out := make([]compiledRule, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, compiledRule{PathRule: r, pattern: splitPath(r.Path)})
+	}
+	return out
+}
+
+type differ struct {
+	rules []compiledRule
+	ops   []PatchOp
+}
+
+// rule 合併所有符合 path 的規則，後面的規則覆蓋前面的設定
+func (d *differ) rule(path []string) PathRule {
+	r := PathRule{}
+	for _, pr := range d.rules {
+		if !matchPath(pr.pattern, path) {
+			continue
+		}
+		if pr.Ordered != nil {
+			r.Ordered = pr.Ordered
+		}
+		if pr.Ignore {
+			r.Ignore = true
+		}
+		if pr.NumericID {
+			r.NumericID = true
+		}
+		if pr.Tolerance > 0 {
+			r.Tolerance = pr.Tolerance
+		}
+	}
+	return r
+}
+
+func (d *differ) add(op, ptr string, value, old interface{}) {
+	d.ops = append(d.ops, PatchOp{Op: op, Path: ptr, Value: value, Old: old})
+}
+
+// diff 比對 a、b；path 為套用規則用的路徑（陣列索引以 [] 表示），ptr 為 JSON Pointer
+func (d *differ) diff(a, b interface{}, path []string, ptr string) {
+	r := d.rule(path)
+	if r.Ignore {
+		return
+	}
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			d.add("replace", ptr, b, a)
+			return
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childPath := append(path[:len(path):len(path)], k)
+			childPtr := ptr + "/" + escapePointer(k)
+			if d.rule(childPath).Ignore {
+				continue
+			}
+			aChild, inA := av[k]
+			bChild, inB := bv[k]
+			switch {
+			case !inB:
+				d.add("remove", childPtr, nil, aChild)
+			case !inA:
+				d.add("add", childPtr, bChild, nil)
+			default:
+				d.diff(aChild, bChild, childPath, childPtr)
+			}
+		}
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			d.add("replace", ptr, b, a)
+			return
+		}
+		itemPath := append(path[:len(path):len(path)], "[]")
+		if r.Ordered == nil || *r.Ordered {
+			d.diffOrdered(av, bv, itemPath, ptr)
+		} else {
+			d.diffUnordered(av, bv, itemPath, ptr)
+		}
+	default:
+		if !d.scalarEqual(a, b, r) {
+			d.add("replace", ptr, b, a)
+		}
+	}
+}
+
+func (d *differ) diffOrdered(a, b []interface{}, itemPath []string, ptr string) {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		d.diff(a[i], b[i], itemPath, ptr+"/"+strconv.Itoa(i))
+	}
+	// 由後往前 remove，讓 patch 可以依序套用
+	for i := len(a) - 1; i >= n; i-- {
+		d.add("remove", ptr+"/"+strconv.Itoa(i), nil, a[i])
+	}
+	for i := n; i < len(b); i++ {
+		d.add("add", ptr+"/-", b[i], nil)
+	}
+}
+
+// diffUnordered 先配對完全相同的元素，剩下的依原順序兩兩比對，多出來的再 remove / add
+func (d *differ) diffUnordered(a, b []interface{}, itemPath []string, ptr string) {
+	usedB := make([]bool, len(b))
+	var restA []int
+	for i, ae := range a {
+		found := false
+		for j, be := range b {
+			if !usedB[j] && d.equal(ae, be, itemPath) {
+				usedB[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			restA = append(restA, i)
+		}
+	}
+	var restB []int
+	for j := range b {
+		if !usedB[j] {
+			restB = append(restB, j)
+		}
+	}
+	n := len(restA)
+	if len(restB) < n {
+		n = len(restB)
+	}
+	for k := 0; k < n; k++ {
+		d.diff(a[restA[k]], b[restB[k]], itemPath, ptr+"/"+strconv.Itoa(restA[k]))
+	}
+	for k := len(restA) - 1; k >= n; k-- {
+		d.add("remove", ptr+"/"+strconv.Itoa(restA[k]), nil, a[restA[k]])
+	}
+	for k := n; k < len(restB); k++ {
+		d.add("add", ptr+"/-", b[restB[k]], nil)
+	}
+}
+
+// equal 以相同規則比對兩個值，但不記錄差異
+func (d *differ) equal(a, b interface{}, path []string) bool {
+	sub := &differ{rules: d.rules}
+	sub.diff(a, b, path, "")
+	return len(sub.ops) == 0
+}
+
+// scalarEqual 比對字串、數字、布林與 null。
+// NumericID 時 "12" 與 12 視為相同；Tolerance 為數字的容許誤差。
+func (d *differ) scalarEqual(a, b interface{}, r PathRule) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	an, aNum := number(a, r.NumericID)
+	bn, bNum := number(b, r.NumericID)
+	if aNum && bNum {
+		if an == bn {
+			return true
+		}
+		af, errA := strconv.ParseFloat(an, 64)
+		bf, errB := strconv.ParseFloat(bn, 64)
+		if errA != nil || errB != nil {
+			return false
+		}
+		return math.Abs(af-bf) <= r.Tolerance
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return false
+	}
+	return a == b
+}
+
+// number 回傳數字的原文；numericID 時也接受純數字字串
+func number(v interface{}, numericID bool) (string, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		return n.String(), true
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64), true
+	case string:
+		if numericID {
+			if _, err := strconv.ParseInt(n, 10, 64); err == nil {
+				return n, true
+			}
+		}
+	}
+	return "", false
+}
+
+// splitPath 將 posts[].heroImage.id 拆成 ["posts", "[]", "heroImage", "id"]
+func splitPath(p string) []string {
+	var parts []string
+	for _, seg := range strings.Split(p, ".") {
+		if name, ok := strings.CutSuffix(seg, "[]"); ok {
+			if name != "" {
+				parts = append(parts, name)
+			}
+			parts = append(parts, "[]")
+			continue
+		}
+		if seg != "" {
+			parts = append(parts, seg)
+		}
+	}
+	return parts
+}
+
+// matchPath 比對規則路徑與實際路徑：* 符合任一段，** 符合零或多段
+func matchPath(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchPath(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if pattern[0] != "*" && pattern[0] != path[0] {
+		return false
+	}
+	return matchPath(pattern[1:], path[1:])
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// preview 將值轉為最多 100 字元的 JSON，供 note 與文字報表使用
+func preview(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	if len(b) > 100 {
+		return string(b[:100]) + "..."
+	}
+	return string(b)
+}
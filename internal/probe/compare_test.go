@@ -0,0 +1,278 @@
+package probe
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func decodeData(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := decodeJSON([]byte(s), &v); err != nil {
+		t.Fatalf("decode %s: %v", s, err)
+	}
+	return v
+}
+
+// opsString 以 PatchOp.String 串接差異，方便在表格中描述預期結果
+func opsString(ops []PatchOp) string {
+	parts := make([]string, 0, len(ops))
+	for _, op := range ops {
+		parts = append(parts, op.String())
+	}
+	return strings.Join(parts, "; ")
+}
+
+func TestMatchPath(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"posts[].id", "posts[].id", true},
+		{"posts[].id", "posts[].slug", false},
+		{"posts.id", "posts[].id", false},
+		{"*.id", "post.id", true},
+		{"*.id", "post.heroImage.id", false},
+		{"**.id", "id", true},
+		{"**.id", "post.heroImage.id", true},
+		{"**.id", "posts[].relateds[].id", true},
+		{"**.id", "post.idx", false},
+		{"post.**", "post", true},
+		{"post.**", "post.relateds[].title", true},
+		{"post.**", "posts", false},
+		{"posts[].**.url", "posts[].heroImage.resized.original.url", true},
+		{"**", "anything[].at.all", true},
+	}
+	for _, tc := range cases {
+		if got := matchPath(splitPath(tc.pattern), splitPath(tc.path)); got != tc.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestDiffRules(t *testing.T) {
+	yes, no := true, false
+	cases := []struct {
+		name string
+		a, b string
+		opts CompareOptions
+		want string
+	}{
+		{
+			name: "arrays are unordered by default",
+			a:    `{"tags":["a","b"]}`, b: `{"tags":["b","a"]}`,
+		},
+		{
+			name: "ordered arrays compare by index",
+			a:    `{"tags":["a","b"]}`, b: `{"tags":["b","a"]}`,
+			opts: CompareOptions{Ordered: true},
+			want: `replace /tags/0: "a" -> "b"; replace /tags/1: "b" -> "a"`,
+		},
+		{
+			name: "path rule overrides the default order",
+			a:    `{"tags":["a","b"],"posts":[1,2]}`, b: `{"tags":["b","a"],"posts":[2,1]}`,
+			opts: CompareOptions{Ordered: true, Rules: []PathRule{{Path: "tags", Ordered: &no}}},
+			want: `replace /posts/0: 1 -> 2; replace /posts/1: 2 -> 1`,
+		},
+		{
+			name: "path rule orders a single array",
+			a:    `{"tags":["a","b"],"posts":[1,2]}`, b: `{"tags":["b","a"],"posts":[2,1]}`,
+			opts: CompareOptions{Rules: []PathRule{{Path: "posts", Ordered: &yes}}},
+			want: `replace /posts/0: 1 -> 2; replace /posts/1: 2 -> 1`,
+		},
+		{
+			name: "ignore inside arrays",
+			a:    `{"posts":[{"id":1,"updatedAt":"x"}]}`, b: `{"posts":[{"id":1,"updatedAt":"y"}]}`,
+			opts: CompareOptions{Ignore: []string{"posts[].updatedAt"}},
+		},
+		{
+			name: "ignore does not match other fields",
+			a:    `{"posts":[{"id":1,"updatedAt":"x"}]}`, b: `{"posts":[{"id":2,"updatedAt":"x"}]}`,
+			opts: CompareOptions{Ignore: []string{"posts[].updatedAt"}},
+			want: `replace /posts/0/id: 1 -> 2`,
+		},
+		{
+			name: "ignored field missing on one side",
+			a:    `{"post":{"id":1,"extra":true}}`, b: `{"post":{"id":1}}`,
+			opts: CompareOptions{Ignore: []string{"post.extra"}},
+		},
+		{
+			name: "** matches at any depth",
+			a:    `{"updatedAt":1,"post":{"updatedAt":1,"relateds":[{"updatedAt":1}]}}`,
+			b:    `{"updatedAt":2,"post":{"updatedAt":2,"relateds":[{"updatedAt":2}]}}`,
+			opts: CompareOptions{Ignore: []string{"**.updatedAt"}},
+		},
+		{
+			name: "* matches exactly one segment",
+			a:    `{"post":{"id":1,"heroImage":{"id":1}}}`, b: `{"post":{"id":2,"heroImage":{"id":2}}}`,
+			opts: CompareOptions{Ignore: []string{"*.id"}},
+			want: `replace /post/heroImage/id: 1 -> 2`,
+		},
+		{
+			name: "numeric ID and string ID differ without numericId",
+			a:    `{"post":{"id":"12"}}`, b: `{"post":{"id":12}}`,
+			want: `replace /post/id: "12" -> 12`,
+		},
+		{
+			name: "numericId treats a numeric string as the number",
+			a:    `{"post":{"id":"12"}}`, b: `{"post":{"id":12}}`,
+			opts: CompareOptions{Rules: []PathRule{{Path: "**.id", NumericID: true}}},
+		},
+		{
+			name: "numericId still compares values",
+			a:    `{"post":{"id":"12"}}`, b: `{"post":{"id":13}}`,
+			opts: CompareOptions{Rules: []PathRule{{Path: "**.id", NumericID: true}}},
+			want: `replace /post/id: "12" -> 13`,
+		},
+		{
+			name: "numericId does not accept other strings",
+			a:    `{"post":{"id":"12a"}}`, b: `{"post":{"id":12}}`,
+			opts: CompareOptions{Rules: []PathRule{{Path: "**.id", NumericID: true}}},
+			want: `replace /post/id: "12a" -> 12`,
+		},
+		{
+			name: "numbers compare exactly without tolerance",
+			a:    `{"score":1.0}`, b: `{"score":1.00005}`,
+			want: `replace /score: 1.0 -> 1.00005`,
+		},
+		{
+			name: "tolerance accepts small differences",
+			a:    `{"score":1.0}`, b: `{"score":1.00005}`,
+			opts: CompareOptions{Rules: []PathRule{{Path: "score", Tolerance: 0.0001}}},
+		},
+		{
+			name: "tolerance rejects larger differences",
+			a:    `{"score":1.0}`, b: `{"score":1.1}`,
+			opts: CompareOptions{Rules: []PathRule{{Path: "score", Tolerance: 0.0001}}},
+			want: `replace /score: 1.0 -> 1.1`,
+		},
+		{
+			name: "later tolerance wins",
+			a:    `{"score":1.0}`, b: `{"score":1.1}`,
+			opts: CompareOptions{Rules: []PathRule{{Path: "**", Tolerance: 0.5}, {Path: "score", Tolerance: 0.01}}},
+			want: `replace /score: 1.0 -> 1.1`,
+		},
+		{
+			name: "later tolerance wins in the other order",
+			a:    `{"score":1.0}`, b: `{"score":1.1}`,
+			opts: CompareOptions{Rules: []PathRule{{Path: "score", Tolerance: 0.01}, {Path: "**", Tolerance: 0.5}}},
+		},
+		{
+			name: "later order rule wins",
+			a:    `{"tags":["a","b"]}`, b: `{"tags":["b","a"]}`,
+			opts: CompareOptions{Rules: []PathRule{{Path: "tags", Ordered: &no}, {Path: "**", Ordered: &yes}}},
+			want: `replace /tags/0: "a" -> "b"; replace /tags/1: "b" -> "a"`,
+		},
+		{
+			name: "test rules merged after the suite override its order",
+			a:    `{"tags":["a","b"]}`, b: `{"tags":["b","a"]}`,
+			opts: CompareOptions{Ordered: true}.Merge(CompareOptions{Rules: []PathRule{{Path: "tags", Ordered: &no}}}),
+		},
+		{
+			name: "unordered arrays pair duplicates one to one",
+			a:    `{"ids":[1,1,2]}`, b: `{"ids":[2,1,2]}`,
+			want: `replace /ids/1: 1 -> 2`,
+		},
+		{
+			name: "unordered arrays remove the extra duplicate",
+			a:    `{"ids":[1,2,1]}`, b: `{"ids":[2,1]}`,
+			want: `remove /ids/2 (was 1)`,
+		},
+		{
+			name: "unordered arrays add the missing duplicate",
+			a:    `{"ids":[1]}`, b: `{"ids":[1,1]}`,
+			want: `add /ids/- = 1`,
+		},
+		{
+			name: "unordered arrays of objects apply nested rules when pairing",
+			a:    `{"posts":[{"id":"1","t":"a"},{"id":"2","t":"b"}]}`, b: `{"posts":[{"id":2,"t":"b"},{"id":1,"t":"a"}]}`,
+			opts: CompareOptions{Rules: []PathRule{{Path: "**.id", NumericID: true}}},
+		},
+		{
+			name: "ordered arrays remove from the end then add",
+			a:    `{"a":[1,2,3],"b":[1]}`, b: `{"a":[1],"b":[1,2,3]}`,
+			opts: CompareOptions{Ordered: true},
+			want: `remove /a/2 (was 3); remove /a/1 (was 2); add /b/- = 2; add /b/- = 3`,
+		},
+		{
+			name: "type change replaces the value",
+			a:    `{"post":{"tags":[]}}`, b: `{"post":{"tags":null}}`,
+			want: `replace /post/tags: [] -> null`,
+		},
+		{
+			name: "keys with / and ~ are escaped in the pointer",
+			a:    `{"a/b":{"c~d":1}}`, b: `{"a/b":{"c~d":2}}`,
+			want: `replace /a~1b/c~0d: 1 -> 2`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := opsString(Diff(decodeData(t, tc.a), decodeData(t, tc.b), tc.opts))
+			if got != tc.want {
+				t.Errorf("diff:\n got  %s\n want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+// remove 沒有 value、add 沒有 old；由後往前 remove 再以 /- add，讓 patch 可以依序套用
+func TestDiffPatchJSON(t *testing.T) {
+	ops := Diff(decodeData(t, `{"a":[1,2,3],"b":[1]}`), decodeData(t, `{"a":[1],"b":[1,4]}`), CompareOptions{Ordered: true})
+	b, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"old":3,"op":"remove","path":"/a/2"},{"old":2,"op":"remove","path":"/a/1"},{"op":"add","path":"/b/-","value":4}]`
+	if string(b) != want {
+		t.Errorf("patch:\n got  %s\n want %s", b, want)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		name         string
+		target, self Result
+		match        bool
+		note         string
+	}{
+		{
+			name:   "same data",
+			target: Result{StatusCode: 200, Body: []byte(`{"data":{"post":{"id":"1"}}}`)},
+			self:   Result{StatusCode: 200, Body: []byte(`{"data":{"post":{"id":"1"}}}`)},
+			match:  true,
+		},
+		{
+			name:   "status code",
+			target: Result{StatusCode: 200, Body: []byte(`{"data":null}`)},
+			self:   Result{StatusCode: 500, Body: []byte(`{"data":null}`)},
+			note:   "status code differ",
+		},
+		{
+			name:   "errors on one side",
+			target: Result{StatusCode: 200, Body: []byte(`{"data":{"post":null}}`)},
+			self:   Result{StatusCode: 200, Body: []byte(`{"data":{"post":null},"errors":[{"message":"x"}]}`)},
+			note:   "errors mismatch: target has errors=false, self has errors=true",
+		},
+		{
+			name:   "transport error",
+			target: Result{StatusCode: 200, Body: []byte(`{"data":null}`)},
+			self:   Result{Error: "connection refused"},
+			note:   "transport error",
+		},
+		{
+			name:   "data differ",
+			target: Result{StatusCode: 200, Body: []byte(`{"data":{"post":{"title":"a"}}}`)},
+			self:   Result{StatusCode: 200, Body: []byte(`{"data":{"post":{"title":"b"}}}`)},
+			note:   `data differ (1 changes): replace /post/title: "a" -> "b"`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			match, note, _ := Compare(tc.target, tc.self, CompareOptions{})
+			if match != tc.match || note != tc.note {
+				t.Errorf("Compare = %v, %q; want %v, %q", match, note, tc.match, tc.note)
+			}
+		})
+	}
+}
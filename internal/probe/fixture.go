@@ -0,0 +1,216 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Fixture 是錄下來的 GraphQL 回應，以 query、operationName 與 variables 對應
+type Fixture struct {
+	// Endpoint 是錄製時的 target，只供參考
+	Endpoint string         `json:"endpoint,omitempty"`
+	Entries  []FixtureEntry `json:"entries"`
+}
+
+// FixtureEntry 是一個請求與它的回應
+type FixtureEntry struct {
+	Query         string          `json:"query"`
+	OperationName string          `json:"operationName,omitempty"`
+	Variables     json.RawMessage `json:"variables,omitempty"`
+	Status        int             `json:"status"`
+	Body          json.RawMessage `json:"body"`
+}
+
+// LoadFixture 讀取 SaveFixture 寫出的檔案
+func LoadFixture(path string) (*Fixture, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fixture: %w", err)
+	}
+	var f Fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("parse fixture %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// SaveFixture 將 f 以縮排 JSON 寫到 path；entries 依 query 與 variables 排序，讓重錄的 diff 易讀
+func SaveFixture(path string, f *Fixture) error {
+	sort.SliceStable(f.Entries, func(i, j int) bool {
+		a, b := f.Entries[i], f.Entries[j]
+		if a.Query != b.Query {
+			return a.Query < b.Query
+		}
+		return string(a.Variables) < string(b.Variables)
+	})
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// fixtureKey 回傳請求的比對鍵：query 不論排版、variables 不論 key 順序都得到相同結果
+func fixtureKey(query, operationName string, variables json.RawMessage) string {
+	vars := "null"
+	if len(variables) > 0 {
+		var v interface{}
+		dec := json.NewDecoder(bytes.NewReader(variables))
+		dec.UseNumber()
+		if dec.Decode(&v) == nil && v != nil {
+			b, _ := json.Marshal(v)
+			vars = string(b)
+		}
+	}
+	return NormalizeQuery(query) + "\x00" + operationName + "\x00" + vars
+}
+
+type gqlRequest struct {
+	Query         string          `json:"query"`
+	OperationName string          `json:"operationName"`
+	Variables     json.RawMessage `json:"variables"`
+}
+
+// Recorder 是記錄所有 GraphQL 請求與回應的 http.RoundTripper，
+// 設定為 probe client 的 Transport 即可錄下 target 的回應
+type Recorder struct {
+	next http.RoundTripper
+
+	mu      sync.Mutex
+	entries map[string]FixtureEntry
+}
+
+// NewRecorder 建立 Recorder；next 為 nil 時使用 http.DefaultTransport
+func NewRecorder(next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{next: next, entries: map[string]FixtureEntry{}}
+}
+
+// RoundTrip 實作 http.RoundTripper；只記錄可解析為 GraphQL 請求的 POST
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var gql gqlRequest
+	if req.Method == http.MethodPost && req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(b))
+		if json.Unmarshal(b, &gql) != nil {
+			gql.Query = ""
+		}
+	}
+	resp, err := r.next.RoundTrip(req)
+	if err != nil || gql.Query == "" {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if !json.Valid(body) {
+		return resp, nil
+	}
+	r.mu.Lock()
+	r.entries[fixtureKey(gql.Query, gql.OperationName, gql.Variables)] = FixtureEntry{
+		Query:         gql.Query,
+		OperationName: gql.OperationName,
+		Variables:     gql.Variables,
+		Status:        resp.StatusCode,
+		Body:          body,
+	}
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Fixture 回傳目前錄到的內容
+func (r *Recorder) Fixture(endpoint string) *Fixture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := &Fixture{Endpoint: endpoint, Entries: make([]FixtureEntry, 0, len(r.entries))}
+	for _, e := range r.entries {
+		f.Entries = append(f.Entries, e)
+	}
+	return f
+}
+
+// ReplayHandler 以 f 回應 GraphQL 請求；沒有錄到的請求回傳 GraphQL error，並記錄在 Misses
+type ReplayHandler struct {
+	entries map[string]FixtureEntry
+
+	mu     sync.Mutex
+	misses []string
+}
+
+// NewReplayHandler 建立 ReplayHandler
+func NewReplayHandler(f *Fixture) *ReplayHandler {
+	h := &ReplayHandler{entries: map[string]FixtureEntry{}}
+	for _, e := range f.Entries {
+		h.entries[fixtureKey(e.Query, e.OperationName, e.Variables)] = e
+	}
+	return h
+}
+
+// ServeHTTP 實作 http.Handler
+func (h *ReplayHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var gql gqlRequest
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&gql) != nil || gql.Query == "" {
+		http.Error(w, "replay: expected a GraphQL POST", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	e, ok := h.entries[fixtureKey(gql.Query, gql.OperationName, gql.Variables)]
+	if !ok {
+		h.mu.Lock()
+		h.misses = append(h.misses, fmt.Sprintf("%s %s", NormalizeQuery(gql.Query), gql.Variables))
+		h.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"errors": []map[string]string{{"message": "replay: no recorded response for this request"}},
+		})
+		return
+	}
+	if e.Status != 0 {
+		w.WriteHeader(e.Status)
+	}
+	_, _ = w.Write(e.Body)
+}
+
+// Misses 回傳沒有錄到回應的請求（正規化後的 query 與 variables）
+func (h *ReplayHandler) Misses() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.misses...)
+}
+
+// NewReplayServer 啟動以 f 回應的 httptest server；GraphQL endpoint 為 server.URL + "/api/graphql"，
+// 實際上任何 path 都會回應。使用完畢需呼叫 Close。
+func NewReplayServer(f *Fixture) (*httptest.Server, *ReplayHandler) {
+	h := NewReplayHandler(f)
+	return httptest.NewServer(h), h
+}
+
+var (
+	commentPattern    = regexp.MustCompile(`#[^\n]*`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+	punctPattern      = regexp.MustCompile(`\s*([{}():,\[\]!=$@])\s*`)
+)
+
+// NormalizeQuery 移除註解並壓縮空白，讓同一個查詢不論排版都得到相同字串
+func NormalizeQuery(q string) string {
+	q = commentPattern.ReplaceAllString(q, "")
+	q = whitespacePattern.ReplaceAllString(strings.TrimSpace(q), " ")
+	return punctPattern.ReplaceAllString(q, "$1")
+}
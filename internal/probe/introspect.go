@@ -0,0 +1,570 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// IntrospectionQuery 是標準的 GraphQL introspection 查詢（不含 directives）
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    types {
+      kind
+      name
+      fields(includeDeprecated: true) {
+        name
+        args { name type { ...TypeRef } defaultValue }
+        type { ...TypeRef }
+      }
+      inputFields { name type { ...TypeRef } defaultValue }
+      enumValues(includeDeprecated: true) { name }
+      possibleTypes { name }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } }
+}`
+
+// typeRef 是 introspection 中的型別參照
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+// String 回傳 SDL 寫法，例如 [Post!]!
+func (t *typeRef) String() string {
+	if t == nil {
+		return ""
+	}
+	switch t.Kind {
+	case "NON_NULL":
+		return t.OfType.String() + "!"
+	case "LIST":
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// named 回傳最內層的型別名稱
+func (t *typeRef) named() string {
+	for t != nil && t.OfType != nil {
+		t = t.OfType
+	}
+	if t == nil {
+		return ""
+	}
+	return t.Name
+}
+
+type inputValue struct {
+	Name         string   `json:"name"`
+	Type         *typeRef `json:"type"`
+	DefaultValue *string  `json:"defaultValue"`
+}
+
+type schemaField struct {
+	Name string       `json:"name"`
+	Args []inputValue `json:"args"`
+	Type *typeRef     `json:"type"`
+}
+
+type schemaType struct {
+	Kind          string                  `json:"kind"`
+	Name          string                  `json:"name"`
+	Fields        []schemaField           `json:"fields"`
+	InputFields   []inputValue            `json:"inputFields"`
+	EnumValues    []struct{ Name string } `json:"enumValues"`
+	PossibleTypes []struct{ Name string } `json:"possibleTypes"`
+}
+
+// SchemaInfo 是 introspection 取得的 schema
+type SchemaInfo struct {
+	QueryType    string
+	MutationType string
+	Types        map[string]*schemaType
+}
+
+// FetchSchema 對 endpoint 執行 introspection 查詢
+func FetchSchema(client *http.Client, endpoint string) (*SchemaInfo, error) {
+	b, _ := json.Marshal(map[string]string{"query": IntrospectionQuery})
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		Data *struct {
+			Schema struct {
+				QueryType    *struct{ Name string } `json:"queryType"`
+				MutationType *struct{ Name string } `json:"mutationType"`
+				Types        []*schemaType          `json:"types"`
+			} `json:"__schema"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("introspection %s: status %d: %w", endpoint, resp.StatusCode, err)
+	}
+	if out.Data == nil {
+		msg := fmt.Sprintf("status %d", resp.StatusCode)
+		if len(out.Errors) > 0 {
+			msg = out.Errors[0].Message
+		}
+		return nil, fmt.Errorf("introspection %s: %s", endpoint, msg)
+	}
+	info := &SchemaInfo{Types: map[string]*schemaType{}}
+	if qt := out.Data.Schema.QueryType; qt != nil {
+		info.QueryType = qt.Name
+	}
+	if mt := out.Data.Schema.MutationType; mt != nil {
+		info.MutationType = mt.Name
+	}
+	for _, t := range out.Data.Schema.Types {
+		if strings.HasPrefix(t.Name, "__") {
+			continue
+		}
+		info.Types[t.Name] = t
+	}
+	return info, nil
+}
+
+// Schema change kinds
+const (
+	// ChangeMissing 表示 target 有、self 沒有（client 會壞掉）
+	ChangeMissing = "missing"
+	// ChangeExtra 表示 self 有、target 沒有（不影響相容性）
+	ChangeExtra = "extra"
+	// ChangeChanged 表示兩邊都有但定義不同
+	ChangeChanged = "changed"
+	// ChangeCompatible 表示定義不同但依 target 寫的查詢仍然可用，例如輸出欄位在 self 為 non-null
+	ChangeCompatible = "compatible"
+)
+
+// DefaultSchemaIgnore 是預設略過的型別與 root 欄位：go-story 只提供查詢，
+// Keystone 的 Mutation 與 Admin UI 使用的型別（KeystoneMeta、KeystoneAdminMeta 等）不需要相容。
+var DefaultSchemaIgnore = []string{"Mutation", "Keystone*", "Query.keystone", "Query.authenticatedItem"}
+
+// schemaIgnore 比對略過清單；項目為型別名稱或 Type.field，結尾的 * 比對前綴
+type schemaIgnore []string
+
+func (ig schemaIgnore) match(path string) bool {
+	for _, p := range ig {
+		if p == path || strings.HasSuffix(p, "*") && strings.HasPrefix(path, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// SchemaChange 是一筆 schema 差異。
+// Path 例如 Post、Post.title、Query.posts(where)、PostWhereInput.slug、State.archived。
+type SchemaChange struct {
+	Change  string `json:"change"`
+	Element string `json:"element"` // type、field、argument、inputField、enumValue、possibleType
+	Path    string `json:"path"`
+	Target  string `json:"target,omitempty"`
+	Self    string `json:"self,omitempty"`
+	// Note 說明 changed 的原因，例如 type、kind、defaultValue；
+	// nullability 會註明方向，例如「nullability: self non-null」
+	Note string `json:"note,omitempty"`
+}
+
+// SchemaTypeReport 是單一 target 型別的比對結果
+type SchemaTypeReport struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Elements int    `json:"elements"` // target 中此型別的欄位、參數與 enum 值數量
+	Matched  int    `json:"matched"`
+	// Changes 只包含 missing 與 changed
+	Changes []SchemaChange `json:"changes,omitempty"`
+}
+
+// SchemaReport 是 target 與 self 的 schema 相容性報告
+type SchemaReport struct {
+	Target  string             `json:"target"`
+	Self    string             `json:"self"`
+	Summary SchemaSummary      `json:"summary"`
+	Types   []SchemaTypeReport `json:"types"`
+	// Tolerated 是定義不同但不影響 target 查詢的部分（nullability 較嚴格的輸出、較寬鬆的輸入）
+	Tolerated []SchemaChange `json:"tolerated,omitempty"`
+	// Extra 是 self 多出來的部分，僅供參考
+	Extra []SchemaChange `json:"extra,omitempty"`
+	// Ignored 是依略過清單不比對的項目
+	Ignored []string `json:"ignored,omitempty"`
+}
+
+// SchemaSummary 統計差異數量；Parity 為 target 元素在 self 中完全相同的比例
+type SchemaSummary struct {
+	Elements   int     `json:"elements"`
+	Matched    int     `json:"matched"`
+	Missing    int     `json:"missing"`
+	Changed    int     `json:"changed"`
+	Compatible int     `json:"compatible"`
+	Extra      int     `json:"extra"`
+	Parity     float64 `json:"parity"`
+}
+
+// Compatible 回傳 self 是否涵蓋 target 的所有定義
+func (r *SchemaReport) Compatible() bool {
+	return r.Summary.Missing == 0 && r.Summary.Changed == 0
+}
+
+// RunSchemaDiff 對 target 與 self 執行 introspection 並比對；只使用 opts.SelfClient
+func RunSchemaDiff(client *http.Client, target, self string, opts Options) (*SchemaReport, error) {
+	ts, err := FetchSchema(client, target)
+	if err != nil {
+		return nil, err
+	}
+	ss, err := FetchSchema(opts.selfClient(client), self)
+	if err != nil {
+		return nil, err
+	}
+	ignore := opts.SchemaIgnore
+	if ignore == nil {
+		ignore = DefaultSchemaIgnore
+	}
+	r := DiffSchemas(ts, ss, ignore)
+	r.Target, r.Self = target, self
+	return r, nil
+}
+
+// DiffSchemas 比對兩份 schema；root 型別依 queryType / mutationType 對應，不要求同名。
+// ignore 中的型別與欄位不比對，只有從未略過的 root 欄位可以到達的 target 型別才列入報告，
+// 因此只給 Mutation 使用的 input 型別也不會算成 missing。
+func DiffSchemas(target, self *SchemaInfo, ignore []string) *SchemaReport {
+	report := &SchemaReport{}
+	ig := schemaIgnore(ignore)
+	reachable := reachableTypes(target, ig)
+	selfName := func(name string) string {
+		switch {
+		case name == target.QueryType && self.QueryType != "":
+			return self.QueryType
+		case name == target.MutationType && self.MutationType != "":
+			return self.MutationType
+		}
+		return name
+	}
+	covered := map[string]bool{}
+	for _, name := range sortedTypeNames(target.Types) {
+		tt := target.Types[name]
+		st := self.Types[selfName(name)]
+		covered[selfName(name)] = true
+		if ig.match(name) {
+			report.Ignored = append(report.Ignored, name)
+			continue
+		}
+		if !reachable[name] {
+			continue
+		}
+		tr := diffType(name, tt, st, report, ig)
+		report.Types = append(report.Types, tr)
+		report.Summary.Elements += tr.Elements
+		report.Summary.Matched += tr.Matched
+		for _, c := range tr.Changes {
+			if c.Change == ChangeMissing {
+				report.Summary.Missing++
+			} else {
+				report.Summary.Changed++
+			}
+		}
+	}
+	for _, name := range sortedTypeNames(self.Types) {
+		if !covered[name] && !ig.match(name) {
+			report.Extra = append(report.Extra, SchemaChange{Change: ChangeExtra, Element: "type", Path: name, Self: self.Types[name].Kind})
+		}
+	}
+	report.Summary.Compatible = len(report.Tolerated)
+	report.Summary.Extra = len(report.Extra)
+	if report.Summary.Elements > 0 {
+		report.Summary.Parity = float64(report.Summary.Matched) / float64(report.Summary.Elements)
+	}
+	return report
+}
+
+// reachableTypes 回傳從 target 的 root 型別經由未略過的欄位、參數、input 欄位與成員可以到達的型別
+func reachableTypes(info *SchemaInfo, ig schemaIgnore) map[string]bool {
+	seen := map[string]bool{}
+	var queue []string
+	visit := func(name string) {
+		if name == "" || seen[name] || ig.match(name) || info.Types[name] == nil {
+			return
+		}
+		seen[name] = true
+		queue = append(queue, name)
+	}
+	visit(info.QueryType)
+	visit(info.MutationType)
+	for len(queue) > 0 {
+		t := info.Types[queue[0]]
+		queue = queue[1:]
+		for _, f := range t.Fields {
+			if ig.match(t.Name + "." + f.Name) {
+				continue
+			}
+			visit(f.Type.named())
+			for _, a := range f.Args {
+				visit(a.Type.named())
+			}
+		}
+		for _, v := range t.InputFields {
+			visit(v.Type.named())
+		}
+		for _, p := range t.PossibleTypes {
+			visit(p.Name)
+		}
+	}
+	return seen
+}
+
+// diffType 比對單一型別；型別本身算一個元素，其下的欄位、參數、enum 值各算一個
+func diffType(name string, tt, st *schemaType, report *SchemaReport, ig schemaIgnore) SchemaTypeReport {
+	r := SchemaTypeReport{Name: name, Kind: tt.Kind}
+	add := func(c SchemaChange) {
+		switch c.Change {
+		case ChangeExtra:
+			report.Extra = append(report.Extra, c)
+		case ChangeCompatible:
+			report.Tolerated = append(report.Tolerated, c)
+		default:
+			r.Changes = append(r.Changes, c)
+		}
+	}
+	r.Elements = 1 + countElements(tt, ig)
+	if st == nil {
+		add(SchemaChange{Change: ChangeMissing, Element: "type", Path: name, Target: tt.Kind})
+		return r
+	}
+	if st.Kind != tt.Kind {
+		add(SchemaChange{Change: ChangeChanged, Element: "type", Path: name, Target: tt.Kind, Self: st.Kind, Note: "kind"})
+		return r
+	}
+	r.Matched++
+
+	// fields 與其參數
+	selfFields := map[string]schemaField{}
+	for _, f := range st.Fields {
+		selfFields[f.Name] = f
+	}
+	for _, f := range tt.Fields {
+		path := name + "." + f.Name
+		sf, ok := selfFields[f.Name]
+		delete(selfFields, f.Name)
+		if ig.match(path) {
+			continue
+		}
+		if !ok {
+			add(SchemaChange{Change: ChangeMissing, Element: "field", Path: path, Target: f.Type.String()})
+			continue
+		}
+		if change, note := compareTypeRef(f.Type, sf.Type, false); note != "" {
+			add(SchemaChange{Change: change, Element: "field", Path: path, Target: f.Type.String(), Self: sf.Type.String(), Note: note})
+		} else {
+			r.Matched++
+		}
+		r.Matched += diffInputValues("argument", path, f.Args, sf.Args, add, true)
+	}
+	for _, n := range sortedKeys(selfFields) {
+		if ig.match(name + "." + n) {
+			continue
+		}
+		add(SchemaChange{Change: ChangeExtra, Element: "field", Path: name + "." + n, Self: selfFields[n].Type.String()})
+	}
+
+	// input fields
+	r.Matched += diffInputValues("inputField", name, tt.InputFields, st.InputFields, add, false)
+
+	// enum values
+	selfValues := map[string]bool{}
+	for _, v := range st.EnumValues {
+		selfValues[v.Name] = true
+	}
+	for _, v := range tt.EnumValues {
+		if selfValues[v.Name] {
+			r.Matched++
+			delete(selfValues, v.Name)
+			continue
+		}
+		add(SchemaChange{Change: ChangeMissing, Element: "enumValue", Path: name + "." + v.Name})
+	}
+	for _, v := range sortedKeys(selfValues) {
+		add(SchemaChange{Change: ChangeExtra, Element: "enumValue", Path: name + "." + v})
+	}
+
+	// union / interface 的成員
+	selfPossible := map[string]bool{}
+	for _, p := range st.PossibleTypes {
+		selfPossible[p.Name] = true
+	}
+	for _, p := range tt.PossibleTypes {
+		if selfPossible[p.Name] {
+			r.Matched++
+			delete(selfPossible, p.Name)
+			continue
+		}
+		add(SchemaChange{Change: ChangeMissing, Element: "possibleType", Path: name + "." + p.Name})
+	}
+	for _, p := range sortedKeys(selfPossible) {
+		add(SchemaChange{Change: ChangeExtra, Element: "possibleType", Path: name + "." + p})
+	}
+	return r
+}
+
+// diffInputValues 比對參數或 input 欄位，回傳完全相同的數量。
+// 參數的路徑寫成 Query.posts(where)，input 欄位寫成 PostWhereInput.slug。
+func diffInputValues(element, parent string, target, self []inputValue, add func(SchemaChange), isArg bool) int {
+	path := func(n string) string {
+		if isArg {
+			return parent + "(" + n + ")"
+		}
+		return parent + "." + n
+	}
+	selfValues := map[string]inputValue{}
+	for _, v := range self {
+		selfValues[v.Name] = v
+	}
+	matched := 0
+	for _, v := range target {
+		sv, ok := selfValues[v.Name]
+		delete(selfValues, v.Name)
+		if !ok {
+			add(SchemaChange{Change: ChangeMissing, Element: element, Path: path(v.Name), Target: v.Type.String()})
+			continue
+		}
+		change, note := compareTypeRef(v.Type, sv.Type, true)
+		if note != "" {
+			add(SchemaChange{Change: change, Element: element, Path: path(v.Name), Target: v.Type.String(), Self: sv.Type.String(), Note: note})
+			if change == ChangeChanged {
+				continue
+			}
+		}
+		if deref(v.DefaultValue) != deref(sv.DefaultValue) {
+			add(SchemaChange{Change: ChangeChanged, Element: element, Path: path(v.Name), Target: deref(v.DefaultValue), Self: deref(sv.DefaultValue), Note: "defaultValue"})
+			continue
+		}
+		if note == "" {
+			matched++
+		}
+	}
+	for _, n := range sortedKeys(selfValues) {
+		sv := selfValues[n]
+		if sv.Type != nil && sv.Type.Kind == "NON_NULL" && sv.DefaultValue == nil {
+			// self 多出必填參數時，依 target 寫的查詢會失敗
+			add(SchemaChange{Change: ChangeChanged, Element: element, Path: path(n), Self: sv.Type.String(), Note: "required only in self"})
+			continue
+		}
+		add(SchemaChange{Change: ChangeExtra, Element: element, Path: path(n), Self: sv.Type.String()})
+	}
+	return matched
+}
+
+// compareTypeRef 比較兩個型別參照，相同時回傳空字串，否則回傳 change 與差異種類：
+// nullability（只差在 !，並註明方向）、list（list 層數不同）或 type（型別名稱不同）。
+// 只差在 nullability 時，輸出（欄位）在 self 較嚴格、輸入（參數與 input 欄位）在 self 較寬鬆為 ChangeCompatible。
+func compareTypeRef(target, self *typeRef, input bool) (change, note string) {
+	if target.String() == self.String() {
+		return "", ""
+	}
+	if stripNonNull(target) != stripNonNull(self) {
+		if target.named() != self.named() {
+			return ChangeChanged, "type"
+		}
+		return ChangeChanged, "list"
+	}
+	stricter, looser := nullabilityDiff(target, self)
+	switch {
+	case stricter && looser:
+		return ChangeChanged, "nullability: mixed"
+	case stricter:
+		if input {
+			return ChangeChanged, "nullability: self non-null"
+		}
+		return ChangeCompatible, "nullability: self non-null"
+	default:
+		if input {
+			return ChangeCompatible, "nullability: self nullable"
+		}
+		return ChangeChanged, "nullability: self nullable"
+	}
+}
+
+// nullabilityDiff 逐層比較只差在 ! 的兩個型別參照，回傳 self 是否在某層加上或拿掉 non-null
+func nullabilityDiff(target, self *typeRef) (stricter, looser bool) {
+	for target != nil && self != nil {
+		tn, sn := target.Kind == "NON_NULL", self.Kind == "NON_NULL"
+		if tn {
+			target = target.OfType
+		}
+		if sn {
+			self = self.OfType
+		}
+		stricter = stricter || sn && !tn
+		looser = looser || tn && !sn
+		if target == nil || self == nil || target.Kind != "LIST" || self.Kind != "LIST" {
+			break
+		}
+		target, self = target.OfType, self.OfType
+	}
+	return stricter, looser
+}
+
+func stripNonNull(t *typeRef) string {
+	return strings.ReplaceAll(t.String(), "!", "")
+}
+
+func countElements(t *schemaType, ig schemaIgnore) int {
+	n := len(t.InputFields) + len(t.EnumValues) + len(t.PossibleTypes)
+	for _, f := range t.Fields {
+		if !ig.match(t.Name + "." + f.Name) {
+			n += 1 + len(f.Args)
+		}
+	}
+	return n
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func sortedTypeNames(types map[string]*schemaType) []string {
+	names := make([]string, 0, len(types))
+	for n := range types {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
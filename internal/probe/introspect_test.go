@@ -0,0 +1,251 @@
+package probe
+
+import (
+	"strings"
+	"testing"
+)
+
+func named(kind, name string) *typeRef { return &typeRef{Kind: kind, Name: name} }
+func nonNull(t *typeRef) *typeRef      { return &typeRef{Kind: "NON_NULL", OfType: t} }
+func listOf(t *typeRef) *typeRef       { return &typeRef{Kind: "LIST", OfType: t} }
+
+var (
+	stringRef = named("SCALAR", "String")
+	idRef     = named("SCALAR", "ID")
+)
+
+// testSchema 回傳類似 Lilith 的最小 schema：Query.post(where: PostWhereInput!) 與 Query.posts
+func testSchema() *SchemaInfo {
+	return &SchemaInfo{
+		QueryType: "Query",
+		Types: map[string]*schemaType{
+			"Query": {Kind: "OBJECT", Name: "Query", Fields: []schemaField{
+				{Name: "post", Type: named("OBJECT", "Post"), Args: []inputValue{{Name: "where", Type: nonNull(named("INPUT_OBJECT", "PostWhereInput"))}}},
+				{Name: "posts", Type: listOf(nonNull(named("OBJECT", "Post")))},
+			}},
+			"Post": {Kind: "OBJECT", Name: "Post", Fields: []schemaField{
+				{Name: "id", Type: nonNull(idRef)},
+				{Name: "title", Type: stringRef},
+				{Name: "state", Type: named("ENUM", "PostStateType")},
+			}},
+			"PostWhereInput": {Kind: "INPUT_OBJECT", Name: "PostWhereInput", InputFields: []inputValue{
+				{Name: "id", Type: idRef},
+				{Name: "slug", Type: stringRef},
+			}},
+			"PostStateType": {Kind: "ENUM", Name: "PostStateType", EnumValues: []struct{ Name string }{{"draft"}, {"published"}}},
+			"String":        {Kind: "SCALAR", Name: "String"},
+			"ID":            {Kind: "SCALAR", Name: "ID"},
+		},
+	}
+}
+
+// withKeystone 在 schema 加上 Keystone 的 Mutation、只給 Mutation 使用的 input 與 Admin UI 型別
+func withKeystone(s *SchemaInfo) *SchemaInfo {
+	s.MutationType = "Mutation"
+	s.Types["Mutation"] = &schemaType{Kind: "OBJECT", Name: "Mutation", Fields: []schemaField{
+		{Name: "createPost", Type: named("OBJECT", "Post"), Args: []inputValue{{Name: "data", Type: nonNull(named("INPUT_OBJECT", "PostCreateInput"))}}},
+	}}
+	s.Types["PostCreateInput"] = &schemaType{Kind: "INPUT_OBJECT", Name: "PostCreateInput", InputFields: []inputValue{{Name: "title", Type: stringRef}}}
+	q := s.Types["Query"]
+	q.Fields = append(q.Fields,
+		schemaField{Name: "keystone", Type: nonNull(named("OBJECT", "KeystoneMeta"))},
+		schemaField{Name: "authenticatedItem", Type: named("UNION", "AuthenticatedItem")},
+	)
+	s.Types["KeystoneMeta"] = &schemaType{Kind: "OBJECT", Name: "KeystoneMeta", Fields: []schemaField{{Name: "adminMeta", Type: nonNull(named("OBJECT", "KeystoneAdminMeta"))}}}
+	s.Types["KeystoneAdminMeta"] = &schemaType{Kind: "OBJECT", Name: "KeystoneAdminMeta", Fields: []schemaField{{Name: "lists", Type: stringRef}}}
+	s.Types["AuthenticatedItem"] = &schemaType{Kind: "UNION", Name: "AuthenticatedItem", PossibleTypes: []struct{ Name string }{{"User"}}}
+	s.Types["User"] = &schemaType{Kind: "OBJECT", Name: "User", Fields: []schemaField{{Name: "id", Type: nonNull(idRef)}}}
+	return s
+}
+
+// changeLines 以 schemaChangeLine 列出所有差異，方便在表格中描述預期結果
+func changeLines(r *SchemaReport) string {
+	var lines []string
+	for _, t := range r.Types {
+		for _, c := range t.Changes {
+			lines = append(lines, schemaChangeLine(c))
+		}
+	}
+	for _, c := range r.Tolerated {
+		lines = append(lines, schemaChangeLine(c))
+	}
+	for _, c := range r.Extra {
+		lines = append(lines, schemaChangeLine(c))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestDiffSchemas(t *testing.T) {
+	cases := []struct {
+		name       string
+		target     func(*SchemaInfo)
+		self       func(*SchemaInfo)
+		ignore     []string
+		compatible bool
+		want       string
+	}{
+		{
+			name:       "same schema",
+			compatible: true,
+		},
+		{
+			name:       "Keystone mutation and admin types are ignored by default",
+			target:     func(s *SchemaInfo) { withKeystone(s) },
+			compatible: true,
+		},
+		{
+			name:   "without the ignore list Keystone types are missing",
+			target: func(s *SchemaInfo) { withKeystone(s) },
+			ignore: []string{},
+			want: strings.Join([]string{
+				"missing type         AuthenticatedItem UNION",
+				"missing type         KeystoneAdminMeta OBJECT",
+				"missing type         KeystoneMeta OBJECT",
+				"missing type         Mutation OBJECT",
+				"missing type         PostCreateInput INPUT_OBJECT",
+				"missing field        Query.keystone KeystoneMeta!",
+				"missing field        Query.authenticatedItem AuthenticatedItem",
+				"missing type         User OBJECT",
+			}, "\n"),
+		},
+		{
+			name: "ignored root field",
+			target: func(s *SchemaInfo) {
+				s.Types["Query"].Fields = append(s.Types["Query"].Fields, schemaField{Name: "postsCount", Type: named("SCALAR", "Int")})
+			},
+			ignore:     []string{"Query.postsCount"},
+			compatible: true,
+		},
+		{
+			name: "missing field",
+			target: func(s *SchemaInfo) {
+				s.Types["Post"].Fields = append(s.Types["Post"].Fields, schemaField{Name: "brief", Type: stringRef})
+			},
+			want: "missing field        Post.brief String",
+		},
+		{
+			name:       "output non-null in self is compatible",
+			self:       func(s *SchemaInfo) { s.Types["Post"].Fields[1].Type = nonNull(stringRef) },
+			compatible: true,
+			want:       "compat  field        Post.title: String -> String! (nullability: self non-null)",
+		},
+		{
+			name: "output nullable in self breaks clients",
+			self: func(s *SchemaInfo) { s.Types["Post"].Fields[0].Type = idRef },
+			want: "changed field        Post.id: ID! -> ID (nullability: self nullable)",
+		},
+		{
+			name:       "non-null list items in self are compatible",
+			target:     func(s *SchemaInfo) { s.Types["Query"].Fields[1].Type = listOf(named("OBJECT", "Post")) },
+			compatible: true,
+			want:       "compat  field        Query.posts: [Post] -> [Post!] (nullability: self non-null)",
+		},
+		{
+			name: "stricter list and looser items are not compatible",
+			self: func(s *SchemaInfo) { s.Types["Query"].Fields[1].Type = nonNull(listOf(named("OBJECT", "Post"))) },
+			want: "changed field        Query.posts: [Post!] -> [Post]! (nullability: mixed)",
+		},
+		{
+			name:       "argument nullable in self is compatible",
+			self:       func(s *SchemaInfo) { s.Types["Query"].Fields[0].Args[0].Type = named("INPUT_OBJECT", "PostWhereInput") },
+			compatible: true,
+			want:       "compat  argument     Query.post(where): PostWhereInput! -> PostWhereInput (nullability: self nullable)",
+		},
+		{
+			name: "input field non-null in self breaks clients",
+			self: func(s *SchemaInfo) { s.Types["PostWhereInput"].InputFields[1].Type = nonNull(stringRef) },
+			want: "changed inputField   PostWhereInput.slug: String -> String! (nullability: self non-null)",
+		},
+		{
+			name: "type change",
+			self: func(s *SchemaInfo) { s.Types["Post"].Fields[0].Type = nonNull(stringRef) },
+			want: "changed field        Post.id: ID! -> String! (type)",
+		},
+		{
+			name: "list change",
+			self: func(s *SchemaInfo) { s.Types["Post"].Fields[1].Type = listOf(stringRef) },
+			want: "changed field        Post.title: String -> [String] (list)",
+		},
+		{
+			name: "missing enum value",
+			self: func(s *SchemaInfo) { s.Types["PostStateType"].EnumValues = s.Types["PostStateType"].EnumValues[:1] },
+			want: "missing enumValue    PostStateType.published ",
+		},
+		{
+			name: "required argument only in self",
+			self: func(s *SchemaInfo) {
+				s.Types["Query"].Fields[1].Args = []inputValue{{Name: "take", Type: nonNull(named("SCALAR", "Int"))}}
+			},
+			want: "changed argument     Query.posts(take):  -> Int! (required only in self)",
+		},
+		{
+			name: "optional argument only in self is extra",
+			self: func(s *SchemaInfo) {
+				s.Types["Query"].Fields[1].Args = []inputValue{{Name: "take", Type: named("SCALAR", "Int")}}
+			},
+			compatible: true,
+			want:       "extra   argument     Query.posts(take) Int",
+		},
+		{
+			name:       "types unreachable from the roots are skipped",
+			target:     func(s *SchemaInfo) { s.Types["Orphan"] = &schemaType{Kind: "OBJECT", Name: "Orphan"} },
+			compatible: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			target, self := testSchema(), testSchema()
+			if tc.target != nil {
+				tc.target(target)
+			}
+			if tc.self != nil {
+				tc.self(self)
+			}
+			ignore := tc.ignore
+			if ignore == nil {
+				ignore = DefaultSchemaIgnore
+			}
+			r := DiffSchemas(target, self, ignore)
+			if got := changeLines(r); got != tc.want {
+				t.Errorf("changes:\n got  %s\n want %s", got, tc.want)
+			}
+			if r.Compatible() != tc.compatible {
+				t.Errorf("Compatible() = %v, want %v (summary %+v)", r.Compatible(), tc.compatible, r.Summary)
+			}
+		})
+	}
+}
+
+func TestDiffSchemasSummary(t *testing.T) {
+	self := testSchema()
+	self.Types["Post"].Fields[1].Type = nonNull(stringRef)
+	self.Types["Post"].Fields = self.Types["Post"].Fields[:2]
+	r := DiffSchemas(withKeystone(testSchema()), self, DefaultSchemaIgnore)
+	want := SchemaSummary{Elements: 16, Matched: 14, Missing: 1, Changed: 0, Compatible: 1, Extra: 0}
+	got := r.Summary
+	got.Parity = 0
+	if got != want {
+		t.Errorf("summary = %+v, want %+v", got, want)
+	}
+	if strings.Join(r.Ignored, ",") != "KeystoneAdminMeta,KeystoneMeta,Mutation" {
+		t.Errorf("ignored = %v", r.Ignored)
+	}
+}
+
+func TestSchemaIgnoreMatch(t *testing.T) {
+	ig := schemaIgnore(DefaultSchemaIgnore)
+	for path, want := range map[string]bool{
+		"Mutation":                 true,
+		"KeystoneMeta":             true,
+		"KeystoneAdminUIFieldMeta": true,
+		"Query.keystone":           true,
+		"Query.authenticatedItem":  true,
+		"Query.posts":              false,
+		"Post":                     false,
+		"MutationInput":            false,
+	} {
+		if got := ig.match(path); got != want {
+			t.Errorf("match(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package probe
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+)
+
+// Millis 是以毫秒輸出的 time.Duration
+type Millis time.Duration
+
+// MarshalJSON 輸出到小數點後一位的毫秒數
+func (m Millis) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Float())
+}
+
+// UnmarshalJSON 讀回 MarshalJSON 輸出的毫秒數
+func (m *Millis) UnmarshalJSON(b []byte) error {
+	var ms float64
+	if err := json.Unmarshal(b, &ms); err != nil {
+		return err
+	}
+	*m = Millis(ms * float64(time.Millisecond))
+	return nil
+}
+
+// Float 回傳毫秒數
+func (m Millis) Float() float64 {
+	return math.Round(float64(time.Duration(m))/float64(time.Millisecond)*10) / 10
+}
+
+// LatencyStats 是一個 endpoint 的延遲與回應大小統計；只計入有收到回應的查詢
+type LatencyStats struct {
+	Count    int    `json:"count"`
+	P50      Millis `json:"p50Ms"`
+	P95      Millis `json:"p95Ms"`
+	Max      Millis `json:"maxMs"`
+	AvgBytes int    `json:"avgBytes"`
+}
+
+// LatencyPair 將 target 與 self 的統計並列
+type LatencyPair struct {
+	Target LatencyStats `json:"target"`
+	Self   LatencyStats `json:"self"`
+}
+
+// Ratio 回傳 self 與 target 的 p50 比值；小於 1 表示 self 較快，無資料時回傳 0
+func (p LatencyPair) Ratio() float64 {
+	if p.Target.P50 <= 0 || p.Self.Count == 0 {
+		return 0
+	}
+	return float64(p.Self.P50) / float64(p.Target.P50)
+}
+
+type latencyCollector struct {
+	target, self sideCollector
+}
+
+func (c *latencyCollector) add(target, self Result) {
+	c.target.add(target)
+	c.self.add(self)
+}
+
+func (c *latencyCollector) pair() LatencyPair {
+	return LatencyPair{Target: c.target.stats(), Self: c.self.stats()}
+}
+
+type sideCollector struct {
+	latencies []time.Duration
+	bytes     int
+}
+
+func (c *sideCollector) add(r Result) {
+	if r.StatusCode == 0 {
+		// 連線失敗或逾時的查詢沒有可比較的延遲
+		return
+	}
+	c.latencies = append(c.latencies, r.Latency)
+	c.bytes += r.Size
+}
+
+func (c *sideCollector) stats() LatencyStats {
+	n := len(c.latencies)
+	if n == 0 {
+		return LatencyStats{}
+	}
+	sorted := append([]time.Duration(nil), c.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return LatencyStats{
+		Count:    n,
+		P50:      Millis(percentile(sorted, 50)),
+		P95:      Millis(percentile(sorted, 95)),
+		Max:      Millis(sorted[n-1]),
+		AvgBytes: c.bytes / n,
+	}
+}
+
+// percentile 以 nearest-rank 取已排序資料的第 p 百分位
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,90 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress 表示連線目標解析後位於內部網段
+var ErrBlockedAddress = errors.New("probe: address is not publicly routable")
+
+// blockedPrefixes 是 net/netip 沒有直接判斷方法的非公開網段
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // this network
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64，可能轉到內部 IPv4
+	netip.MustParsePrefix("2002::/16"),     // 6to4，同上
+}
+
+// PublicAddr 回傳 addr 是否為可對外路由的位址；
+// loopback、私有網段、link-local（含 169.254.169.254 metadata server）、multicast 等皆為 false
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// guardDial 在 DNS 解析之後、建立連線之前檢查實際要連的 IP，
+// 因此 DNS rebinding 或指向內部位址的 hostname 也會被擋下
+func guardDial(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	if !PublicAddr(ap.Addr()) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, ap.Addr())
+	}
+	return nil
+}
+
+// NewGuardedClient 回傳只會連到公開位址的 HTTP client，用於對外部輸入的 target 發送請求。
+// 不使用環境變數的 proxy 設定，避免 proxy 替我們連到內部位址。
+// allow 不為 nil 時，每次 redirect 的目的地也要通過 allow（例如 allowlist），
+// 否則允許的 target 可以把請求（307/308 時包含 POST body）轉到任意公開主機。
+func NewGuardedClient(allow func(target string) error) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: guardDial}
+	transport := &http.Transport{
+		Proxy: nil,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, addr)
+		},
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          16,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 3 {
+				return errors.New("probe: too many redirects")
+			}
+			if allow != nil {
+				if err := allow(req.URL.String()); err != nil {
+					return fmt.Errorf("probe: redirect to %s: %w", req.URL.Redacted(), err)
+				}
+			}
+			return nil
+		},
+	}
+}
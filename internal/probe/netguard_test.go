@@ -0,0 +1,103 @@
+package probe
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestPublicAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"fe80::1":          false,
+		"fc00::1":          false,
+		"0.0.0.0":          false,
+		"100.64.0.1":       false,
+		"224.0.0.1":        false,
+		"::ffff:10.0.0.1":  false,
+		"::ffff:127.0.0.1": false,
+		"64:ff9b::a00:1":   false,
+		"2002:a00:1::":     false,
+		"8.8.8.8":          true,
+		"::ffff:8.8.8.8":   true,
+		"2001:4860::8888":  true,
+	} {
+		if got := PublicAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("PublicAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestGuardDial(t *testing.T) {
+	for address, want := range map[string]bool{
+		"127.0.0.1:80":            false,
+		"[::1]:443":               false,
+		"10.0.0.1:80":             false,
+		"169.254.169.254:80":      false,
+		"[::ffff:10.0.0.1]:80":    false,
+		"[64:ff9b::a00:1]:80":     false,
+		"not-an-address":          false,
+		"93.184.216.34:443":       true,
+		"[2606:2800:220:1::]:443": true,
+	} {
+		err := guardDial("tcp", address, nil)
+		if got := err == nil; got != want {
+			t.Errorf("guardDial(%s) = %v, want allowed=%v", address, err, want)
+		}
+		if err != nil && !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("guardDial(%s) = %v, want ErrBlockedAddress", address, err)
+		}
+	}
+}
+
+// httptest server 在 loopback 上，guarded client 不能連過去
+func TestGuardedClientRefusesLoopback(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+	resp, err := NewGuardedClient(nil).Get(srv.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("request to loopback succeeded")
+	}
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("err = %v, want ErrBlockedAddress", err)
+	}
+	if called {
+		t.Error("loopback server received the request")
+	}
+}
+
+func TestGuardedClientChecksRedirects(t *testing.T) {
+	allow := func(target string) error {
+		if !strings.HasPrefix(target, "https://gql.example.com/") {
+			return errors.New("not allowed")
+		}
+		return nil
+	}
+	client := NewGuardedClient(allow)
+	via := []*http.Request{httptest.NewRequest(http.MethodPost, "https://gql.example.com/api/graphql", nil)}
+	for target, want := range map[string]bool{
+		"https://gql.example.com/api/graphql2": true,
+		"https://evil.example.com/api/graphql": false,
+		"http://gql.example.com/api/graphql":   false,
+	} {
+		err := client.CheckRedirect(httptest.NewRequest(http.MethodPost, target, nil), via)
+		if got := err == nil; got != want {
+			t.Errorf("redirect to %s: %v, want allowed=%v", target, err, want)
+		}
+	}
+	if err := client.CheckRedirect(via[0], append(via, via[0], via[0])); err == nil {
+		t.Error("fourth redirect was allowed")
+	}
+}
@@ -0,0 +1,309 @@
+// Package probe 在 target（原 GraphQL 服務）與 self 上執行同一組查詢並比對回應，
+// 查詢定義在隨 binary 內嵌的 suite 檔案中。
+package probe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Result 是單一查詢在某個 endpoint 上的執行結果
+type Result struct {
+	Name       string          `json:"name"`
+	Query      string          `json:"query,omitempty"` // 完整的 GraphQL query
+	StatusCode int             `json:"statusCode"`
+	Body       json.RawMessage `json:"body,omitempty"` // 完整的 response body
+	Error      string          `json:"error,omitempty"`
+	GQLErrors  []string        `json:"gqlErrors,omitempty"` // GraphQL errors 的簡要資訊
+	Latency    time.Duration   `json:"-"`                   // 從送出請求到讀完 body 的時間
+	Size       int             `json:"size"`                // response body 的 bytes
+}
+
+// NewClient 回傳 probe 使用的 HTTP client
+func NewClient() *http.Client {
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+// DefaultSamples 是每個種類預設的 sample 數量
+const DefaultSamples = 5
+
+// DefaultConcurrency 是預設同時進行的查詢數
+const DefaultConcurrency = 8
+
+// Options 是 RunSuite 的執行設定，零值使用預設值
+type Options struct {
+	// Samples 是每個種類的 sample 數量
+	Samples int
+	// Concurrency 是 target 與 self 合計同時進行的查詢數上限
+	Concurrency int
+	// SelfClient 是送往 self 的 client；nil 時與 target 共用同一個 client。
+	// target 使用 NewGuardedClient 時，self 通常是 loopback，需要另外指定。
+	SelfClient *http.Client
+	// SchemaIgnore 是 schema 比對時略過的型別與欄位，nil 時使用 DefaultSchemaIgnore
+	SchemaIgnore []string
+}
+
+func (o Options) withDefaults() Options {
+	if o.Samples <= 0 {
+		o.Samples = DefaultSamples
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultConcurrency
+	}
+	return o
+}
+
+func (o Options) selfClient(client *http.Client) *http.Client {
+	if o.SelfClient != nil {
+		return o.SelfClient
+	}
+	return client
+}
+
+// maxFailingIDs 是每個 test 在報表中列出的失敗 sample 數量上限
+const maxFailingIDs = 5
+
+// run 是一個 test 在某個 sample 上的執行
+type run struct {
+	test   Test
+	sample *Sample
+	vars   map[string]string
+}
+
+type runList []run
+
+// plan 展開 suite：引用樣板的 test 對該種類的每個 sample 各執行一次，其餘 test 只執行一次。
+// 取不到 sample 的種類仍執行一次（樣板綁定為空字串），讓問題出現在比對結果中。
+func plan(suite *Suite, samples Samples) runList {
+	base := samples.Vars()
+	var runs runList
+	for _, t := range suite.Tests {
+		list := samples[sampleKind(t)]
+		if len(list) == 0 {
+			runs = append(runs, run{test: t, vars: base})
+			continue
+		}
+		for i := range list {
+			vars := make(map[string]string, len(base)+len(list[i].Vars))
+			for k, v := range base {
+				vars[k] = v
+			}
+			for k, v := range list[i].Vars {
+				vars[k] = v
+			}
+			runs = append(runs, run{test: t, sample: &list[i], vars: vars})
+		}
+	}
+	return runs
+}
+
+// exec 在 target 與 self 上同時執行所有 run，最多 concurrency 個查詢同時進行。
+// 兩邊的同一個 run 會交錯送出，讓 latency 在相近的負載下量測。
+func (p runList) exec(client, selfClient *http.Client, target, self string, concurrency int) (targetResults, selfResults []Result) {
+	targetResults = make([]Result, len(p))
+	selfResults = make([]Result, len(p))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range p {
+		for _, side := range []struct {
+			client   *http.Client
+			endpoint string
+			out      []Result
+		}{{client, target, targetResults}, {selfClient, self, selfResults}} {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, client *http.Client, endpoint string, out []Result) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				out[i] = execute(client, endpoint, p[i].test, p[i].vars)
+			}(i, side.client, side.endpoint, side.out)
+		}
+	}
+	wg.Wait()
+	return targetResults, selfResults
+}
+
+func execute(client *http.Client, target string, t Test, samples map[string]string) Result {
+	res := Result{Name: t.Name, Query: t.Query}
+	body := map[string]interface{}{"query": t.Query}
+	if t.OperationName != "" {
+		body["operationName"] = t.OperationName
+	}
+	if t.Variables != nil {
+		body["variables"] = bindVariables(t.Variables, samples)
+	}
+	b, _ := json.Marshal(body)
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(b))
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		res.Latency = time.Since(start)
+		res.Error = err.Error()
+		return res
+	}
+	res.StatusCode = resp.StatusCode
+	raw, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	res.Latency = time.Since(start)
+	res.Size = len(raw)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Body = json.RawMessage(raw)
+	// 嘗試解析 GraphQL errors
+	var gqlResp struct {
+		Errors []struct {
+			Message string      `json:"message"`
+			Path    interface{} `json:"path,omitempty"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(raw, &gqlResp) == nil && len(gqlResp.Errors) > 0 {
+		res.GQLErrors = make([]string, 0, len(gqlResp.Errors))
+		for _, e := range gqlResp.Errors {
+			res.GQLErrors = append(res.GQLErrors, e.Message)
+		}
+	}
+	return res
+}
+
+// Comparison 是單一查詢在 target 與 self 的比對結果
+type Comparison struct {
+	Name            string   `json:"name"`
+	Sample          *Sample  `json:"sample,omitempty"`
+	Match           bool     `json:"match"`
+	TargetStatus    int      `json:"targetStatus,omitempty"`
+	SelfStatus      int      `json:"selfStatus,omitempty"`
+	TargetError     string   `json:"targetError,omitempty"`
+	SelfError       string   `json:"selfError,omitempty"`
+	TargetGQLErrors []string `json:"targetGQLErrors,omitempty"`
+	SelfGQLErrors   []string `json:"selfGQLErrors,omitempty"`
+	TargetLatency   Millis   `json:"targetLatencyMs"`
+	SelfLatency     Millis   `json:"selfLatencyMs"`
+	TargetSize      int      `json:"targetSize"`
+	SelfSize        int      `json:"selfSize"`
+	Note            string   `json:"note,omitempty"`
+	// Diff 是 target 到 self 的 data 差異（JSON Patch）
+	Diff []PatchOp `json:"diff,omitempty"`
+}
+
+// Label 回傳含 sample 資訊的名稱，例如 post_gql_GetPostById[post 123 member]
+func (c Comparison) Label() string {
+	if c.Sample == nil {
+		return c.Name
+	}
+	return fmt.Sprintf("%s[%s %s %s]", c.Name, c.Sample.Kind, c.Sample.ID, c.Sample.Stratum)
+}
+
+// Report 是整個 suite 的比對結果
+type Report struct {
+	Suite      string       `json:"suite"`
+	Target     string       `json:"target"`
+	Self       string       `json:"self"`
+	Summary    Summary      `json:"summary"`
+	Latency    LatencyPair  `json:"latency"`
+	Tests      []TestStat   `json:"tests"`
+	Results    []Comparison `json:"-"`
+	Mismatches []Comparison `json:"mismatches,omitempty"`
+}
+
+// Summary 是比對結果的統計，以每個 test 在每個 sample 上的執行為單位
+type Summary struct {
+	Total    int `json:"total"`
+	Matched  int `json:"matched"`
+	Mismatch int `json:"mismatch"`
+}
+
+// TestStat 是單一 test 在所有 sample 上的通過率
+type TestStat struct {
+	Name     string  `json:"name"`
+	Runs     int     `json:"runs"`
+	Passed   int     `json:"passed"`
+	PassRate float64 `json:"passRate"`
+	// Failing 列出最多 maxFailingIDs 個失敗的 sample
+	Failing []Sample `json:"failing,omitempty"`
+	// Latency 是 target 與 self 在這個 test 所有 sample 上的延遲分布
+	Latency LatencyPair `json:"latency"`
+}
+
+// RunSuite 從 target 依分層取得每個種類最多 opts.Samples 筆參考值後，
+// 在 target 與 self 上同時執行 suite 並逐一比對
+func RunSuite(client *http.Client, suite *Suite, target, self string, opts Options) *Report {
+	opts = opts.withDefaults()
+	runs := plan(suite, CollectSamples(client, target, opts.Samples))
+	targetResults, selfResults := runs.exec(client, opts.selfClient(client), target, self, opts.Concurrency)
+
+	report := &Report{Suite: suite.Name, Target: target, Self: self}
+	stats := map[string]*TestStat{}
+	var all latencyCollector
+	perTest := map[string]*latencyCollector{}
+	for _, t := range suite.Tests {
+		report.Tests = append(report.Tests, TestStat{Name: t.Name})
+	}
+	for i := range report.Tests {
+		stats[report.Tests[i].Name] = &report.Tests[i]
+	}
+	for i, r := range runs {
+		tr, sr := targetResults[i], selfResults[i]
+		match, note, diff := Compare(tr, sr, suite.Compare.Merge(r.test.Compare))
+		c := Comparison{
+			Name:            r.test.Name,
+			Sample:          r.sample,
+			Match:           match,
+			TargetStatus:    tr.StatusCode,
+			SelfStatus:      sr.StatusCode,
+			TargetError:     tr.Error,
+			SelfError:       sr.Error,
+			TargetGQLErrors: tr.GQLErrors,
+			SelfGQLErrors:   sr.GQLErrors,
+			TargetLatency:   Millis(tr.Latency),
+			SelfLatency:     Millis(sr.Latency),
+			TargetSize:      tr.Size,
+			SelfSize:        sr.Size,
+			Note:            note,
+			Diff:            diff,
+		}
+		report.Results = append(report.Results, c)
+		all.add(tr, sr)
+		if perTest[r.test.Name] == nil {
+			perTest[r.test.Name] = &latencyCollector{}
+		}
+		perTest[r.test.Name].add(tr, sr)
+		st := stats[r.test.Name]
+		st.Runs++
+		if match {
+			st.Passed++
+		} else {
+			report.Mismatches = append(report.Mismatches, c)
+			if r.sample != nil && len(st.Failing) < maxFailingIDs {
+				st.Failing = append(st.Failing, *r.sample)
+			}
+		}
+	}
+	for i := range report.Tests {
+		if st := &report.Tests[i]; st.Runs > 0 {
+			st.PassRate = float64(st.Passed) / float64(st.Runs)
+			st.Latency = perTest[st.Name].pair()
+		}
+	}
+	report.Latency = all.pair()
+	report.Summary = Summary{
+		Total:    len(report.Results),
+		Matched:  len(report.Results) - len(report.Mismatches),
+		Mismatch: len(report.Mismatches),
+	}
+	return report
+}
@@ -0,0 +1,91 @@
+package probe
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// goldenFixture 是 internal/schema 產生的 go-story 回應快照（default suite、--samples 2）
+const goldenFixture = "../schema/testdata/golden.json"
+
+// changedTitle 是 candidateFixture 對文章 101 title 的修改
+const changedTitle = "免費文章（更新）"
+
+// candidateFixture 回傳 golden 的複本，只有 GetPostById 文章 101 的 title 被改成 changedTitle
+func candidateFixture(t *testing.T, golden *Fixture) *Fixture {
+	t.Helper()
+	out := &Fixture{Endpoint: "candidate", Entries: append([]FixtureEntry(nil), golden.Entries...)}
+	changed := 0
+	for i, e := range out.Entries {
+		if fixtureKey(e.Query, e.OperationName, e.Variables) != fixtureKey(e.Query, "GetPostById", json.RawMessage(`{"id":101}`)) {
+			continue
+		}
+		var body map[string]map[string]map[string]interface{}
+		if err := json.Unmarshal(e.Body, &body); err != nil {
+			t.Fatal(err)
+		}
+		body["data"]["post"]["title"] = changedTitle
+		out.Entries[i].Body, _ = json.Marshal(body)
+		changed++
+	}
+	if changed != 1 {
+		t.Fatalf("golden fixture has %d GetPostById entries for post 101, want 1", changed)
+	}
+	return out
+}
+
+// 以 golden 與只改了一筆回應的 candidate 執行 suite，比對結果應該只有那一筆不一致
+func TestReplayFixtures(t *testing.T) {
+	golden, err := LoadFixture(goldenFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	targetSrv, targetReplay := NewReplayServer(golden)
+	defer targetSrv.Close()
+	candidateSrv, candidateReplay := NewReplayServer(candidateFixture(t, golden))
+	defer candidateSrv.Close()
+	suite, err := LoadSuite(DefaultSuite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := RunSuite(http.DefaultClient, suite, targetSrv.URL+"/api/graphql", candidateSrv.URL+"/api/graphql", Options{Samples: 2})
+	if misses := append(targetReplay.Misses(), candidateReplay.Misses()...); len(misses) > 0 {
+		t.Fatalf("requests not recorded: %v", misses)
+	}
+	if report.Summary.Total != 12 || report.Summary.Matched != 11 {
+		t.Fatalf("summary = %+v, want 11 of 12 matched", report.Summary)
+	}
+	m := report.Mismatches[0]
+	if m.Name != "post_gql_GetPostById" || m.Sample == nil || m.Sample.ID != "101" {
+		t.Fatalf("mismatch = %s, want post_gql_GetPostById on post 101", m.Label())
+	}
+	want := PatchOp{Op: "replace", Path: "/post/title", Old: "免費文章", Value: changedTitle}
+	if len(m.Diff) != 1 || m.Diff[0] != want {
+		t.Errorf("diff = %v, want [%v]", m.Diff, want)
+	}
+}
+
+// 直接以 Compare 逐筆比對 golden 與 candidate 中相同請求的回應
+func TestCompareFixtureEntries(t *testing.T) {
+	golden, err := LoadFixture(goldenFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidate := candidateFixture(t, golden)
+	suite, err := LoadSuite(DefaultSuite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mismatches []string
+	for i, e := range golden.Entries {
+		c := candidate.Entries[i]
+		if match, _, _ := Compare(Result{StatusCode: e.Status, Body: e.Body}, Result{StatusCode: c.Status, Body: c.Body}, suite.Compare); !match {
+			mismatches = append(mismatches, e.OperationName)
+		}
+	}
+	if len(mismatches) != 1 || mismatches[0] != "GetPostById" {
+		t.Errorf("mismatches = %v, want only GetPostById on post 101", mismatches)
+	}
+}
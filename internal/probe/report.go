@@ -0,0 +1,246 @@
+package probe
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteText 輸出人類可讀的比對結果
+func WriteText(w io.Writer, r *Report) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "probe suite %s\n  target:    %s\n  candidate: %s\n\n", r.Suite, r.Target, r.Self)
+	for _, t := range r.Tests {
+		lat := latencyColumns(t.Latency)
+		if t.Runs > 0 && t.Passed == t.Runs {
+			fmt.Fprintf(&sb, "  ok    %-40s %-10s %s\n", t.Name, fmt.Sprintf("(%d/%d)", t.Passed, t.Runs), lat)
+			continue
+		}
+		fmt.Fprintf(&sb, "  FAIL  %-40s %-10s %s", t.Name, fmt.Sprintf("(%d/%d)", t.Passed, t.Runs), lat)
+		fmt.Fprintf(&sb, " %.0f%% passed", t.PassRate*100)
+		if len(t.Failing) > 0 {
+			ids := make([]string, 0, len(t.Failing))
+			for _, s := range t.Failing {
+				ids = append(ids, s.ID+" ("+s.Stratum+")")
+			}
+			fmt.Fprintf(&sb, " failing %s: %s", t.Failing[0].Kind, strings.Join(ids, ", "))
+		}
+		sb.WriteString("\n")
+	}
+	if len(r.Mismatches) > 0 {
+		sb.WriteString("\nmismatches:\n")
+	}
+	for _, c := range r.Mismatches {
+		fmt.Fprintf(&sb, "  %s: %s\n", c.Label(), failureMessage(c))
+		for _, e := range c.TargetGQLErrors {
+			fmt.Fprintf(&sb, "          target error: %s\n", e)
+		}
+		for _, e := range c.SelfGQLErrors {
+			fmt.Fprintf(&sb, "          candidate error: %s\n", e)
+		}
+		for _, op := range c.Diff {
+			fmt.Fprintf(&sb, "          %s\n", op)
+		}
+	}
+	fmt.Fprintf(&sb, "\n%d runs, %d matched, %d mismatched\n", r.Summary.Total, r.Summary.Matched, r.Summary.Mismatch)
+	fmt.Fprintf(&sb, "latency    %10s %10s %10s %10s\n", "p50", "p95", "max", "avg size")
+	for _, side := range []struct {
+		name string
+		s    LatencyStats
+	}{{"target", r.Latency.Target}, {"candidate", r.Latency.Self}} {
+		fmt.Fprintf(&sb, "%-10s %8.1fms %8.1fms %8.1fms %9dB\n", side.name, side.s.P50.Float(), side.s.P95.Float(), side.s.Max.Float(), side.s.AvgBytes)
+	}
+	if ratio := r.Latency.Ratio(); ratio > 0 {
+		fmt.Fprintf(&sb, "candidate p50 is %.2fx target\n", ratio)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitCase     `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit 輸出 JUnit XML，讓 CI 以測試報告呈現每個查詢的比對結果
+func WriteJUnit(w io.Writer, r *Report) error {
+	suite := junitSuite{
+		Name:     "probe." + r.Suite,
+		Tests:    r.Summary.Total,
+		Failures: r.Summary.Mismatch,
+		Properties: []junitProperty{
+			{Name: "target", Value: r.Target},
+			{Name: "candidate", Value: r.Self},
+			{Name: "target.p50Ms", Value: fmt.Sprint(r.Latency.Target.P50.Float())},
+			{Name: "target.p95Ms", Value: fmt.Sprint(r.Latency.Target.P95.Float())},
+			{Name: "candidate.p50Ms", Value: fmt.Sprint(r.Latency.Self.P50.Float())},
+			{Name: "candidate.p95Ms", Value: fmt.Sprint(r.Latency.Self.P95.Float())},
+		},
+	}
+	for _, c := range r.Results {
+		tc := junitCase{
+			Name:      c.Label(),
+			Classname: "probe." + r.Suite + "." + c.Name,
+			// time 為 candidate 的回應時間，target 的時間記在 failure 內容與 properties
+			Time: fmt.Sprintf("%.3f", time.Duration(c.SelfLatency).Seconds()),
+		}
+		if !c.Match {
+			msg := failureMessage(c)
+			var body strings.Builder
+			fmt.Fprintf(&body, "target status: %d (%.1fms, %dB)\ncandidate status: %d (%.1fms, %dB)\n",
+				c.TargetStatus, c.TargetLatency.Float(), c.TargetSize, c.SelfStatus, c.SelfLatency.Float(), c.SelfSize)
+			for _, e := range c.TargetGQLErrors {
+				fmt.Fprintf(&body, "target error: %s\n", e)
+			}
+			for _, e := range c.SelfGQLErrors {
+				fmt.Fprintf(&body, "candidate error: %s\n", e)
+			}
+			for _, op := range c.Diff {
+				fmt.Fprintf(&body, "%s\n", op)
+			}
+			tc.Failure = &junitFailure{Message: msg, Type: "mismatch", Body: body.String()}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	return writeJUnit(w, suite)
+}
+
+func writeJUnit(w io.Writer, suite junitSuite) error {
+	out, err := xml.MarshalIndent(junitSuites{
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []junitSuite{suite},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	_, err = w.Write(append(out, '\n'))
+	return err
+}
+
+// latencyColumns 回傳 test 的 p50 / p95 對照，例如 "target 120.0/310.5ms  candidate 35.2/80.1ms"
+func latencyColumns(p LatencyPair) string {
+	return fmt.Sprintf("target %.1f/%.1fms  candidate %.1f/%.1fms",
+		p.Target.P50.Float(), p.Target.P95.Float(), p.Self.P50.Float(), p.Self.P95.Float())
+}
+
+func failureMessage(c Comparison) string {
+	switch {
+	case c.TargetError != "" || c.SelfError != "":
+		return fmt.Sprintf("%s (target: %q, candidate: %q)", c.Note, c.TargetError, c.SelfError)
+	case c.Note != "":
+		return c.Note
+	default:
+		return "mismatch"
+	}
+}
+
+// WriteSchemaText 輸出人類可讀的 schema 相容性報告：先列出 missing / changed，再列出 self 多出的部分
+func WriteSchemaText(w io.Writer, r *SchemaReport) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "schema diff\n  target:    %s\n  candidate: %s\n\n", r.Target, r.Self)
+	for _, t := range r.Types {
+		if len(t.Changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "  %s %s (%d/%d)\n", t.Kind, t.Name, t.Matched, t.Elements)
+		for _, c := range t.Changes {
+			fmt.Fprintf(&sb, "    %s\n", schemaChangeLine(c))
+		}
+	}
+	if len(r.Tolerated) > 0 {
+		sb.WriteString("\n  compatible:\n")
+		for _, c := range r.Tolerated {
+			fmt.Fprintf(&sb, "    %s\n", schemaChangeLine(c))
+		}
+	}
+	if len(r.Extra) > 0 {
+		sb.WriteString("\n  only in candidate:\n")
+		for _, c := range r.Extra {
+			fmt.Fprintf(&sb, "    %s\n", schemaChangeLine(c))
+		}
+	}
+	s := r.Summary
+	fmt.Fprintf(&sb, "\n%d elements, %d matched, %d missing, %d changed, %d compatible, %d extra (parity %.1f%%)\n",
+		s.Elements, s.Matched, s.Missing, s.Changed, s.Compatible, s.Extra, s.Parity*100)
+	if len(r.Ignored) > 0 {
+		fmt.Fprintf(&sb, "ignored: %s\n", strings.Join(r.Ignored, ", "))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func schemaChangeLine(c SchemaChange) string {
+	switch c.Change {
+	case ChangeMissing:
+		return fmt.Sprintf("missing %-12s %s %s", c.Element, c.Path, c.Target)
+	case ChangeExtra:
+		return fmt.Sprintf("extra   %-12s %s %s", c.Element, c.Path, c.Self)
+	case ChangeCompatible:
+		return fmt.Sprintf("compat  %-12s %s: %s -> %s (%s)", c.Element, c.Path, c.Target, c.Self, c.Note)
+	}
+	return fmt.Sprintf("changed %-12s %s: %s -> %s (%s)", c.Element, c.Path, c.Target, c.Self, c.Note)
+}
+
+// WriteSchemaJUnit 輸出 JUnit XML，每個 target 型別為一個 testcase
+func WriteSchemaJUnit(w io.Writer, r *SchemaReport) error {
+	suite := junitSuite{
+		Name:  "probe.schema",
+		Tests: len(r.Types),
+		Properties: []junitProperty{
+			{Name: "target", Value: r.Target},
+			{Name: "candidate", Value: r.Self},
+			{Name: "parity", Value: fmt.Sprintf("%.4f", r.Summary.Parity)},
+		},
+	}
+	for _, t := range r.Types {
+		tc := junitCase{Name: t.Name, Classname: "probe.schema." + strings.ToLower(t.Kind), Time: "0"}
+		if len(t.Changes) > 0 {
+			suite.Failures++
+			var body strings.Builder
+			for _, c := range t.Changes {
+				fmt.Fprintf(&body, "%s\n", schemaChangeLine(c))
+			}
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d of %d elements differ", len(t.Changes), t.Elements),
+				Type:    "schema",
+				Body:    body.String(),
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	return writeJUnit(w, suite)
+}
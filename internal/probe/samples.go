@@ -0,0 +1,372 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 抽樣的內容種類，對應 test 的 Sample 欄位
+const (
+	KindPost     = "post"
+	KindExternal = "external"
+	KindTopic    = "topic"
+	KindVideo    = "video"
+)
+
+// sampleWindow 是每個分層查詢取回的候選數量上限
+const sampleWindow = 50
+
+// maxTopicTypeQueries 限制 topicStrata 為了找出其他 type 額外發出的查詢次數
+const maxTopicTypeQueries = 20
+
+// Sample 是從 target 取得的一個實際存在的內容，Vars 為 test 樣板可使用的值
+type Sample struct {
+	Kind    string            `json:"kind"`
+	Stratum string            `json:"stratum"`
+	ID      string            `json:"id"`
+	Vars    map[string]string `json:"-"`
+}
+
+// Samples 是依種類分組的抽樣結果
+type Samples map[string][]Sample
+
+// Vars 回傳每個種類第一個 sample 的樣板值，供沒有指定種類的 test 使用
+func (s Samples) Vars() map[string]string {
+	out := map[string]string{}
+	for _, kind := range []string{KindPost, KindExternal, KindTopic, KindVideo} {
+		if list := s[kind]; len(list) > 0 {
+			for k, v := range list[0].Vars {
+				out[k] = v
+			}
+		}
+	}
+	return out
+}
+
+// stratum 是一個分層的候選內容
+type stratum struct {
+	name  string
+	items []Sample
+}
+
+// CollectSamples 從 target 依分層各取最多 n 筆 post / external / topic / video 作為參考值，
+// 以避免硬編 id / slug 造成 400 或比對失敗：
+//   - post：最新、最舊、會員文章、成人文章、有相關文章
+//   - external：最新、最舊
+//   - topic：每一種 type 各自成層
+//   - video：最新、最舊、shorts、一般影片
+//
+// 各層輪流挑選且不重複，讓 n 筆 sample 盡量涵蓋所有分層；取不到的種類沒有 sample。
+// 可用的樣板名稱：postID、postSlug、externalID、externalSlug、partnerSlug、topicSlug、videoID。
+func CollectSamples(client *http.Client, target string, n int) Samples {
+	if n < 1 {
+		n = 1
+	}
+	sources := map[string]func(*http.Client, string) []stratum{
+		KindPost:     postStrata,
+		KindExternal: externalStrata,
+		KindTopic:    topicStrata,
+		KindVideo:    videoStrata,
+	}
+	out := Samples{}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for kind, strata := range sources {
+		wg.Add(1)
+		go func(kind string, strata func(*http.Client, string) []stratum) {
+			defer wg.Done()
+			picked := pick(strata(client, target), n)
+			mu.Lock()
+			out[kind] = picked
+			mu.Unlock()
+		}(kind, strata)
+	}
+	wg.Wait()
+	return out
+}
+
+// pick 從各層輪流取一筆，直到取滿 n 筆或候選用完；同一 ID 只會被取一次
+func pick(strata []stratum, n int) []Sample {
+	var out []Sample
+	seen := map[string]bool{}
+	cursors := make([]int, len(strata))
+	for len(out) < n {
+		progressed := false
+		for i, s := range strata {
+			if len(out) == n {
+				break
+			}
+			// 跳過已被其他層取走的內容
+			for cursors[i] < len(s.items) && seen[s.items[cursors[i]].ID] {
+				cursors[i]++
+			}
+			if cursors[i] == len(s.items) {
+				continue
+			}
+			item := s.items[cursors[i]]
+			cursors[i]++
+			seen[item.ID] = true
+			item.Stratum = s.name
+			out = append(out, item)
+			progressed = true
+		}
+		if !progressed {
+			break
+		}
+	}
+	return out
+}
+
+var (
+	published   = map[string]interface{}{"state": map[string]interface{}{"equals": "published"}}
+	newestFirst = []map[string]string{{"publishedDate": "desc"}}
+	oldestFirst = []map[string]string{{"publishedDate": "asc"}}
+	isTrue      = map[string]interface{}{"equals": true}
+	isFalse     = map[string]interface{}{"equals": false}
+)
+
+// publishedAnd 回傳已發佈且符合 extra 條件的 filter
+func publishedAnd(extra map[string]interface{}) map[string]interface{} {
+	f := map[string]interface{}{"state": map[string]interface{}{"equals": "published"}}
+	for k, v := range extra {
+		f[k] = v
+	}
+	return f
+}
+
+func postStrata(client *http.Client, target string) []stratum {
+	const query = `query ($take:Int,$skip:Int,$orderBy:[PostOrderByInput!]!,$filter:PostWhereInput!){
+  posts(take:$take,skip:$skip,orderBy:$orderBy,where:$filter){
+    id
+    slug
+    relateds{ id }
+  }
+}`
+	type post struct {
+		ID       string `json:"id"`
+		Slug     string `json:"slug"`
+		Relateds []struct {
+			ID string `json:"id"`
+		} `json:"relateds"`
+	}
+	fetch := func(orderBy interface{}, filter map[string]interface{}) []post {
+		var posts []post
+		sampleQuery(client, target, query, sampleWindow, orderBy, filter, "posts", &posts)
+		return posts
+	}
+	toSamples := func(posts []post, keep func(post) bool) []Sample {
+		var out []Sample
+		for _, p := range posts {
+			if keep == nil || keep(p) {
+				out = append(out, Sample{Kind: KindPost, ID: p.ID, Vars: map[string]string{"postID": p.ID, "postSlug": p.Slug}})
+			}
+		}
+		return out
+	}
+
+	newest := fetch(newestFirst, published)
+	return []stratum{
+		{name: "newest", items: toSamples(newest, nil)},
+		{name: "oldest", items: toSamples(fetch(oldestFirst, published), nil)},
+		{name: "member", items: toSamples(fetch(newestFirst, publishedAnd(map[string]interface{}{"isMember": isTrue})), nil)},
+		{name: "adult", items: toSamples(fetch(newestFirst, publishedAnd(map[string]interface{}{"isAdult": isTrue})), nil)},
+		// PostWhereInput 沒有 relateds 過濾條件，從最新文章中挑選
+		{name: "relateds", items: toSamples(newest, func(p post) bool { return len(p.Relateds) > 0 })},
+	}
+}
+
+func externalStrata(client *http.Client, target string) []stratum {
+	const query = `query ($take:Int,$skip:Int,$orderBy:[ExternalOrderByInput!]!,$filter:ExternalWhereInput!){
+  externals(take:$take,skip:$skip,orderBy:$orderBy,where:$filter){
+    id
+    slug
+    partner{ slug }
+  }
+}`
+	filter := publishedAnd(map[string]interface{}{
+		"publishedDate": map[string]interface{}{"not": map[string]interface{}{"equals": nil}},
+	})
+	fetch := func(orderBy interface{}) []Sample {
+		var exts []struct {
+			ID      string `json:"id"`
+			Slug    string `json:"slug"`
+			Partner *struct {
+				Slug string `json:"slug"`
+			} `json:"partner"`
+		}
+		sampleQuery(client, target, query, sampleWindow, orderBy, filter, "externals", &exts)
+		var out []Sample
+		for _, e := range exts {
+			vars := map[string]string{"externalID": e.ID, "externalSlug": e.Slug}
+			if e.Partner != nil {
+				vars["partnerSlug"] = e.Partner.Slug
+			}
+			out = append(out, Sample{Kind: KindExternal, ID: e.ID, Vars: vars})
+		}
+		return out
+	}
+	return []stratum{
+		{name: "newest", items: fetch(newestFirst)},
+		{name: "oldest", items: fetch(oldestFirst)},
+	}
+}
+
+func topicStrata(client *http.Client, target string) []stratum {
+	type topic struct {
+		ID   string `json:"id"`
+		Slug string `json:"slug"`
+		Type string `json:"type"`
+	}
+	fetch := func(filter map[string]interface{}) []topic {
+		var topics []topic
+		sampleQuery(client, target, `query ($take:Int,$skip:Int,$orderBy:[TopicOrderByInput!]!,$filter:TopicWhereInput!){
+  topics(take:$take,skip:$skip,orderBy:$orderBy,where:$filter){
+    id
+    slug
+    type
+  }
+}`, sampleWindow, []map[string]string{{"sortOrder": "asc"}}, filter, "topics", &topics)
+		return topics
+	}
+
+	// 單一 window 可能全是同一種 type：之後每次排除已出現的 type 再查一次，
+	// 每次至少找到一種新的 type，直到沒有結果（沒有 type 的專題只會出現在第一個 window）
+	topics := fetch(published)
+	seen := map[string]bool{}
+	for _, t := range topics {
+		seen[t.Type] = true
+	}
+	for i := 0; i < maxTopicTypeQueries && len(topics) > 0; i++ {
+		types := make([]string, 0, len(seen))
+		for typ := range seen {
+			types = append(types, typ)
+		}
+		sort.Strings(types)
+		more := fetch(publishedAnd(map[string]interface{}{
+			"type": map[string]interface{}{"not": map[string]interface{}{"in": types}},
+		}))
+		if len(more) == 0 {
+			break
+		}
+		for _, t := range more {
+			seen[t.Type] = true
+		}
+		topics = append(topics, more...)
+	}
+
+	byType := map[string][]Sample{}
+	for _, t := range topics {
+		typ := "type:" + t.Type
+		if t.Type == "" {
+			typ = "type:none"
+		}
+		byType[typ] = append(byType[typ], Sample{Kind: KindTopic, ID: t.ID, Vars: map[string]string{"topicSlug": t.Slug}})
+	}
+	names := make([]string, 0, len(byType))
+	for name := range byType {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]stratum, 0, len(names))
+	for _, name := range names {
+		out = append(out, stratum{name: name, items: byType[name]})
+	}
+	return out
+}
+
+func videoStrata(client *http.Client, target string) []stratum {
+	const query = `query ($take:Int,$skip:Int,$orderBy:[VideoOrderByInput!]!,$filter:VideoWhereInput!){
+  videos(take:$take,skip:$skip,orderBy:$orderBy,where:$filter){
+    id
+  }
+}`
+	fetch := func(orderBy interface{}, filter map[string]interface{}) []Sample {
+		var videos []struct {
+			ID string `json:"id"`
+		}
+		sampleQuery(client, target, query, sampleWindow, orderBy, filter, "videos", &videos)
+		var out []Sample
+		for _, v := range videos {
+			out = append(out, Sample{Kind: KindVideo, ID: v.ID, Vars: map[string]string{"videoID": v.ID}})
+		}
+		return out
+	}
+	return []stratum{
+		{name: "newest", items: fetch(newestFirst, published)},
+		{name: "oldest", items: fetch(oldestFirst, published)},
+		{name: "shorts", items: fetch(newestFirst, publishedAnd(map[string]interface{}{"isShorts": isTrue}))},
+		{name: "normal", items: fetch(newestFirst, publishedAnd(map[string]interface{}{"isShorts": isFalse}))},
+	}
+}
+
+// sampleKind 依 test 引用的樣板名稱推斷要使用哪一種 sample；沒有引用樣板時回傳空字串
+func sampleKind(t Test) string {
+	if t.Sample != "" {
+		return t.Sample
+	}
+	b, _ := json.Marshal(t.Variables)
+	s := string(b)
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			return ""
+		}
+		s = strings.TrimLeft(s[start+2:], " ")
+		switch {
+		case strings.HasPrefix(s, "post"):
+			return KindPost
+		case strings.HasPrefix(s, "external"), strings.HasPrefix(s, "partner"):
+			return KindExternal
+		case strings.HasPrefix(s, "topic"):
+			return KindTopic
+		case strings.HasPrefix(s, "video"):
+			return KindVideo
+		}
+	}
+}
+
+// sampleQuery 執行列表查詢，並將 data[field] 解析到 dest；失敗時回傳 false
+func sampleQuery(client *http.Client, target, query string, take int, orderBy interface{}, filter map[string]interface{}, field string, dest interface{}) bool {
+	b, err := json.Marshal(map[string]interface{}{
+		"query": query,
+		"variables": map[string]interface{}{
+			"take":    take,
+			"skip":    0,
+			"orderBy": orderBy,
+			"filter":  filter,
+		},
+	})
+	if err != nil {
+		return false
+	}
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(b))
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	var gr struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &gr); err != nil || gr.Data == nil {
+		return false
+	}
+	raw, ok := gr.Data[field]
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(raw, dest) == nil
+}
@@ -0,0 +1,88 @@
+package probe
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// 依 sortOrder 排序時前 60 則都是 list，其他 type 不在第一個 window 中
+func TestTopicStrataCoversEveryType(t *testing.T) {
+	type topic struct {
+		ID   string `json:"id"`
+		Slug string `json:"slug"`
+		Type string `json:"type"`
+	}
+	var all []topic
+	add := func(typ string, n int) {
+		for i := 0; i < n; i++ {
+			id := strconv.Itoa(len(all) + 1)
+			all = append(all, topic{ID: id, Slug: "topic-" + id, Type: typ})
+		}
+	}
+	add("list", 60)
+	add("timeline", 70)
+	add("group", 2)
+	add("portraitWall", 1)
+
+	queries := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		var req struct {
+			Variables struct {
+				Take   int `json:"take"`
+				Filter struct {
+					Type *struct {
+						Not struct {
+							In []string `json:"in"`
+						} `json:"not"`
+					} `json:"type"`
+				} `json:"filter"`
+			} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		excluded := map[string]bool{}
+		if f := req.Variables.Filter.Type; f != nil {
+			for _, typ := range f.Not.In {
+				excluded[typ] = true
+			}
+		}
+		out := []topic{}
+		for _, tp := range all {
+			if !excluded[tp.Type] && len(out) < req.Variables.Take {
+				out = append(out, tp)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"topics": out}})
+	}))
+	defer srv.Close()
+
+	strata := topicStrata(srv.Client(), srv.URL)
+	got := map[string]int{}
+	for _, s := range strata {
+		got[s.name] = len(s.items)
+	}
+	want := map[string]int{"type:list": 50, "type:timeline": 50, "type:group": 2, "type:portraitWall": 1}
+	for name, n := range want {
+		if got[name] != n {
+			t.Errorf("stratum %s has %d items, want %d (strata %v)", name, got[name], n, got)
+		}
+	}
+	// 第一個 window、timeline、group + portraitWall，最後一次沒有結果
+	if queries != 4 {
+		t.Errorf("queries = %d, want 4", queries)
+	}
+
+	picked := pick(strata, 4)
+	types := map[string]bool{}
+	for _, s := range picked {
+		types[s.Stratum] = true
+	}
+	if len(types) != 4 {
+		t.Errorf("pick(4) covered %d types: %v", len(types), picked)
+	}
+}
@@ -0,0 +1,335 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StoredRun 是保存下來的一次 probe 執行結果
+type StoredRun struct {
+	ID         int64        `json:"id"`
+	StartedAt  time.Time    `json:"startedAt"`
+	Duration   Millis       `json:"durationMs"`
+	Commit     string       `json:"commit,omitempty"`
+	Suite      string       `json:"suite"`
+	Target     string       `json:"target"`
+	Self       string       `json:"self"`
+	Summary    Summary      `json:"summary"`
+	Latency    LatencyPair  `json:"latency"`
+	Tests      []TestStat   `json:"tests"`
+	Mismatches []Comparison `json:"mismatches,omitempty"`
+}
+
+// NewStoredRun 由 report 建立要保存的紀錄；commit 為 candidate 的版本
+func NewStoredRun(r *Report, commit string, startedAt time.Time) *StoredRun {
+	return &StoredRun{
+		StartedAt:  startedAt.UTC(),
+		Duration:   Millis(time.Since(startedAt)),
+		Commit:     commit,
+		Suite:      r.Suite,
+		Target:     r.Target,
+		Self:       r.Self,
+		Summary:    r.Summary,
+		Latency:    r.Latency,
+		Tests:      r.Tests,
+		Mismatches: r.Mismatches,
+	}
+}
+
+// RunFilter 限定 List 回傳的紀錄；空字串代表不限定
+type RunFilter struct {
+	Suite  string
+	Target string
+	// Limit 是回傳的最多筆數（最新的優先），<= 0 時為 50
+	Limit int
+}
+
+func (f RunFilter) match(r *StoredRun) bool {
+	return (f.Suite == "" || r.Suite == f.Suite) && (f.Target == "" || r.Target == f.Target)
+}
+
+func (f RunFilter) limit() int {
+	if f.Limit <= 0 {
+		return 50
+	}
+	return f.Limit
+}
+
+// Store 保存 probe 執行結果
+type Store interface {
+	// Save 保存 run 並設定 run.ID
+	Save(ctx context.Context, run *StoredRun) error
+	// List 依時間由新到舊回傳符合 filter 的紀錄
+	List(ctx context.Context, filter RunFilter) ([]StoredRun, error)
+}
+
+// FileStore 以 JSON Lines 檔案保存紀錄，適合本機或 CI。
+// 需求原本指定 SQLite，但目前的依賴中沒有 SQLite driver，因此以 JSON Lines 代替；
+// 需要多個 instance 共用或大量紀錄時請使用 PGStore。
+// 最後一個 ID 在第一次 Save 時從檔案的最後一行讀出後保存在記憶體中，之後的 Save 只附加一行；
+// 同一個檔案只能由一個 process 寫入，否則 ID 可能重複。
+// List 從檔案結尾往前讀，找到 filter.Limit 筆就停止，因此成本與最近紀錄的大小成正比，
+// 但 suite 或 target 很少出現時仍可能讀完整個檔案；檔案不會自動輪替。
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+	// lastID 是已寫入的最大 ID；loaded 為 false 時尚未從檔案讀取
+	lastID int64
+	loaded bool
+}
+
+// NewFileStore 建立 FileStore；檔案在第一次 Save 時建立
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Save 實作 Store
+func (s *FileStore) Save(ctx context.Context, run *StoredRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded {
+		// 紀錄依 ID 遞增附加，最後一行就是最大的 ID
+		err := s.scanBackward(func(r *StoredRun) bool {
+			s.lastID = r.ID
+			return false
+		})
+		if err != nil {
+			return err
+		}
+		s.loaded = true
+	}
+	run.ID = s.lastID + 1
+	b, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open probe store: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	s.lastID = run.ID
+	return nil
+}
+
+// List 實作 Store
+func (s *FileStore) List(ctx context.Context, filter RunFilter) ([]StoredRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []StoredRun
+	err := s.scanBackward(func(r *StoredRun) bool {
+		if filter.match(r) {
+			out = append(out, *r)
+		}
+		return len(out) < filter.limit()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// fileStoreChunk 是 scanBackward 每次從檔案讀取的大小
+const fileStoreChunk = 64 * 1024
+
+// scanBackward 由最後一行往前逐筆解析紀錄，fn 回傳 false 時停止
+func (s *FileStore) scanBackward(fn func(*StoredRun) bool) error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open probe store: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("open probe store: %w", err)
+	}
+	// emit 解析一行；空白行略過
+	emit := func(line []byte) (bool, error) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			return true, nil
+		}
+		var r StoredRun
+		if err := json.Unmarshal(line, &r); err != nil {
+			return false, fmt.Errorf("parse probe store: %w", err)
+		}
+		return fn(&r), nil
+	}
+	// pending 是目前讀到、還沒遇到前一個換行的部分（可能是不完整的一行）
+	var pending []byte
+	for pos := info.Size(); pos > 0; {
+		n := int64(fileStoreChunk)
+		if n > pos {
+			n = pos
+		}
+		pos -= n
+		chunk := make([]byte, n, n+int64(len(pending)))
+		if _, err := f.ReadAt(chunk, pos); err != nil {
+			return fmt.Errorf("read probe store: %w", err)
+		}
+		pending = append(chunk, pending...)
+		for {
+			i := bytes.LastIndexByte(pending, '\n')
+			if i < 0 {
+				break
+			}
+			more, err := emit(pending[i+1:])
+			if err != nil || !more {
+				return err
+			}
+			pending = pending[:i]
+		}
+	}
+	_, err = emit(pending)
+	return err
+}
+
+// PGStore 將紀錄保存在 Postgres 的 "ProbeRun" table，完整結果存為 jsonb
+type PGStore struct {
+	db *sql.DB
+}
+
+// NewPGStore 建立 PGStore，並在 table 不存在時建立
+func NewPGStore(ctx context.Context, db *sql.DB) (*PGStore, error) {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS "ProbeRun" (
+			id bigserial PRIMARY KEY,
+			"startedAt" timestamptz NOT NULL,
+			commit text NOT NULL DEFAULT '',
+			suite text NOT NULL,
+			target text NOT NULL,
+			result jsonb NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS "ProbeRun_suite_startedAt_idx" ON "ProbeRun" (suite, "startedAt" DESC);`)
+	if err != nil {
+		return nil, fmt.Errorf("create ProbeRun table: %w", err)
+	}
+	return &PGStore{db: db}, nil
+}
+
+// Save 實作 Store
+func (s *PGStore) Save(ctx context.Context, run *StoredRun) error {
+	b, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	return s.db.QueryRowContext(ctx,
+		`INSERT INTO "ProbeRun" ("startedAt", commit, suite, target, result) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		run.StartedAt, run.Commit, run.Suite, run.Target, b,
+	).Scan(&run.ID)
+}
+
+// List 實作 Store
+func (s *PGStore) List(ctx context.Context, filter RunFilter) ([]StoredRun, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, result FROM "ProbeRun"
+		WHERE ($1 = '' OR suite = $1) AND ($2 = '' OR target = $2)
+		ORDER BY "startedAt" DESC, id DESC LIMIT $3`, filter.Suite, filter.Target, filter.limit())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []StoredRun
+	for rows.Next() {
+		var (
+			id  int64
+			raw []byte
+			run StoredRun
+		)
+		if err := rows.Scan(&id, &raw); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &run); err != nil {
+			return nil, fmt.Errorf("parse ProbeRun %d: %w", id, err)
+		}
+		run.ID = id
+		out = append(out, run)
+	}
+	return out, rows.Err()
+}
+
+// TrendPoint 是一個 test 在某次執行的結果
+type TrendPoint struct {
+	RunID     int64     `json:"runId"`
+	StartedAt time.Time `json:"startedAt"`
+	Commit    string    `json:"commit,omitempty"`
+	Runs      int       `json:"runs"`
+	Passed    int       `json:"passed"`
+	PassRate  float64   `json:"passRate"`
+	SelfP50   Millis    `json:"selfP50Ms"`
+	TargetP50 Millis    `json:"targetP50Ms"`
+}
+
+// Trend 是一個 test 在歷次執行的通過率
+type Trend struct {
+	Name string `json:"name"`
+	// History 依時間由舊到新排列
+	History []TrendPoint `json:"history"`
+	// FailingSince 是目前連續失敗的第一次執行；最新一次通過時為 nil
+	FailingSince *TrendPoint `json:"failingSince,omitempty"`
+	// Regressed 表示 FailingSince 之前曾經全部通過，也就是 FailingSince 是退步的那一次
+	Regressed bool `json:"regressed"`
+}
+
+// Trends 由多次執行（任意順序）整理出每個 test 的趨勢，依名稱排序
+func Trends(runs []StoredRun) []Trend {
+	sorted := append([]StoredRun(nil), runs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].StartedAt.Equal(sorted[j].StartedAt) {
+			return sorted[i].StartedAt.Before(sorted[j].StartedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	byName := map[string]*Trend{}
+	for _, run := range sorted {
+		for _, t := range run.Tests {
+			// 沒有執行任何 sample 的 test（例如抽不到 sample）不算通過也不算失敗
+			if t.Runs == 0 {
+				continue
+			}
+			tr := byName[t.Name]
+			if tr == nil {
+				tr = &Trend{Name: t.Name}
+				byName[t.Name] = tr
+			}
+			tr.History = append(tr.History, TrendPoint{
+				RunID:     run.ID,
+				StartedAt: run.StartedAt,
+				Commit:    run.Commit,
+				Runs:      t.Runs,
+				Passed:    t.Passed,
+				PassRate:  t.PassRate,
+				SelfP50:   t.Latency.Self.P50,
+				TargetP50: t.Latency.Target.P50,
+			})
+		}
+	}
+	out := make([]Trend, 0, len(byName))
+	for _, tr := range byName {
+		// 由最新往回找目前連續失敗的起點
+		for i := len(tr.History) - 1; i >= 0; i-- {
+			p := tr.History[i]
+			if p.Passed == p.Runs {
+				tr.Regressed = tr.FailingSince != nil
+				break
+			}
+			point := p
+			tr.FailingSince = &point
+		}
+		out = append(out, *tr)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
@@ -0,0 +1,119 @@
+package probe
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "runs.jsonl")
+	s := NewFileStore(path)
+	for i, suite := range []string{"default", "smoke", "default"} {
+		run := &StoredRun{StartedAt: time.Unix(int64(i), 0).UTC(), Suite: suite, Target: "https://gql.example"}
+		if err := s.Save(ctx, run); err != nil {
+			t.Fatal(err)
+		}
+		if run.ID != int64(i+1) {
+			t.Fatalf("run %d: ID = %d", i, run.ID)
+		}
+	}
+
+	// 重新開啟時從檔案中最大的 ID 繼續
+	s = NewFileStore(path)
+	run := &StoredRun{Suite: "default"}
+	if err := s.Save(ctx, run); err != nil {
+		t.Fatal(err)
+	}
+	if run.ID != 4 {
+		t.Errorf("ID after reopen = %d, want 4", run.ID)
+	}
+
+	runs, err := s.List(ctx, RunFilter{Suite: "default", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].ID != 4 || runs[1].ID != 3 {
+		t.Errorf("List = %+v, want runs 4 and 3", runs)
+	}
+}
+
+// List 由檔案結尾往前讀，紀錄跨過讀取區塊的邊界時也要完整解析
+func TestFileStoreListAcrossChunks(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "runs.jsonl")
+	s := NewFileStore(path)
+	big := strings.Repeat("x", fileStoreChunk/3)
+	for i := 0; i < 10; i++ {
+		suite := "default"
+		if i%2 == 1 {
+			suite = "smoke"
+		}
+		if err := s.Save(ctx, &StoredRun{Suite: suite, Commit: big}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 手動編輯留下的空行不影響讀取
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("\n\n")
+	f.Close()
+
+	runs, err := NewFileStore(path).List(ctx, RunFilter{Suite: "smoke", Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, r := range runs {
+		if r.Commit != big {
+			t.Fatalf("run %d: commit truncated to %d bytes", r.ID, len(r.Commit))
+		}
+		ids = append(ids, r.ID)
+	}
+	if len(ids) != 3 || ids[0] != 10 || ids[1] != 8 || ids[2] != 6 {
+		t.Errorf("List ids = %v, want [10 8 6]", ids)
+	}
+	all, err := NewFileStore(path).List(ctx, RunFilter{Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 10 || all[9].ID != 1 {
+		t.Errorf("List returned %d runs, want all 10", len(all))
+	}
+
+	run := &StoredRun{Suite: "default"}
+	if err := NewFileStore(path).Save(ctx, run); err != nil {
+		t.Fatal(err)
+	}
+	if run.ID != 11 {
+		t.Errorf("ID after reopen = %d, want 11", run.ID)
+	}
+}
+
+// 沒有執行任何 sample 的點不算失敗，不能讓 test 被標記為退步
+func TestTrendsSkipZeroRunPoints(t *testing.T) {
+	run := func(id int64, tests ...TestStat) StoredRun {
+		return StoredRun{ID: id, StartedAt: time.Unix(id, 0), Tests: tests}
+	}
+	trends := Trends([]StoredRun{
+		run(1, TestStat{Name: "a", Runs: 2, Passed: 2}, TestStat{Name: "b", Runs: 2, Passed: 2}, TestStat{Name: "empty"}),
+		run(2, TestStat{Name: "a"}, TestStat{Name: "b", Runs: 2, Passed: 1}, TestStat{Name: "empty"}),
+		run(3, TestStat{Name: "a"}, TestStat{Name: "b"}),
+	})
+	if len(trends) != 2 {
+		t.Fatalf("trends = %+v, want a and b only", trends)
+	}
+	a, b := trends[0], trends[1]
+	if len(a.History) != 1 || a.FailingSince != nil || a.Regressed {
+		t.Errorf("a = %+v, want one passing point and no failure", a)
+	}
+	if len(b.History) != 2 || b.FailingSince == nil || b.FailingSince.RunID != 2 || !b.Regressed {
+		t.Errorf("b = %+v, want regressed since run 2", b)
+	}
+}
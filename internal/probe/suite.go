@@ -0,0 +1,208 @@
+package probe
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// suitesFS 內含隨 binary 發佈的 probe suites（*.json）與其引用的 GraphQL 文件（*.graphql）
+//
+//go:embed suites
+var suitesFS embed.FS
+
+// DefaultSuite 是未指定 suite 時使用的 suite
+const DefaultSuite = "default"
+
+// Suite 是一組要在 target 與 self 上執行並比對的 GraphQL 查詢
+type Suite struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Compare 是所有 test 共用的比對設定，會與 test 各自的設定合併
+	Compare CompareOptions `json:"compare,omitempty"`
+	Tests   []Test         `json:"tests"`
+}
+
+// Test 是 suite 中的一個查詢。
+// Document 為 suites 目錄下的 .graphql 檔名，也可以直接以 Query 內嵌；
+// Variables 中的字串可以使用 {{name}} 或 {{name:int}} 樣板，綁定 CollectSamples 取得的參考值；
+// 引用樣板的 test 會對該種類的每一個 sample 各執行一次。
+type Test struct {
+	Name          string                 `json:"name"`
+	Document      string                 `json:"document,omitempty"`
+	Query         string                 `json:"query,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	// Sample 指定要使用的 sample 種類（post、external、topic、video）；空字串時依樣板名稱推斷
+	Sample  string         `json:"sample,omitempty"`
+	Compare CompareOptions `json:"compare,omitempty"`
+}
+
+// CompareOptions 是比對設定。
+// 路徑以 . 分隔欄位、以 [] 表示陣列元素，例如 posts[].heroImage.id；
+// * 符合任一段，** 符合零或多段，例如 **.updatedAt。
+type CompareOptions struct {
+	// Ordered 為 true 時陣列預設必須順序相同；預設忽略順序，個別路徑可用 Rules 覆蓋
+	Ordered bool `json:"ordered,omitempty"`
+	// Ignore 列出比對時略過的 data 路徑，例如 posts[].updatedAt
+	Ignore []string `json:"ignore,omitempty"`
+	// Rules 是個別路徑的比對規則，符合多條規則時後面的設定優先
+	Rules []PathRule `json:"rules,omitempty"`
+}
+
+// PathRule 是單一路徑的比對規則
+type PathRule struct {
+	Path string `json:"path"`
+	// Ordered 設定該路徑的陣列是否需要同順序；nil 時沿用上層設定
+	Ordered *bool `json:"ordered,omitempty"`
+	// Ignore 為 true 時略過該路徑
+	Ignore bool `json:"ignore,omitempty"`
+	// NumericID 為 true 時 "12" 與 12 視為相同（target 與 self 的 ID 型別不同時使用）
+	NumericID bool `json:"numericId,omitempty"`
+	// Tolerance 是數字的容許誤差，例如 0.0001
+	Tolerance float64 `json:"tolerance,omitempty"`
+}
+
+// Merge 回傳以 o 為基礎、再套用 other 的設定：Ordered 任一為 true 即為 true，Ignore 與 Rules 依序串接
+func (o CompareOptions) Merge(other CompareOptions) CompareOptions {
+	return CompareOptions{
+		Ordered: o.Ordered || other.Ordered,
+		Ignore:  append(append([]string{}, o.Ignore...), other.Ignore...),
+		Rules:   append(append([]PathRule{}, o.Rules...), other.Rules...),
+	}
+}
+
+// rules 將 Ordered、Ignore 與 Rules 展開成依優先順序排列的規則
+func (o CompareOptions) rules() []PathRule {
+	ordered := o.Ordered
+	out := make([]PathRule, 0, len(o.Ignore)+len(o.Rules)+1)
+	out = append(out, PathRule{Path: "**", Ordered: &ordered})
+	for _, p := range o.Ignore {
+		out = append(out, PathRule{Path: p, Ignore: true})
+	}
+	return append(out, o.Rules...)
+}
+
+// SuiteNames 回傳內建的 suite 名稱（依名稱排序）
+func SuiteNames() []string {
+	entries, _ := fs.ReadDir(suitesFS, "suites")
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && path.Ext(e.Name()) == ".json" {
+			names = append(names, strings.TrimSuffix(e.Name(), ".json"))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadSuite 讀取內建 suite，並載入每個 test 引用的 .graphql 文件
+func LoadSuite(name string) (*Suite, error) {
+	if name == "" {
+		name = DefaultSuite
+	}
+	if strings.ContainsAny(name, "/\\.") {
+		return nil, fmt.Errorf("invalid suite name: %q", name)
+	}
+	raw, err := suitesFS.ReadFile("suites/" + name + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("unknown probe suite: %q", name)
+	}
+	var s Suite
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("parse probe suite %q: %w", name, err)
+	}
+	if s.Name == "" {
+		s.Name = name
+	}
+	seen := map[string]bool{}
+	for i := range s.Tests {
+		t := &s.Tests[i]
+		if t.Name == "" {
+			return nil, fmt.Errorf("probe suite %q: test %d has no name", name, i)
+		}
+		if seen[t.Name] {
+			return nil, fmt.Errorf("probe suite %q: duplicate test %q", name, t.Name)
+		}
+		seen[t.Name] = true
+		for _, r := range append(append([]PathRule{}, s.Compare.Rules...), t.Compare.Rules...) {
+			if strings.TrimSpace(r.Path) == "" {
+				return nil, fmt.Errorf("probe suite %q: test %q has a compare rule without path", name, t.Name)
+			}
+			if r.Tolerance < 0 {
+				return nil, fmt.Errorf("probe suite %q: test %q: negative tolerance for %q", name, t.Name, r.Path)
+			}
+		}
+		switch t.Sample {
+		case "", KindPost, KindExternal, KindTopic, KindVideo:
+		default:
+			return nil, fmt.Errorf("probe suite %q: test %q: unknown sample kind %q", name, t.Name, t.Sample)
+		}
+		if t.Query != "" {
+			continue
+		}
+		if t.Document == "" {
+			return nil, fmt.Errorf("probe suite %q: test %q needs document or query", name, t.Name)
+		}
+		doc, err := suitesFS.ReadFile("suites/" + path.Clean(t.Document))
+		if err != nil {
+			return nil, fmt.Errorf("probe suite %q: test %q: %w", name, t.Name, err)
+		}
+		t.Query = string(doc)
+	}
+	return &s, nil
+}
+
+// bindVariables 以 samples 取代 variables 中的 {{name}} 樣板。
+// 整個字串為單一樣板時，{{name:int}} 會在值為整數時轉為數字（target 的 ID 需要整數格式），
+// 取不到值時保留空字串，讓差異顯示在比對結果中。
+func bindVariables(v interface{}, samples map[string]string) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = bindVariables(item, samples)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = bindVariables(item, samples)
+		}
+		return out
+	case string:
+		return bindString(val, samples)
+	default:
+		return v
+	}
+}
+
+func bindString(s string, samples map[string]string) interface{} {
+	if strings.HasPrefix(s, "{{") && strings.HasSuffix(s, "}}") && strings.Count(s, "{{") == 1 {
+		name, typ, _ := strings.Cut(strings.TrimSpace(s[2:len(s)-2]), ":")
+		value := samples[strings.TrimSpace(name)]
+		if strings.TrimSpace(typ) == "int" {
+			if n, err := strconv.Atoi(value); err == nil {
+				return n
+			}
+		}
+		return value
+	}
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			return s
+		}
+		end := strings.Index(s[start:], "}}")
+		if end < 0 {
+			return s
+		}
+		name, _, _ := strings.Cut(strings.TrimSpace(s[start+2:start+end]), ":")
+		s = s[:start] + samples[strings.TrimSpace(name)] + s[start+end+2:]
+	}
+}
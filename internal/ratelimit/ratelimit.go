@@ -0,0 +1,90 @@
+// Package ratelimit 提供 token bucket 速率限制，以及依 key（例如 caller IP）分開計算的版本。
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Bucket 是簡單的 token bucket：每秒補充 rate 個 token，最多累積 burst 個
+type Bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket 建立一開始就裝滿的 Bucket；burst 小於 1 時視為 1
+func NewBucket(rate float64, burst int) *Bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Bucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Allow 取用一個 token；沒有 token 時回傳 false
+func (b *Bucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (b *Bucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+// Keyed 為每個 key 維護一個 Bucket；閒置超過 idle 的 key 會被清除
+type Keyed struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   int
+	idle    time.Duration
+	buckets map[string]*Bucket
+	swept   time.Time
+}
+
+// NewKeyed 建立 Keyed；每個 key 每秒補充 rate 個 token，最多累積 burst 個
+func NewKeyed(rate float64, burst int) *Keyed {
+	idle := 10 * time.Minute
+	if rate > 0 {
+		// 至少保留到 bucket 補滿為止，避免清除後又拿到完整的 burst
+		if full := time.Duration(float64(burst) / rate * float64(time.Second)); full > idle {
+			idle = full
+		}
+	}
+	return &Keyed{rate: rate, burst: burst, idle: idle, buckets: map[string]*Bucket{}, swept: time.Now()}
+}
+
+// Allow 取用 key 的一個 token；沒有 token 時回傳 false
+func (k *Keyed) Allow(key string) bool {
+	k.mu.Lock()
+	now := time.Now()
+	if now.Sub(k.swept) > k.idle {
+		for key, b := range k.buckets {
+			b.mu.Lock()
+			stale := now.Sub(b.last) > k.idle
+			b.mu.Unlock()
+			if stale {
+				delete(k.buckets, key)
+			}
+		}
+		k.swept = now
+	}
+	b := k.buckets[key]
+	if b == nil {
+		b = NewBucket(k.rate, k.burst)
+		k.buckets[key] = b
+	}
+	k.mu.Unlock()
+	return b.Allow()
+}
@@ -0,0 +1,282 @@
+// Package redirect 將前台路徑解析為 canonical 目標與應使用的 HTTP 狀態，
+// 依序套用 Post.redirect 與 slug history，讓 edge 不必查詢 CMS 就能處理轉址。
+package redirect
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go-story/internal/data"
+	"go-story/internal/links"
+)
+
+// maxHops 是單次解析最多跟隨的轉址次數
+const maxHops = 10
+
+// maxSlugLength 是可解析的 slug 長度上限（bytes），超過時視為無效路徑
+const maxSlugLength = 256
+
+// Result kinds
+const (
+	KindPost     = "post"
+	KindExternal = "external"
+	KindTopic    = "topic"
+	KindSection  = "section"
+	KindURL      = "url"
+)
+
+// StatusLoopDetected 表示轉址形成迴圈或超過 maxHops
+const StatusLoopDetected = http.StatusLoopDetected
+
+// prefixes 對應前台路徑的第一段與內容種類，與 links.Builder 一致
+var prefixes = map[string]string{
+	"story":    KindPost,
+	"external": KindExternal,
+	"topic":    KindTopic,
+	"section":  KindSection,
+}
+
+// Lookup 提供解析所需的查詢，由 *data.Repo 實作
+type Lookup interface {
+	QueryPathRecord(ctx context.Context, kind, slug string) (*data.PathRecord, error)
+	QuerySlugHistory(ctx context.Context, kind, slug string) (string, error)
+}
+
+// Result 是路徑解析結果。
+// Status：200 已是 canonical 路徑、301 永久轉址、302 暫時轉址（外部網址）、
+// 404 找不到、410 已下架、508 轉址迴圈。
+type Result struct {
+	Path     string   `json:"path"`
+	Status   int      `json:"status"`
+	Kind     string   `json:"kind,omitempty"`
+	ID       string   `json:"id,omitempty"`
+	Slug     string   `json:"slug,omitempty"`
+	Location string   `json:"location,omitempty"`
+	Chain    []string `json:"chain"`
+	Loop     bool     `json:"loop"`
+}
+
+// Resolver 解析前台路徑
+type Resolver struct {
+	lookup Lookup
+	links  *links.Builder
+}
+
+// New 建立 Resolver
+func New(lookup Lookup, lb *links.Builder) *Resolver {
+	return &Resolver{lookup: lookup, links: lb}
+}
+
+// Resolve 解析 path（站內路徑或本站的絕對網址），跟隨 Post.redirect 與 slug history 直到抵達最終目標
+func (r *Resolver) Resolve(ctx context.Context, path string) (*Result, error) {
+	res := &Result{Path: path, Chain: []string{}}
+	visited := map[string]bool{}
+	moved := false
+	cur := path
+
+	for hop := 0; ; hop++ {
+		kind, slug, ok := r.parse(cur)
+		if !ok {
+			res.Status = http.StatusNotFound
+			return res, nil
+		}
+		key := kind + "/" + slug
+		if visited[key] || hop > maxHops {
+			res.Loop = true
+			res.Status = StatusLoopDetected
+			return res, nil
+		}
+		visited[key] = true
+		res.Chain = append(res.Chain, r.canonical(kind, slug))
+
+		rec, err := r.lookup.QueryPathRecord(ctx, kind, slug)
+		if err != nil {
+			return nil, err
+		}
+		if rec == nil {
+			current, err := r.lookup.QuerySlugHistory(ctx, kind, slug)
+			if err != nil {
+				return nil, err
+			}
+			if current == "" {
+				res.Status = http.StatusNotFound
+				return res, nil
+			}
+			moved = true
+			cur = r.canonical(kind, current)
+			continue
+		}
+
+		gone := isGone(kind, rec.State)
+		// 草稿與排程中的內容視同不存在，不跟隨其 redirect 以免洩漏
+		if rec.Redirect != "" && (rec.Visible || gone) {
+			target := strings.TrimSpace(rec.Redirect)
+			if next, internal := r.redirectPath(target); internal {
+				moved = true
+				cur = next
+				continue
+			}
+			if p, ok := r.links.Rel(target); ok {
+				// 本站但不屬於可解析種類的路徑（分類頁、標籤頁等）
+				res.Kind = KindURL
+				res.Location = r.links.Abs(p)
+				res.Chain = append(res.Chain, res.Location)
+				res.Status = http.StatusMovedPermanently
+				return res, nil
+			}
+			if abs := absoluteURL(target); abs != "" {
+				// 外部網址可能隨時更換，使用暫時轉址避免被瀏覽器永久快取
+				res.Kind = KindURL
+				res.Location = abs
+				res.Chain = append(res.Chain, abs)
+				res.Status = http.StatusFound
+				return res, nil
+			}
+		}
+
+		switch {
+		case rec.Visible:
+		case gone:
+			res.Kind = kind
+			res.Status = http.StatusGone
+			return res, nil
+		default:
+			res.Status = http.StatusNotFound
+			return res, nil
+		}
+
+		res.Kind = kind
+		res.ID = rec.ID
+		res.Slug = rec.Slug
+		res.Location = r.canonical(kind, rec.Slug)
+		if res.Location != res.Chain[len(res.Chain)-1] {
+			res.Chain = append(res.Chain, res.Location)
+		}
+		if !moved && !sameTarget(path, res.Location, r.links) {
+			// 同一內容的非 canonical 寫法（缺少結尾斜線、slug 大小寫不同等）
+			moved = true
+		}
+		res.Status = http.StatusOK
+		if moved {
+			res.Status = http.StatusMovedPermanently
+		}
+		return res, nil
+	}
+}
+
+// Normalize 將 path 轉為固定寫法的站內路徑（去掉網站網址、query string 與 fragment，slug 重新編碼），
+// 解析結果與原本的 path 相同，可作為 cache key。不支援的路徑回傳 false。
+func (r *Resolver) Normalize(path string) (string, bool) {
+	kind, slug, ok := r.parse(path)
+	if !ok {
+		return "", false
+	}
+	rel, _ := r.links.Rel(r.canonical(kind, slug))
+	p, _ := r.links.Rel(strings.TrimSpace(path))
+	if i := strings.IndexAny(p, "?#"); i >= 0 {
+		p = p[:i]
+	}
+	// 保留結尾斜線的有無，缺少斜線的寫法仍需回應 301
+	if !strings.HasSuffix(p, "/") {
+		rel = strings.TrimSuffix(rel, "/")
+	}
+	return rel, true
+}
+
+// parse 將路徑拆成內容種類與 slug；不支援的路徑回傳 false
+func (r *Resolver) parse(raw string) (string, string, bool) {
+	p, ok := r.links.Rel(strings.TrimSpace(raw))
+	if !ok {
+		return "", "", false
+	}
+	if i := strings.IndexAny(p, "?#"); i >= 0 {
+		p = p[:i]
+	}
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	kind, ok := prefixes[parts[0]]
+	if !ok {
+		return "", "", false
+	}
+	slug, err := url.PathUnescape(parts[1])
+	if err != nil || slug == "" || len(slug) > maxSlugLength {
+		return "", "", false
+	}
+	return kind, slug, true
+}
+
+// redirectPath 將 Post.redirect 轉為可繼續解析的站內路徑。
+// redirect 可以是本站網址、站內路徑或單純的文章 slug。
+func (r *Resolver) redirectPath(target string) (string, bool) {
+	if target == "" {
+		return "", false
+	}
+	if p, ok := r.links.Rel(target); ok {
+		if _, _, ok := r.parse(p); ok {
+			return p, true
+		}
+		return "", false
+	}
+	if !strings.ContainsAny(target, "/:?#") {
+		return r.links.Post(target), true
+	}
+	return "", false
+}
+
+func (r *Resolver) canonical(kind, slug string) string {
+	switch kind {
+	case KindPost:
+		return r.links.Post(slug)
+	case KindExternal:
+		return r.links.External(slug)
+	case KindTopic:
+		return r.links.Topic(slug)
+	case KindSection:
+		return r.links.Section(slug)
+	}
+	return ""
+}
+
+// isGone 判斷內容是否已下架（回應 410 而非 404）
+func isGone(kind, state string) bool {
+	if kind == KindSection {
+		return state == "inactive"
+	}
+	return state == "archived"
+}
+
+// sameTarget 比較請求路徑與 canonical 網址是否完全相同（忽略 query string）
+func sameTarget(path, canonical string, lb *links.Builder) bool {
+	p, ok := lb.Rel(path)
+	if !ok {
+		return false
+	}
+	if i := strings.IndexAny(p, "?#"); i >= 0 {
+		p = p[:i]
+	}
+	c, _ := lb.Rel(canonical)
+	p, _ = url.PathUnescape(p)
+	c, _ = url.PathUnescape(c)
+	return p == c
+}
+
+// absoluteURL 只接受 http(s) 的外部網址
+func absoluteURL(target string) string {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return u.String()
+	case "":
+		if strings.HasPrefix(target, "//") {
+			return "https:" + target
+		}
+	}
+	return ""
+}
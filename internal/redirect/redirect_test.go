@@ -0,0 +1,71 @@
+package redirect
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go-story/internal/data"
+	"go-story/internal/links"
+)
+
+type fakeLookup map[string]*data.PathRecord
+
+func (f fakeLookup) QueryPathRecord(ctx context.Context, kind, slug string) (*data.PathRecord, error) {
+	return f[kind+"/"+slug], nil
+}
+
+func (f fakeLookup) QuerySlugHistory(ctx context.Context, kind, slug string) (string, error) {
+	return "", nil
+}
+
+func TestNormalize(t *testing.T) {
+	r := New(fakeLookup{}, links.New("https://www.example.com/"))
+	cases := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"/story/abc/", "/story/abc/", true},
+		{"/story/abc", "/story/abc", true},
+		{"https://www.example.com/story/abc/?utm_source=x#top", "/story/abc/", true},
+		{"/story/abc?utm_source=x", "/story/abc", true},
+		{"/story/%E4%B8%AD%E6%96%87/", "/story/%E4%B8%AD%E6%96%87/", true},
+		{"/topic/t1/", "/topic/t1/", true},
+		{"/category/news/", "", false},
+		{"/story/a/b/", "", false},
+		{"https://other.example.com/story/abc/", "", false},
+		{"/story/" + strings.Repeat("a", maxSlugLength+1) + "/", "", false},
+	}
+	for _, c := range cases {
+		got, ok := r.Normalize(c.path)
+		if got != c.want || ok != c.ok {
+			t.Errorf("Normalize(%q) = %q, %v; want %q, %v", c.path, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+// 正規化後的路徑解析結果與原本的路徑相同
+func TestNormalizeKeepsResult(t *testing.T) {
+	r := New(fakeLookup{
+		"post/abc": {ID: "1", Slug: "abc", State: "published", Visible: true},
+	}, links.New("https://www.example.com"))
+	for _, path := range []string{"/story/abc/", "/story/abc", "https://www.example.com/story/abc/?a=1", "/story/missing/"} {
+		orig, err := r.Resolve(context.Background(), path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		norm, _ := r.Normalize(path)
+		got, err := r.Resolve(context.Background(), norm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != orig.Status || got.Location != orig.Location {
+			t.Errorf("%s: normalized %s resolves to %d %s, want %d %s", path, norm, got.Status, got.Location, orig.Status, orig.Location)
+		}
+	}
+	if res, _ := r.Resolve(context.Background(), "/story/abc"); res.Status != http.StatusMovedPermanently {
+		t.Errorf("missing trailing slash: status %d, want 301", res.Status)
+	}
+}
@@ -0,0 +1,408 @@
+// Package render 將 Lilith draftConverter 產生的 apiData blocks
+// 轉成經過清理的 HTML、AMP HTML 與純文字，讓 web / app / AMP / RSS 共用同一套輸出。
+package render
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Format 是 HTML 輸出格式
+type Format string
+
+const (
+	FormatHTML Format = "html"
+	FormatAMP  Format = "amp"
+)
+
+// block 對應 apiData 中的單一 block
+type block struct {
+	Type    string
+	Content []interface{}
+}
+
+// embedSandbox 是嵌入碼 iframe 的 sandbox 設定，HTML 與 AMP 輸出相同
+const embedSandbox = "allow-scripts allow-same-origin allow-popups"
+
+var (
+	youtubeIDRe = regexp.MustCompile(`^[A-Za-z0-9_-]{6,20}$`)
+	iframeSrcRe = regexp.MustCompile(`(?i)<iframe[^>]*\ssrc\s*=\s*["']([^"']+)["']`)
+	httpsURLRe  = regexp.MustCompile(`https://[^\s"'<>]+`)
+)
+
+// HTML 將 apiData 轉為 HTML
+func HTML(apiData interface{}) string {
+	return Render(apiData, FormatHTML)
+}
+
+// AMP 將 apiData 轉為 AMP HTML（amp-img / amp-youtube / amp-iframe 等元件）
+func AMP(apiData interface{}) string {
+	return Render(apiData, FormatAMP)
+}
+
+// Render 依指定格式輸出；未知格式視為 HTML
+func Render(apiData interface{}, format Format) string {
+	blocks := parseBlocks(apiData)
+	if len(blocks) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, b := range blocks {
+		sb.WriteString(renderBlock(b, format == FormatAMP))
+	}
+	return sb.String()
+}
+
+// Text 將 apiData 轉為純文字，段落之間以空行分隔
+func Text(apiData interface{}) string {
+	var parts []string
+	for _, b := range parseBlocks(apiData) {
+		if t := strings.TrimSpace(blockText(b)); t != "" {
+			parts = append(parts, t)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func parseBlocks(apiData interface{}) []block {
+	list, ok := apiData.([]interface{})
+	if !ok {
+		return nil
+	}
+	blocks := make([]block, 0, len(list))
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		content, _ := m["content"].([]interface{})
+		blocks = append(blocks, block{
+			Type:    str(m, "type"),
+			Content: content,
+		})
+	}
+	return blocks
+}
+
+func renderBlock(b block, amp bool) string {
+	switch b.Type {
+	case "unstyled", "":
+		return wrapEach("p", textItems(b.Content))
+	case "header-two":
+		return wrapEach("h2", textItems(b.Content))
+	case "header-three":
+		return wrapEach("h3", textItems(b.Content))
+	case "blockquote":
+		if inner := wrapEach("p", textItems(b.Content)); inner != "" {
+			return "<blockquote>" + inner + "</blockquote>"
+		}
+		return ""
+	case "code-block":
+		var sb strings.Builder
+		for _, t := range textItems(b.Content) {
+			sb.WriteString("<pre><code>" + html.EscapeString(stripTags(t)) + "</code></pre>")
+		}
+		return sb.String()
+	case "ordered-list-item", "unordered-list-item":
+		items := textItems(b.Content)
+		if len(items) == 0 {
+			return ""
+		}
+		tag := "ul"
+		if b.Type == "ordered-list-item" {
+			tag = "ol"
+		}
+		return "<" + tag + ">" + wrapEach("li", items) + "</" + tag + ">"
+	case "image":
+		if img := firstMap(b.Content); img != nil {
+			return renderFigure(img, amp)
+		}
+		return ""
+	case "slideshow", "slideshow-v2":
+		return renderSlideshow(slideshowImages(b.Content), amp)
+	case "youtube":
+		return renderYoutube(firstMap(b.Content), amp)
+	case "embeddedcode":
+		return renderEmbed(firstMap(b.Content), amp)
+	case "video":
+		return renderMedia("video", firstMap(b.Content), amp)
+	case "audio":
+		return renderMedia("audio", firstMap(b.Content), amp)
+	case "quoteby":
+		m := firstMap(b.Content)
+		quote := sanitizeInline(str(m, "quote"))
+		if quote == "" {
+			return ""
+		}
+		out := `<blockquote class="quoteby"><p>` + quote + "</p>"
+		if by := sanitizeInline(str(m, "quoteBy")); by != "" {
+			out += "<cite>" + by + "</cite>"
+		}
+		return out + "</blockquote>"
+	case "infobox":
+		m := firstMap(b.Content)
+		body := sanitizeInline(str(m, "body"))
+		title := sanitizeInline(str(m, "title"))
+		if body == "" && title == "" {
+			return ""
+		}
+		return `<aside class="infobox"><h4>` + title + "</h4><p>" + body + "</p></aside>"
+	case "divider":
+		return "<hr>"
+	default:
+		// 其他 block（annotation、side-index 等）只保留文字內容
+		return wrapEach("p", textItems(b.Content))
+	}
+}
+
+func blockText(b block) string {
+	switch b.Type {
+	case "image", "slideshow", "slideshow-v2", "youtube", "embeddedcode", "video", "audio", "divider":
+		return ""
+	case "quoteby":
+		m := firstMap(b.Content)
+		quote := stripTags(str(m, "quote"))
+		if strings.TrimSpace(quote) == "" {
+			return ""
+		}
+		if by := stripTags(str(m, "quoteBy")); by != "" {
+			return quote + "\n—— " + by
+		}
+		return quote
+	case "infobox":
+		m := firstMap(b.Content)
+		return strings.TrimSpace(stripTags(str(m, "title")) + "\n" + stripTags(str(m, "body")))
+	case "ordered-list-item", "unordered-list-item":
+		items := textItems(b.Content)
+		lines := make([]string, 0, len(items))
+		for i, it := range items {
+			prefix := "- "
+			if b.Type == "ordered-list-item" {
+				prefix = fmt.Sprintf("%d. ", i+1)
+			}
+			lines = append(lines, prefix+stripTags(it))
+		}
+		return strings.Join(lines, "\n")
+	default:
+		items := textItems(b.Content)
+		lines := make([]string, 0, len(items))
+		for _, it := range items {
+			lines = append(lines, stripTags(it))
+		}
+		return strings.Join(lines, "\n")
+	}
+}
+
+func renderFigure(img map[string]interface{}, amp bool) string {
+	src, srcset := imageSources(img)
+	if src == "" {
+		return ""
+	}
+	caption := sanitizeInline(firstNonEmpty(str(img, "desc"), str(img, "description")))
+	alt := html.EscapeString(stripTags(firstNonEmpty(str(img, "desc"), str(img, "name"))))
+
+	var sb strings.Builder
+	sb.WriteString("<figure>")
+	if amp {
+		w, h := imageSize(img)
+		sb.WriteString(fmt.Sprintf(`<amp-img src="%s" alt="%s" width="%d" height="%d" layout="responsive"`, html.EscapeString(src), alt, w, h))
+		if srcset != "" {
+			sb.WriteString(` srcset="` + html.EscapeString(srcset) + `"`)
+		}
+		sb.WriteString("></amp-img>")
+	} else {
+		sb.WriteString(`<img src="` + html.EscapeString(src) + `" alt="` + alt + `" loading="lazy"`)
+		if srcset != "" {
+			sb.WriteString(` srcset="` + html.EscapeString(srcset) + `"`)
+		}
+		sb.WriteString(">")
+	}
+	if caption != "" {
+		sb.WriteString("<figcaption>" + caption + "</figcaption>")
+	}
+	sb.WriteString("</figure>")
+	return sb.String()
+}
+
+func renderSlideshow(images []map[string]interface{}, amp bool) string {
+	if len(images) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	if amp {
+		w, h := imageSize(images[0])
+		sb.WriteString(fmt.Sprintf(`<amp-carousel type="slides" layout="responsive" width="%d" height="%d">`, w, h))
+		for _, img := range images {
+			src, _ := imageSources(img)
+			if src == "" {
+				continue
+			}
+			alt := html.EscapeString(stripTags(firstNonEmpty(str(img, "desc"), str(img, "name"))))
+			sb.WriteString(fmt.Sprintf(`<amp-img src="%s" alt="%s" width="%d" height="%d" layout="responsive"></amp-img>`, html.EscapeString(src), alt, w, h))
+		}
+		sb.WriteString("</amp-carousel>")
+		return sb.String()
+	}
+	sb.WriteString(`<div class="slideshow">`)
+	for _, img := range images {
+		sb.WriteString(renderFigure(img, false))
+	}
+	sb.WriteString("</div>")
+	return sb.String()
+}
+
+func renderYoutube(m map[string]interface{}, amp bool) string {
+	id := str(m, "youtubeId")
+	if !youtubeIDRe.MatchString(id) {
+		return ""
+	}
+	var out string
+	if amp {
+		out = `<amp-youtube data-videoid="` + id + `" layout="responsive" width="16" height="9"></amp-youtube>`
+	} else {
+		out = `<iframe src="https://www.youtube.com/embed/` + id + `" loading="lazy" allowfullscreen></iframe>`
+	}
+	return wrapFigure(out, sanitizeInline(str(m, "description")))
+}
+
+// renderEmbed 只輸出嵌入碼中的 https iframe；沒有 iframe 時退回第一個 https 連結，
+// 嵌入碼中的 script 一律不輸出。
+func renderEmbed(m map[string]interface{}, amp bool) string {
+	code := str(m, "embeddedCode")
+	caption := sanitizeInline(str(m, "caption"))
+	if match := iframeSrcRe.FindStringSubmatch(code); match != nil {
+		src := html.UnescapeString(match[1])
+		if strings.HasPrefix(src, "//") {
+			src = "https:" + src
+		}
+		if strings.HasPrefix(strings.ToLower(src), "https://") {
+			var out string
+			if amp {
+				out = `<amp-iframe src="` + html.EscapeString(src) + `" sandbox="` + embedSandbox + `" layout="responsive" width="16" height="9" frameborder="0"></amp-iframe>`
+			} else {
+				out = `<iframe src="` + html.EscapeString(src) + `" sandbox="` + embedSandbox + `" loading="lazy" allowfullscreen></iframe>`
+			}
+			return wrapFigure(out, caption)
+		}
+	}
+	if u := httpsURLRe.FindString(code); u != "" {
+		href := html.EscapeString(html.UnescapeString(u))
+		return wrapFigure(`<a href="`+href+`" rel="noopener" target="_blank">`+href+`</a>`, caption)
+	}
+	return ""
+}
+
+func renderMedia(kind string, m map[string]interface{}, amp bool) string {
+	src := safeURL(firstNonEmpty(str(m, "url"), str(m, "videoSrc"), str(m, "src")))
+	if src == "" {
+		return ""
+	}
+	src = html.EscapeString(src)
+	var out string
+	switch {
+	case amp && kind == "video":
+		out = `<amp-video src="` + src + `" controls layout="responsive" width="16" height="9"></amp-video>`
+	case amp:
+		out = `<amp-audio src="` + src + `" controls></amp-audio>`
+	default:
+		out = "<" + kind + ` src="` + src + `" controls preload="none"></` + kind + ">"
+	}
+	return wrapFigure(out, sanitizeInline(firstNonEmpty(str(m, "description"), str(m, "name"))))
+}
+
+// imageSources 取得圖片主要 URL（w800 優先）與 srcset
+func imageSources(img map[string]interface{}) (string, string) {
+	resized, _ := img["resized"].(map[string]interface{})
+	src := safeURL(firstNonEmpty(str(resized, "w800"), str(resized, "original"), str(img, "url")))
+	var set []string
+	for _, w := range []string{"w480", "w800", "w1200", "w1600", "w2400"} {
+		if u := safeURL(str(resized, w)); u != "" {
+			set = append(set, u+" "+strings.TrimPrefix(w, "w")+"w")
+		}
+	}
+	return src, strings.Join(set, ", ")
+}
+
+// imageSize 取得圖片寬高，沒有 imageFile 時使用 16:9
+func imageSize(img map[string]interface{}) (int, int) {
+	file, _ := img["imageFile"].(map[string]interface{})
+	w, _ := file["width"].(float64)
+	h, _ := file["height"].(float64)
+	if w <= 0 || h <= 0 {
+		return 16, 9
+	}
+	return int(w), int(h)
+}
+
+func slideshowImages(content []interface{}) []map[string]interface{} {
+	// slideshow-v2 的 content[0] 內含 images 陣列
+	if first := firstMap(content); first != nil {
+		if list, ok := first["images"].([]interface{}); ok {
+			content = list
+		}
+	}
+	images := make([]map[string]interface{}, 0, len(content))
+	for _, item := range content {
+		if m, ok := item.(map[string]interface{}); ok {
+			images = append(images, m)
+		}
+	}
+	return images
+}
+
+// textItems 攤平 content 中的字串（列表 block 的 content 可能是巢狀陣列）
+func textItems(content []interface{}) []string {
+	items := []string{}
+	for _, c := range content {
+		switch v := c.(type) {
+		case string:
+			if strings.TrimSpace(v) != "" {
+				items = append(items, v)
+			}
+		case []interface{}:
+			items = append(items, textItems(v)...)
+		}
+	}
+	return items
+}
+
+func wrapEach(tag string, items []string) string {
+	var sb strings.Builder
+	for _, it := range items {
+		if inner := sanitizeInline(it); strings.TrimSpace(inner) != "" {
+			sb.WriteString("<" + tag + ">" + inner + "</" + tag + ">")
+		}
+	}
+	return sb.String()
+}
+
+func wrapFigure(inner, caption string) string {
+	if caption == "" {
+		return "<figure>" + inner + "</figure>"
+	}
+	return "<figure>" + inner + "<figcaption>" + caption + "</figcaption></figure>"
+}
+
+func firstMap(content []interface{}) map[string]interface{} {
+	if len(content) == 0 {
+		return nil
+	}
+	m, _ := content[0].(map[string]interface{})
+	return m
+}
+
+func str(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package render
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata golden files")
+
+// TestGolden 以 testdata/*.json 的 apiData 產生 HTML、AMP 與純文字，和同名的 .html / .amp.html / .txt 比對。
+// 修改輸出後以 go test ./internal/render -update 重新產生並檢查差異。
+func TestGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no testdata")
+	}
+	for _, in := range inputs {
+		base := strings.TrimSuffix(in, ".json")
+		t.Run(filepath.Base(base), func(t *testing.T) {
+			raw, err := os.ReadFile(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var blocks []interface{}
+			if err := json.Unmarshal(raw, &blocks); err != nil {
+				t.Fatal(err)
+			}
+			// HTML 每個 block 輸出一行（沒有輸出的 block 為空行），方便檢查差異
+			var html, amp []string
+			for _, b := range blocks {
+				html = append(html, HTML([]interface{}{b}))
+				amp = append(amp, AMP([]interface{}{b}))
+			}
+			outputs := map[string]string{
+				".html":     strings.Join(html, "\n"),
+				".amp.html": strings.Join(amp, "\n"),
+				".txt":      Text(blocks),
+			}
+			for ext, got := range outputs {
+				golden := base + ext
+				got += "\n"
+				if *update {
+					if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("%v (run with -update to create)", err)
+				}
+				if got != string(want) {
+					t.Errorf("%s mismatch\n got:\n%s\nwant:\n%s", golden, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package render
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// inlineTags 是段落內容允許保留的 inline tag
+var inlineTags = map[string]bool{
+	"a": true, "b": true, "strong": true, "i": true, "em": true, "u": true,
+	"s": true, "del": true, "code": true, "sup": true, "sub": true, "br": true,
+}
+
+// htmlTags 是完整 HTML 片段（External.content）允許保留的 tag：inline tag 加上區塊 tag
+var htmlTags = map[string]bool{
+	"p": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "blockquote": true, "pre": true, "hr": true,
+	"figure": true, "figcaption": true, "img": true,
+}
+
+func init() {
+	for name := range inlineTags {
+		htmlTags[name] = true
+	}
+}
+
+// droppedContentTags 的內容整段移除（不只移除 tag）
+var droppedContentTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+}
+
+var (
+	tagNameRe = regexp.MustCompile(`^<\s*(/?)\s*([a-zA-Z][a-zA-Z0-9-]*)`)
+	hrefRe    = attrRe("href")
+	srcRe     = attrRe("src")
+	altRe     = attrRe("alt")
+)
+
+func attrRe(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\s` + name + `\s*=\s*("([^"]*)"|'([^']*)'|([^\s>]+))`)
+}
+
+// sanitizeInline 清理 draftConverter 段落中的 inline HTML：
+// 只保留白名單 tag，a 只保留 http(s) / mailto 的 href，其餘屬性與 tag 一律移除，文字重新 escape。
+func sanitizeInline(s string) string {
+	return sanitize(s, inlineTags)
+}
+
+// SanitizeHTML 清理來源不受信任的完整 HTML 片段（例如合作夥伴的 External.content）：
+// 除了 inline tag 也保留段落、標題、清單、引言與圖片，img 只保留 http(s) 的 src 與 alt。
+func SanitizeHTML(s string) string {
+	return sanitize(s, htmlTags)
+}
+
+func sanitize(s string, allowed map[string]bool) string {
+	var sb strings.Builder
+	skipUntil := ""
+	for len(s) > 0 {
+		lt := strings.IndexByte(s, '<')
+		if lt < 0 {
+			if skipUntil == "" {
+				sb.WriteString(escapeText(s))
+			}
+			break
+		}
+		if skipUntil == "" {
+			sb.WriteString(escapeText(s[:lt]))
+		}
+		s = s[lt:]
+		gt := strings.IndexByte(s, '>')
+		if gt < 0 {
+			// 沒有結尾的 '<' 當作文字處理
+			if skipUntil == "" {
+				sb.WriteString(escapeText(s))
+			}
+			break
+		}
+		tag := s[:gt+1]
+		s = s[gt+1:]
+
+		m := tagNameRe.FindStringSubmatch(tag)
+		if m == nil {
+			continue
+		}
+		closing := m[1] == "/"
+		name := strings.ToLower(m[2])
+		if skipUntil != "" {
+			if closing && name == skipUntil {
+				skipUntil = ""
+			}
+			continue
+		}
+		if droppedContentTags[name] && !closing {
+			skipUntil = name
+			continue
+		}
+		if !allowed[name] {
+			continue
+		}
+		switch {
+		case name == "br":
+			sb.WriteString("<br>")
+		case name == "hr":
+			if !closing {
+				sb.WriteString("<hr>")
+			}
+		case name == "img":
+			if src := safeURL(attr(srcRe, tag)); src != "" && !closing && !strings.HasPrefix(strings.ToLower(src), "mailto:") {
+				sb.WriteString(`<img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(attr(altRe, tag)) + `">`)
+			}
+		case closing:
+			sb.WriteString("</" + name + ">")
+		case name == "a":
+			if href := safeURL(attr(hrefRe, tag)); href != "" {
+				sb.WriteString(`<a href="` + html.EscapeString(href) + `" rel="noopener" target="_blank">`)
+			} else {
+				sb.WriteString("<a>")
+			}
+		default:
+			sb.WriteString("<" + name + ">")
+		}
+	}
+	return sb.String()
+}
+
+// stripTags 移除所有 tag，回傳純文字（未 escape）
+func stripTags(s string) string {
+	var sb strings.Builder
+	skipUntil := ""
+	for len(s) > 0 {
+		lt := strings.IndexByte(s, '<')
+		if lt < 0 {
+			if skipUntil == "" {
+				sb.WriteString(s)
+			}
+			break
+		}
+		if skipUntil == "" {
+			sb.WriteString(s[:lt])
+		}
+		s = s[lt:]
+		gt := strings.IndexByte(s, '>')
+		if gt < 0 {
+			if skipUntil == "" {
+				sb.WriteString(s)
+			}
+			break
+		}
+		tag := s[:gt+1]
+		s = s[gt+1:]
+		m := tagNameRe.FindStringSubmatch(tag)
+		if m == nil {
+			continue
+		}
+		name := strings.ToLower(m[2])
+		if skipUntil != "" {
+			if m[1] == "/" && name == skipUntil {
+				skipUntil = ""
+			}
+			continue
+		}
+		if droppedContentTags[name] && m[1] != "/" {
+			skipUntil = name
+			continue
+		}
+		if name == "br" {
+			sb.WriteString("\n")
+		}
+	}
+	return html.UnescapeString(sb.String())
+}
+
+// attr 取出 tag 中 re 對應屬性的值（已還原 entity）
+func attr(re *regexp.Regexp, tag string) string {
+	m := re.FindStringSubmatch(tag)
+	if m == nil {
+		return ""
+	}
+	for _, v := range m[2:] {
+		if v != "" {
+			return html.UnescapeString(v)
+		}
+	}
+	return ""
+}
+
+// safeURL 只接受 http、https 與 mailto 的 URL
+func safeURL(u string) string {
+	u = strings.TrimSpace(u)
+	lower := strings.ToLower(u)
+	if strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "mailto:") {
+		return u
+	}
+	if strings.HasPrefix(u, "//") {
+		return "https:" + u
+	}
+	return ""
+}
+
+// escapeText 先還原既有 entity 再 escape，避免重複 escape（&amp;amp;）
+func escapeText(s string) string {
+	return html.EscapeString(html.UnescapeString(s))
+}
+
+// StripHTML 將 HTML 字串轉為純文字並合併多餘空白，供摘要、meta description 使用
+func StripHTML(s string) string {
+	return strings.Join(strings.Fields(stripTags(s)), " ")
+}
@@ -0,0 +1,31 @@
+package render
+
+import "testing"
+
+func TestSanitizeHTML(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{`<p class="x" onclick="evil()">文字 <b>粗體</b></p>`, `<p>文字 <b>粗體</b></p>`},
+		{`<h2 id="a">標題</h2><ul><li>一</li></ul>`, `<h2>標題</h2><ul><li>一</li></ul>`},
+		{`<p>a</p><script>alert(1)</script><style>p{}</style><p>b</p>`, `<p>a</p><p>b</p>`},
+		{`<iframe src="https://evil.example"></iframe><p>c</p>`, `<p>c</p>`},
+		{`<img src="https://img.example/a.jpg" alt="圖 &quot;說明&quot;" onerror="x()">`, `<img src="https://img.example/a.jpg" alt="圖 &#34;說明&#34;">`},
+		{`<img src="javascript:alert(1)"><img data-src="https://a" src=x>`, ``},
+		{`<a href="javascript:alert(1)" data-href="https://ok">x</a>`, `<a>x</a>`},
+		{`<a data-x="1" href='https://ok.example/?a=1&amp;b=2'>x</a>`, `<a href="https://ok.example/?a=1&amp;b=2" rel="noopener" target="_blank">x</a>`},
+		{`<div><span>內文</span></div><hr/>`, `內文<hr>`},
+		{`<p onmouseover=alert(1)>1 &lt; 2</p>`, `<p>1 &lt; 2</p>`},
+	}
+	for _, c := range cases {
+		if got := SanitizeHTML(c.in); got != c.want {
+			t.Errorf("SanitizeHTML(%q)\n got %q\nwant %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestSanitizeInlineDropsBlockTags(t *testing.T) {
+	if got := sanitizeInline(`<p>a</p><img src="https://img.example/a.jpg"><b>b</b>`); got != `a<b>b</b>` {
+		t.Errorf("sanitizeInline kept block tags: %q", got)
+	}
+}
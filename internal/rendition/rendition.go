@@ -0,0 +1,207 @@
+// Package rendition 依設定的 profile（尺寸、格式、URL 樣板）產生圖片各尺寸的網址，
+// 新增斷點或格式（例如 AVIF）只需要調整設定。
+package rendition
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FormatOriginal 表示沿用原檔格式（副檔名取自 Image.file_extension）
+const FormatOriginal = "original"
+
+// Profile 描述一種輸出格式的所有尺寸。
+// Template / OriginalTemplate 可使用 {host}、{id}、{width}、{ext} 佔位符。
+type Profile struct {
+	// Format 是對外的格式名稱，例如 original、webp、avif
+	Format string `json:"format"`
+	// Ext 是網址使用的副檔名；空字串時沿用原檔副檔名
+	Ext string `json:"ext,omitempty"`
+	// Widths 是可用的寬度（px）
+	Widths []int `json:"widths"`
+	// Template 產生指定寬度的網址
+	Template string `json:"template,omitempty"`
+	// OriginalTemplate 產生原尺寸的網址；空字串時不輸出原尺寸
+	OriginalTemplate string `json:"originalTemplate,omitempty"`
+}
+
+// Rendition 是一張圖片在某個格式、寬度下的網址；Width 為 0 表示原尺寸且寬度未知
+type Rendition struct {
+	Format   string `json:"format"`
+	Width    int    `json:"width"`
+	URL      string `json:"url"`
+	Original bool   `json:"original"`
+}
+
+const (
+	defaultTemplate         = "{host}/{id}-w{width}.{ext}"
+	defaultOriginalTemplate = "{host}/{id}.{ext}"
+)
+
+// Default 回傳與既有 resized / resizedWebp 相同的設定：原檔格式與 webP，各五個尺寸
+func Default() []Profile {
+	widths := []int{480, 800, 1200, 1600, 2400}
+	return []Profile{
+		{Format: FormatOriginal, Widths: widths, Template: defaultTemplate, OriginalTemplate: defaultOriginalTemplate},
+		{Format: "webp", Ext: "webP", Widths: widths, Template: defaultTemplate, OriginalTemplate: defaultOriginalTemplate},
+	}
+}
+
+// Parse 解析 JSON 格式的 profile 陣列，未指定樣板時使用預設樣板
+func Parse(raw []byte) ([]Profile, error) {
+	var profiles []Profile
+	if err := json.Unmarshal(raw, &profiles); err != nil {
+		return nil, fmt.Errorf("parse rendition profiles: %w", err)
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("rendition profiles are empty")
+	}
+	seen := map[string]bool{}
+	for i := range profiles {
+		p := &profiles[i]
+		p.Format = strings.ToLower(strings.TrimSpace(p.Format))
+		if p.Format == "" {
+			return nil, fmt.Errorf("rendition profile %d: format is required", i)
+		}
+		if seen[p.Format] {
+			return nil, fmt.Errorf("rendition profile %q is defined twice", p.Format)
+		}
+		seen[p.Format] = true
+		if p.Template == "" {
+			p.Template = defaultTemplate
+		}
+		for _, w := range p.Widths {
+			if w <= 0 {
+				return nil, fmt.Errorf("rendition profile %q: invalid width %d", p.Format, w)
+			}
+		}
+		sort.Ints(p.Widths)
+	}
+	return profiles, nil
+}
+
+// Builder 以 statics host 與 profiles 產生圖片網址
+type Builder struct {
+	host     string
+	profiles []Profile
+}
+
+// New 建立 Builder；profiles 為空時使用 Default()
+func New(host string, profiles []Profile) *Builder {
+	if len(profiles) == 0 {
+		profiles = Default()
+	}
+	return &Builder{host: strings.TrimSuffix(host, "/"), profiles: profiles}
+}
+
+// Build 產生一張圖片在所有 profile 下的 renditions；originalWidth 為原圖寬度（未知時為 0）
+func (b *Builder) Build(fileID, ext string, originalWidth int) []Rendition {
+	if fileID == "" {
+		return nil
+	}
+	if ext == "" {
+		ext = "jpg"
+	}
+	var out []Rendition
+	for _, p := range b.profiles {
+		pext := p.Ext
+		if pext == "" {
+			pext = ext
+		}
+		if p.OriginalTemplate != "" {
+			out = append(out, Rendition{
+				Format:   p.Format,
+				Width:    originalWidth,
+				URL:      expand(p.OriginalTemplate, b.host, fileID, 0, pext),
+				Original: true,
+			})
+		}
+		for _, w := range p.Widths {
+			out = append(out, Rendition{Format: p.Format, Width: w, URL: expand(p.Template, b.host, fileID, w, pext)})
+		}
+	}
+	return out
+}
+
+// Formats 回傳設定中的格式名稱（依設定順序）
+func (b *Builder) Formats() []string {
+	formats := make([]string, 0, len(b.profiles))
+	for _, p := range b.profiles {
+		formats = append(formats, p.Format)
+	}
+	return formats
+}
+
+func expand(tmpl, host, id string, width int, ext string) string {
+	return strings.NewReplacer(
+		"{host}", host,
+		"{id}", id,
+		"{width}", strconv.Itoa(width),
+		"{ext}", ext,
+	).Replace(tmpl)
+}
+
+// Pick 在指定格式中選出最接近 width 的 rendition：
+// 優先取寬度不小於 width 的最小尺寸，都不足時取最大尺寸；width <= 0 時回傳原尺寸。
+// format 為空字串時使用第一個 rendition 的格式。
+func Pick(rs []Rendition, width int, format string) (Rendition, bool) {
+	candidates := ofFormat(rs, format)
+	if len(candidates) == 0 {
+		return Rendition{}, false
+	}
+	if width <= 0 {
+		for _, r := range candidates {
+			if r.Original {
+				return r, true
+			}
+		}
+		return candidates[len(candidates)-1], true
+	}
+	for _, r := range candidates {
+		if r.Width >= width {
+			return r, true
+		}
+	}
+	return candidates[len(candidates)-1], true
+}
+
+// Srcset 以指定格式的固定寬度 renditions 組成 srcset 字串
+func Srcset(rs []Rendition, format string) string {
+	var parts []string
+	for _, r := range ofFormat(rs, format) {
+		if r.Original || r.Width <= 0 {
+			continue
+		}
+		parts = append(parts, r.URL+" "+strconv.Itoa(r.Width)+"w")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ofFormat 回傳指定格式的 renditions，依寬度排序；寬度未知的原尺寸排在最後
+func ofFormat(rs []Rendition, format string) []Rendition {
+	format = strings.ToLower(format)
+	if format == "" && len(rs) > 0 {
+		format = rs[0].Format
+	}
+	var out []Rendition
+	for _, r := range rs {
+		if r.Format == format {
+			out = append(out, r)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		wi, wj := out[i].Width, out[j].Width
+		if wi <= 0 || wj <= 0 {
+			return wj <= 0 && wi > 0
+		}
+		if wi == wj {
+			// 同寬度時固定尺寸優先於原尺寸
+			return !out[i].Original && out[j].Original
+		}
+		return wi < wj
+	})
+	return out
+}
@@ -0,0 +1,179 @@
+package repofake
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+
+	"go-story/internal/data"
+)
+
+// Recorder 將查詢轉給 src 並記下參數與結果；同樣參數的查詢只保留最後一次
+type Recorder struct {
+	src Source
+
+	mu    sync.Mutex
+	calls map[string]Call
+}
+
+// NewRecorder 建立包住 src 的 Recorder
+func NewRecorder(src Source) *Recorder {
+	return &Recorder{src: src, calls: map[string]Call{}}
+}
+
+func (r *Recorder) record(method string, result interface{}, err error, args ...interface{}) {
+	c := Call{Method: method, Args: marshalArgs(args...)}
+	if err != nil {
+		c.Error = err.Error()
+		c.Result = json.RawMessage("null")
+	} else {
+		b, mErr := json.Marshal(result)
+		if mErr != nil {
+			return
+		}
+		c.Result = b
+	}
+	r.mu.Lock()
+	r.calls[callKey(c.Method, c.Args)] = c
+	r.mu.Unlock()
+}
+
+// Fixture 回傳目前錄到的查詢，依方法名稱與參數排序
+func (r *Recorder) Fixture() *Fixture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := &Fixture{Calls: make([]Call, 0, len(r.calls))}
+	for _, c := range r.calls {
+		f.Calls = append(f.Calls, c)
+	}
+	sort.Slice(f.Calls, func(i, j int) bool {
+		if f.Calls[i].Method != f.Calls[j].Method {
+			return f.Calls[i].Method < f.Calls[j].Method
+		}
+		return string(f.Calls[i].Args) < string(f.Calls[j].Args)
+	})
+	return f
+}
+
+// Save 將錄到的查詢以縮排 JSON 寫到 path，可由 Load 讀回
+func (r *Recorder) Save(path string) error {
+	b, err := json.MarshalIndent(r.Fixture(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// QueryPosts 實作 schema.Repository
+func (r *Recorder) QueryPosts(ctx context.Context, where *data.PostWhereInput, orders []data.OrderRule, take, skip int) ([]data.Post, error) {
+	out, err := r.src.QueryPosts(ctx, where, orders, take, skip)
+	r.record("QueryPosts", out, err, where, orders, take, skip)
+	return out, err
+}
+
+// QueryPostsCount 實作 schema.Repository
+func (r *Recorder) QueryPostsCount(ctx context.Context, where *data.PostWhereInput) (int, error) {
+	n, err := r.src.QueryPostsCount(ctx, where)
+	r.record("QueryPostsCount", n, err, where)
+	return n, err
+}
+
+// QueryPostByUnique 實作 schema.Repository
+func (r *Recorder) QueryPostByUnique(ctx context.Context, where *data.PostWhereUniqueInput) (*data.Post, error) {
+	out, err := r.src.QueryPostByUnique(ctx, where)
+	r.record("QueryPostByUnique", out, err, where)
+	return out, err
+}
+
+// QueryExternals 實作 schema.Repository
+func (r *Recorder) QueryExternals(ctx context.Context, where *data.ExternalWhereInput, orders []data.OrderRule, take, skip int) ([]data.External, error) {
+	out, err := r.src.QueryExternals(ctx, where, orders, take, skip)
+	r.record("QueryExternals", out, err, where, orders, take, skip)
+	return out, err
+}
+
+// QueryExternalsCount 實作 schema.Repository
+func (r *Recorder) QueryExternalsCount(ctx context.Context, where *data.ExternalWhereInput) (int, error) {
+	n, err := r.src.QueryExternalsCount(ctx, where)
+	r.record("QueryExternalsCount", n, err, where)
+	return n, err
+}
+
+// QueryExternalByID 實作 schema.Repository
+func (r *Recorder) QueryExternalByID(ctx context.Context, id string) (*data.External, error) {
+	out, err := r.src.QueryExternalByID(ctx, id)
+	r.record("QueryExternalByID", out, err, id)
+	return out, err
+}
+
+// QueryExternalBySlug 實作 schema.Repository
+func (r *Recorder) QueryExternalBySlug(ctx context.Context, slug string) (*data.External, error) {
+	out, err := r.src.QueryExternalBySlug(ctx, slug)
+	r.record("QueryExternalBySlug", out, err, slug)
+	return out, err
+}
+
+// QueryPartnerByID 實作 schema.Repository
+func (r *Recorder) QueryPartnerByID(ctx context.Context, id string) (*data.Partner, error) {
+	out, err := r.src.QueryPartnerByID(ctx, id)
+	r.record("QueryPartnerByID", out, err, id)
+	return out, err
+}
+
+// QueryTopics 實作 schema.Repository
+func (r *Recorder) QueryTopics(ctx context.Context, where *data.TopicWhereInput, orders []data.OrderRule, take, skip int) ([]data.Topic, error) {
+	out, err := r.src.QueryTopics(ctx, where, orders, take, skip)
+	r.record("QueryTopics", out, err, where, orders, take, skip)
+	return out, err
+}
+
+// QueryTopicsCount 實作 schema.Repository
+func (r *Recorder) QueryTopicsCount(ctx context.Context, where *data.TopicWhereInput) (int, error) {
+	n, err := r.src.QueryTopicsCount(ctx, where)
+	r.record("QueryTopicsCount", n, err, where)
+	return n, err
+}
+
+// QueryTopicByUnique 實作 schema.Repository
+func (r *Recorder) QueryTopicByUnique(ctx context.Context, where *data.TopicWhereUniqueInput) (*data.Topic, error) {
+	out, err := r.src.QueryTopicByUnique(ctx, where)
+	r.record("QueryTopicByUnique", out, err, where)
+	return out, err
+}
+
+// QueryVideos 實作 schema.Repository
+func (r *Recorder) QueryVideos(ctx context.Context, where *data.VideoWhereInput, orders []data.OrderRule, take, skip int) ([]data.Video, error) {
+	out, err := r.src.QueryVideos(ctx, where, orders, take, skip)
+	r.record("QueryVideos", out, err, where, orders, take, skip)
+	return out, err
+}
+
+// QueryVideosCount 實作 schema.Repository
+func (r *Recorder) QueryVideosCount(ctx context.Context, where *data.VideoWhereInput) (int, error) {
+	n, err := r.src.QueryVideosCount(ctx, where)
+	r.record("QueryVideosCount", n, err, where)
+	return n, err
+}
+
+// QueryVideoByUnique 實作 schema.Repository
+func (r *Recorder) QueryVideoByUnique(ctx context.Context, where *data.VideoWhereUniqueInput) (*data.Video, error) {
+	out, err := r.src.QueryVideoByUnique(ctx, where)
+	r.record("QueryVideoByUnique", out, err, where)
+	return out, err
+}
+
+// QueryPathRecord 實作 redirect.Lookup
+func (r *Recorder) QueryPathRecord(ctx context.Context, kind, slug string) (*data.PathRecord, error) {
+	out, err := r.src.QueryPathRecord(ctx, kind, slug)
+	r.record("QueryPathRecord", out, err, kind, slug)
+	return out, err
+}
+
+// QuerySlugHistory 實作 redirect.Lookup
+func (r *Recorder) QuerySlugHistory(ctx context.Context, kind, slug string) (string, error) {
+	out, err := r.src.QuerySlugHistory(ctx, kind, slug)
+	r.record("QuerySlugHistory", out, err, kind, slug)
+	return out, err
+}
@@ -0,0 +1,217 @@
+// Package repofake 錄製與重播 repository 的查詢結果。
+// Recorder 包住真正的 *data.Repo，記下每次查詢的參數與結果；
+// Repo 以錄好的結果回應相同參數的查詢，讓 schema.Build 不需要資料庫也能執行。
+// 查詢以方法名稱與參數的 JSON 對應，不考慮 context（預覽授權等），錄製時應以匿名請求進行。
+package repofake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"go-story/internal/data"
+	"go-story/internal/redirect"
+	"go-story/internal/schema"
+)
+
+// ErrNotRecorded 表示查詢沒有錄製的結果
+var ErrNotRecorded = errors.New("repofake: query was not recorded")
+
+// Source 是可以錄製的查詢，由 *data.Repo 實作
+type Source interface {
+	schema.Repository
+	redirect.Lookup
+}
+
+var (
+	_ Source = (*data.Repo)(nil)
+	_ Source = (*Recorder)(nil)
+	_ Source = (*Repo)(nil)
+)
+
+// Call 是一次查詢的參數與結果
+type Call struct {
+	Method string          `json:"method"`
+	Args   json.RawMessage `json:"args"`
+	Result json.RawMessage `json:"result"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Fixture 是錄製的所有查詢
+type Fixture struct {
+	Calls []Call `json:"calls"`
+}
+
+func callKey(method string, args json.RawMessage) string {
+	return method + "\x00" + string(args)
+}
+
+func marshalArgs(args ...interface{}) json.RawMessage {
+	b, err := json.Marshal(args)
+	if err != nil {
+		// 參數都是 data 的 input struct，不會發生
+		panic(fmt.Sprintf("repofake: marshal args: %v", err))
+	}
+	return b
+}
+
+// Load 讀取 Recorder.Save 寫出的檔案
+func Load(path string) (*Repo, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read repo fixture: %w", err)
+	}
+	var f Fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("parse repo fixture %s: %w", path, err)
+	}
+	return New(&f), nil
+}
+
+// Repo 以 Fixture 回應查詢；沒有錄製的查詢回傳 ErrNotRecorded
+type Repo struct {
+	calls map[string]Call
+
+	mu     sync.Mutex
+	misses []string
+}
+
+// New 建立以 f 回應的 Repo
+func New(f *Fixture) *Repo {
+	r := &Repo{calls: map[string]Call{}}
+	for _, c := range f.Calls {
+		// 檔案中的 args 是縮排過的，壓縮後才與 marshalArgs 的結果一致
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, c.Args); err == nil {
+			c.Args = buf.Bytes()
+		}
+		r.calls[callKey(c.Method, c.Args)] = c
+	}
+	return r
+}
+
+// Misses 回傳沒有錄製結果的查詢（方法名稱與參數）
+func (r *Repo) Misses() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.misses...)
+}
+
+// replay 將 method 在 args 下錄到的結果解到 dest
+func (r *Repo) replay(method string, dest interface{}, args ...interface{}) error {
+	raw := marshalArgs(args...)
+	c, ok := r.calls[callKey(method, raw)]
+	if !ok {
+		r.mu.Lock()
+		r.misses = append(r.misses, method+" "+string(raw))
+		r.mu.Unlock()
+		return fmt.Errorf("%w: %s %s", ErrNotRecorded, method, raw)
+	}
+	if c.Error != "" {
+		return errors.New(c.Error)
+	}
+	if err := json.Unmarshal(c.Result, dest); err != nil {
+		return fmt.Errorf("repofake: decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+// QueryPosts 實作 schema.Repository
+func (r *Repo) QueryPosts(ctx context.Context, where *data.PostWhereInput, orders []data.OrderRule, take, skip int) ([]data.Post, error) {
+	var out []data.Post
+	return out, r.replay("QueryPosts", &out, where, orders, take, skip)
+}
+
+// QueryPostsCount 實作 schema.Repository
+func (r *Repo) QueryPostsCount(ctx context.Context, where *data.PostWhereInput) (int, error) {
+	var n int
+	return n, r.replay("QueryPostsCount", &n, where)
+}
+
+// QueryPostByUnique 實作 schema.Repository
+func (r *Repo) QueryPostByUnique(ctx context.Context, where *data.PostWhereUniqueInput) (*data.Post, error) {
+	var out *data.Post
+	return out, r.replay("QueryPostByUnique", &out, where)
+}
+
+// QueryExternals 實作 schema.Repository
+func (r *Repo) QueryExternals(ctx context.Context, where *data.ExternalWhereInput, orders []data.OrderRule, take, skip int) ([]data.External, error) {
+	var out []data.External
+	return out, r.replay("QueryExternals", &out, where, orders, take, skip)
+}
+
+// QueryExternalsCount 實作 schema.Repository
+func (r *Repo) QueryExternalsCount(ctx context.Context, where *data.ExternalWhereInput) (int, error) {
+	var n int
+	return n, r.replay("QueryExternalsCount", &n, where)
+}
+
+// QueryExternalByID 實作 schema.Repository
+func (r *Repo) QueryExternalByID(ctx context.Context, id string) (*data.External, error) {
+	var out *data.External
+	return out, r.replay("QueryExternalByID", &out, id)
+}
+
+// QueryExternalBySlug 實作 schema.Repository
+func (r *Repo) QueryExternalBySlug(ctx context.Context, slug string) (*data.External, error) {
+	var out *data.External
+	return out, r.replay("QueryExternalBySlug", &out, slug)
+}
+
+// QueryPartnerByID 實作 schema.Repository
+func (r *Repo) QueryPartnerByID(ctx context.Context, id string) (*data.Partner, error) {
+	var out *data.Partner
+	return out, r.replay("QueryPartnerByID", &out, id)
+}
+
+// QueryTopics 實作 schema.Repository
+func (r *Repo) QueryTopics(ctx context.Context, where *data.TopicWhereInput, orders []data.OrderRule, take, skip int) ([]data.Topic, error) {
+	var out []data.Topic
+	return out, r.replay("QueryTopics", &out, where, orders, take, skip)
+}
+
+// QueryTopicsCount 實作 schema.Repository
+func (r *Repo) QueryTopicsCount(ctx context.Context, where *data.TopicWhereInput) (int, error) {
+	var n int
+	return n, r.replay("QueryTopicsCount", &n, where)
+}
+
+// QueryTopicByUnique 實作 schema.Repository
+func (r *Repo) QueryTopicByUnique(ctx context.Context, where *data.TopicWhereUniqueInput) (*data.Topic, error) {
+	var out *data.Topic
+	return out, r.replay("QueryTopicByUnique", &out, where)
+}
+
+// QueryVideos 實作 schema.Repository
+func (r *Repo) QueryVideos(ctx context.Context, where *data.VideoWhereInput, orders []data.OrderRule, take, skip int) ([]data.Video, error) {
+	var out []data.Video
+	return out, r.replay("QueryVideos", &out, where, orders, take, skip)
+}
+
+// QueryVideosCount 實作 schema.Repository
+func (r *Repo) QueryVideosCount(ctx context.Context, where *data.VideoWhereInput) (int, error) {
+	var n int
+	return n, r.replay("QueryVideosCount", &n, where)
+}
+
+// QueryVideoByUnique 實作 schema.Repository
+func (r *Repo) QueryVideoByUnique(ctx context.Context, where *data.VideoWhereUniqueInput) (*data.Video, error) {
+	var out *data.Video
+	return out, r.replay("QueryVideoByUnique", &out, where)
+}
+
+// QueryPathRecord 實作 redirect.Lookup
+func (r *Repo) QueryPathRecord(ctx context.Context, kind, slug string) (*data.PathRecord, error) {
+	var out *data.PathRecord
+	return out, r.replay("QueryPathRecord", &out, kind, slug)
+}
+
+// QuerySlugHistory 實作 redirect.Lookup
+func (r *Repo) QuerySlugHistory(ctx context.Context, kind, slug string) (string, error) {
+	var out string
+	return out, r.replay("QuerySlugHistory", &out, kind, slug)
+}
@@ -0,0 +1,232 @@
+package schema_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-story/internal/data"
+	"go-story/internal/probe"
+	"go-story/internal/repofake"
+)
+
+// newFixtureSource 回傳產生 testdata 時使用的內容：免費、會員與成人文章各一則，
+// 兩則合作文章、list 與 timeline 專題各一則，以及一般影片與短影音各一部
+func newFixtureSource() *fixtureSource {
+	section := data.Section{ID: "1", Name: "新聞", Slug: "news", State: "active"}
+	category := data.Category{ID: "2", Name: "政治", Slug: "politics", State: "active", Sections: []data.Section{section}}
+	memberCat := data.Category{ID: "3", Name: "會員專區", Slug: "member", State: "active", IsMemberOnly: true}
+	writer := data.Contact{ID: "4", Name: "記者甲"}
+	tag := data.Tag{ID: "5", Name: "選舉", Slug: "election"}
+	brief := paragraphs("摘要文字")
+	free := data.Post{ID: "101", Slug: "free-post", Title: "免費文章", State: "published", Style: "article",
+		PublishedDate: "2026-10-01T08:00:00.000Z", UpdatedAt: "2026-10-02T08:00:00.000Z",
+		Sections: []data.Section{section}, Categories: []data.Category{category}, Writers: []data.Contact{writer}, Tags: []data.Tag{tag},
+		ApiDataBrief: brief, ApiData: paragraphs("第一段", "第二段", "第三段"), ExcerptSource: "摘要文字"}
+	member := data.Post{ID: "102", Slug: "member-post", Title: "會員文章", State: "published", Style: "article", IsMember: true,
+		PublishedDate: "2026-09-30T08:00:00.000Z", UpdatedAt: "2026-09-30T09:00:00.000Z",
+		Categories: []data.Category{memberCat}, ApiData: paragraphs("會員第一段", "會員第二段", "會員第三段"),
+		ExcerptSource: "會員第一段 會員第二段 會員第三段", ExcerptFromBody: true}
+	adult := data.Post{ID: "103", Slug: "adult-post", Title: "成人文章", State: "published", Style: "article", IsAdult: true,
+		PublishedDate: "2026-09-29T08:00:00.000Z", ApiData: paragraphs("成人內容")}
+	free.Relateds = []data.Post{{ID: member.ID, Slug: member.Slug, Title: member.Title}}
+
+	partner := &data.Partner{ID: "201", Slug: "partner-a", Name: "合作夥伴 A", ShowOnIndex: true}
+	ext1 := data.External{ID: "301", Slug: "ext-1", Title: "合作文章一", State: "published", Partner: partner,
+		PublishedDate: "2026-10-01T00:00:00.000Z", Brief: "外部摘要", Content: "<p>外部內文</p>"}
+	ext2 := data.External{ID: "302", Slug: "ext-2", Title: "合作文章二", State: "published", Partner: partner,
+		PublishedDate: "2026-09-01T00:00:00.000Z"}
+
+	one, two := 1, 2
+	topics := []data.Topic{
+		{ID: "401", Name: "專題列表", Slug: "topic-list", SortOrder: &one, State: "published", Type: "list", Leading: "video"},
+		{ID: "402", Name: "時間軸專題", Slug: "topic-timeline", SortOrder: &two, State: "published", Type: "timeline"},
+	}
+	videos := []data.Video{
+		{ID: "501", Name: "一般影片", State: "published", YoutubeUrl: "https://youtu.be/dQw4w9WgXcQ", PublishedDate: "2026-10-01T00:00:00.000Z"},
+		{ID: "502", Name: "短影音", IsShorts: true, State: "published", PublishedDate: "2026-10-02T00:00:00.000Z"},
+	}
+	return &fixtureSource{posts: []data.Post{free, member, adult}, externals: []data.External{ext1, ext2}, topics: topics, videos: videos}
+}
+
+// recordFixtures 以 newFixtureSource 為資料來源執行 default suite，將 go-story 自己的回應寫到
+// testdata/golden.json、resolvers 對 repository 的呼叫寫到 testdata/repo.json。
+// 兩邊都是 go-story，golden.json 只是輸出快照，不代表與 Keystone 一致。
+func recordFixtures(t *testing.T, suite *probe.Suite) {
+	t.Helper()
+	src := newFixtureSource()
+	golden := httptest.NewServer(fixtureHandler(t, src))
+	defer golden.Close()
+	repoRec := repofake.NewRecorder(src)
+	self := httptest.NewServer(fixtureHandler(t, repoRec))
+	defer self.Close()
+
+	rec := probe.NewRecorder(nil)
+	report := probe.RunSuite(&http.Client{Transport: rec}, suite, golden.URL, self.URL, probe.Options{Samples: fixtureSamples, SelfClient: http.DefaultClient})
+	if report.Summary.Matched != report.Summary.Total {
+		t.Fatalf("recording: %d of %d matched", report.Summary.Matched, report.Summary.Total)
+	}
+	if err := probe.SaveFixture(goldenFile, rec.Fixture(goldenEndpoint)); err != nil {
+		t.Fatal(err)
+	}
+	if err := repoRec.Save("testdata/repo.json"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// paragraphs 回傳每段一個 unstyled block 的 apiData
+func paragraphs(texts ...string) []interface{} {
+	var out []interface{}
+	for i, t := range texts {
+		out = append(out, map[string]interface{}{"id": fmt.Sprintf("b%d", i), "type": "unstyled", "content": []interface{}{t}})
+	}
+	return out
+}
+
+// fixtureSource 是產生 testdata 用的 repository，依 id、slug 與常用的布林條件篩選記憶體中的內容
+type fixtureSource struct {
+	posts     []data.Post
+	externals []data.External
+	topics    []data.Topic
+	videos    []data.Video
+}
+
+func boolMatch(f *data.BooleanFilter, v bool) bool {
+	return f == nil || f.Equals == nil || *f.Equals == v
+}
+
+func limit[T any](xs []T, take, skip int) []T {
+	if skip > len(xs) {
+		return nil
+	}
+	xs = xs[skip:]
+	if take > 0 && take < len(xs) {
+		xs = xs[:take]
+	}
+	return xs
+}
+
+func (s *fixtureSource) filterPosts(w *data.PostWhereInput) []data.Post {
+	var out []data.Post
+	for _, p := range s.posts {
+		if w != nil {
+			if !boolMatch(w.IsMember, p.IsMember) || !boolMatch(w.IsAdult, p.IsAdult) {
+				continue
+			}
+			if w.ID != nil && w.ID.Equals != nil && *w.ID.Equals != p.ID {
+				continue
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
+func (s *fixtureSource) QueryPosts(ctx context.Context, w *data.PostWhereInput, o []data.OrderRule, take, skip int) ([]data.Post, error) {
+	return limit(s.filterPosts(w), take, skip), nil
+}
+func (s *fixtureSource) QueryPostsCount(ctx context.Context, w *data.PostWhereInput) (int, error) {
+	return len(s.filterPosts(w)), nil
+}
+func (s *fixtureSource) QueryPostByUnique(ctx context.Context, w *data.PostWhereUniqueInput) (*data.Post, error) {
+	for i := range s.posts {
+		if (w.ID != nil && *w.ID == s.posts[i].ID) || (w.Slug != nil && *w.Slug == s.posts[i].Slug) {
+			return &s.posts[i], nil
+		}
+	}
+	return nil, nil
+}
+func (s *fixtureSource) QueryExternals(ctx context.Context, w *data.ExternalWhereInput, o []data.OrderRule, take, skip int) ([]data.External, error) {
+	return limit(s.externals, take, skip), nil
+}
+func (s *fixtureSource) QueryExternalsCount(ctx context.Context, w *data.ExternalWhereInput) (int, error) {
+	return len(s.externals), nil
+}
+func (s *fixtureSource) QueryExternalByID(ctx context.Context, id string) (*data.External, error) {
+	for i := range s.externals {
+		if s.externals[i].ID == id {
+			return &s.externals[i], nil
+		}
+	}
+	return nil, nil
+}
+func (s *fixtureSource) QueryExternalBySlug(ctx context.Context, slug string) (*data.External, error) {
+	for i := range s.externals {
+		if s.externals[i].Slug == slug {
+			return &s.externals[i], nil
+		}
+	}
+	return nil, nil
+}
+func (s *fixtureSource) QueryPartnerByID(ctx context.Context, id string) (*data.Partner, error) {
+	for _, e := range s.externals {
+		if e.Partner != nil && e.Partner.ID == id {
+			p := *e.Partner
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+func (s *fixtureSource) filterTopics(w *data.TopicWhereInput) []data.Topic {
+	var out []data.Topic
+	for _, t := range s.topics {
+		if w != nil && w.Type != nil && w.Type.Not != nil {
+			skip := false
+			for _, typ := range w.Type.Not.In {
+				if typ == t.Type {
+					skip = true
+				}
+			}
+			if skip || t.Type == "" {
+				continue
+			}
+		}
+		out = append(out, t)
+	}
+	return out
+}
+func (s *fixtureSource) QueryTopics(ctx context.Context, w *data.TopicWhereInput, o []data.OrderRule, take, skip int) ([]data.Topic, error) {
+	return limit(s.filterTopics(w), take, skip), nil
+}
+func (s *fixtureSource) QueryTopicsCount(ctx context.Context, w *data.TopicWhereInput) (int, error) {
+	return len(s.filterTopics(w)), nil
+}
+func (s *fixtureSource) QueryTopicByUnique(ctx context.Context, w *data.TopicWhereUniqueInput) (*data.Topic, error) {
+	for i := range s.topics {
+		if (w.ID != nil && *w.ID == s.topics[i].ID) || (w.Slug != nil && *w.Slug == s.topics[i].Slug) {
+			return &s.topics[i], nil
+		}
+	}
+	return nil, nil
+}
+func (s *fixtureSource) filterVideos(w *data.VideoWhereInput) []data.Video {
+	var out []data.Video
+	for _, v := range s.videos {
+		if w != nil && !boolMatch(w.IsShorts, v.IsShorts) {
+			continue
+		}
+		out = append(out, v)
+	}
+	return out
+}
+func (s *fixtureSource) QueryVideos(ctx context.Context, w *data.VideoWhereInput, o []data.OrderRule, take, skip int) ([]data.Video, error) {
+	return limit(s.filterVideos(w), take, skip), nil
+}
+func (s *fixtureSource) QueryVideosCount(ctx context.Context, w *data.VideoWhereInput) (int, error) {
+	return len(s.filterVideos(w)), nil
+}
+func (s *fixtureSource) QueryVideoByUnique(ctx context.Context, w *data.VideoWhereUniqueInput) (*data.Video, error) {
+	for i := range s.videos {
+		if w.ID != nil && *w.ID == s.videos[i].ID {
+			return &s.videos[i], nil
+		}
+	}
+	return nil, nil
+}
+func (s *fixtureSource) QueryPathRecord(ctx context.Context, kind, slug string) (*data.PathRecord, error) {
+	return nil, nil
+}
+func (s *fixtureSource) QuerySlugHistory(ctx context.Context, kind, slug string) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,105 @@
+package schema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-story/internal/links"
+	"go-story/internal/probe"
+	"go-story/internal/redirect"
+	"go-story/internal/repofake"
+	"go-story/internal/schema"
+	"go-story/internal/seo"
+	"go-story/internal/server"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/golden.json and testdata/repo.json")
+
+// goldenFile 是 go-story 自己對 default suite 的回應快照；internal/probe 的測試也以它作為比對資料
+const goldenFile = "testdata/golden.json"
+
+// goldenEndpoint 記錄在 golden.json 的 endpoint 欄位，標明回應來自 go-story 而不是 Keystone
+const goldenEndpoint = "go-story (internal/schema newFixtureSource)"
+
+// fixtureSamples 是錄製時每個種類取樣的數量，對應 probe --samples
+const fixtureSamples = 2
+
+// fixtureHandler 以產生快照時的設定建立 GraphQL handler：TrimBlocks 2、網站 https://www.example.com、名稱「範例新聞」
+func fixtureHandler(t *testing.T, repo repofake.Source) http.Handler {
+	t.Helper()
+	siteLinks := links.New("https://www.example.com")
+	s, err := schema.Build(repo, schema.Options{
+		TrimBlocks: 2,
+		SEO:        seo.New(siteLinks, "範例新聞"),
+		Redirects:  redirect.New(repo, siteLinks),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server.NewGraphQLHandler(s, nil, nil)
+}
+
+// testdata/golden.json 是 go-story 以 newFixtureSource 執行 default suite 的回應快照，testdata/repo.json 是
+// 同一次執行中 resolvers 對 repository 的呼叫；以 repo.json 重播 resolvers，輸出必須與快照一致。
+// 這是 go-story 自身輸出的回歸測試，與 Keystone 的一致性由 probe --record / --replay 對真實服務錄製的資料檢查。
+// 修改 resolvers 的輸出後以 go test ./internal/schema -run TestGoldenSnapshot -update 重新產生並檢查差異。
+func TestGoldenSnapshot(t *testing.T) {
+	suite, err := probe.LoadSuite(probe.DefaultSuite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		recordFixtures(t, suite)
+	}
+	repo, err := repofake.Load("testdata/repo.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := probe.LoadFixture(goldenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := fixtureHandler(t, repo)
+
+	// golden.json 也含有取樣查詢，這裡只重播 suite 中的 test
+	tests := map[string]probe.Test{}
+	for _, tc := range suite.Tests {
+		tests[probe.NormalizeQuery(tc.Query)] = tc
+	}
+	replayed := 0
+	for _, e := range golden.Entries {
+		tc, ok := tests[probe.NormalizeQuery(e.Query)]
+		if !ok {
+			continue
+		}
+		replayed++
+		body := map[string]interface{}{"query": e.Query}
+		if e.OperationName != "" {
+			body["operationName"] = e.OperationName
+		}
+		if len(e.Variables) > 0 {
+			body["variables"] = e.Variables
+		}
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/api/graphql", bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		want := probe.Result{StatusCode: e.Status, Body: e.Body}
+		got := probe.Result{StatusCode: w.Code, Body: w.Body.Bytes()}
+		if match, note, diff := probe.Compare(want, got, suite.Compare.Merge(tc.Compare)); !match {
+			t.Errorf("%s %s: %s %v", tc.Name, e.Variables, note, diff)
+		}
+	}
+	if replayed == 0 {
+		t.Fatal("golden.json has no entries for the default suite")
+	}
+	if misses := repo.Misses(); len(misses) > 0 {
+		t.Errorf("repository queries not in repo.json: %v", misses)
+	}
+}
@@ -0,0 +1,49 @@
+package schema
+
+import (
+	"go-story/internal/auth"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Guard 依 request context 中的 caller claims（與欄位的 source）決定是否可以讀取欄位
+type Guard func(p graphql.ResolveParams) bool
+
+// guarded 包裝 resolver：guard 未通過時欄位回傳 null，不產生錯誤。
+// 用於付費牆這類「內容存在但不給看」的欄位。
+func guarded(g Guard, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if !g(p) {
+			return nil, nil
+		}
+		return resolve(p)
+	}
+}
+
+// allGuards 所有 guard 都通過時才通過
+func allGuards(gs ...Guard) Guard {
+	return func(p graphql.ResolveParams) bool {
+		for _, g := range gs {
+			if !g(p) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// memberGuard 允許會員讀取付費文章全文；非付費文章所有人皆可讀取
+func memberGuard(p graphql.ResolveParams) bool {
+	return canReadFull(p.Context, normalizePost(p.Source))
+}
+
+// editorGuard 只允許 editor / admin
+func editorGuard(p graphql.ResolveParams) bool {
+	return auth.FromContext(p.Context).IsEditor()
+}
+
+// adultGuard 只讓完成年齡驗證的 caller 讀取成人文章的內容；
+// 未通過時文章只剩下 isAdult: true 與標題等外殼欄位。
+func adultGuard(p graphql.ResolveParams) bool {
+	return !normalizePost(p.Source).IsAdult || auth.AgeVerified(p.Context)
+}
@@ -0,0 +1,102 @@
+package schema_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go-story/internal/auth"
+	"go-story/internal/data"
+	"go-story/internal/schema"
+
+	"github.com/graphql-go/graphql"
+)
+
+// stubRepo 以記憶體中的文章回應查詢；沒有準備的種類回傳空結果
+type stubRepo struct {
+	posts []data.Post
+}
+
+func (r *stubRepo) QueryPosts(ctx context.Context, where *data.PostWhereInput, orders []data.OrderRule, take, skip int) ([]data.Post, error) {
+	return r.posts, nil
+}
+func (r *stubRepo) QueryPostsCount(ctx context.Context, where *data.PostWhereInput) (int, error) {
+	return len(r.posts), nil
+}
+func (r *stubRepo) QueryPostByUnique(ctx context.Context, where *data.PostWhereUniqueInput) (*data.Post, error) {
+	for i := range r.posts {
+		if (where.ID != nil && *where.ID == r.posts[i].ID) || (where.Slug != nil && *where.Slug == r.posts[i].Slug) {
+			return &r.posts[i], nil
+		}
+	}
+	return nil, nil
+}
+func (r *stubRepo) QueryExternals(ctx context.Context, where *data.ExternalWhereInput, orders []data.OrderRule, take, skip int) ([]data.External, error) {
+	return nil, nil
+}
+func (r *stubRepo) QueryExternalsCount(ctx context.Context, where *data.ExternalWhereInput) (int, error) {
+	return 0, nil
+}
+func (r *stubRepo) QueryExternalByID(ctx context.Context, id string) (*data.External, error) {
+	return nil, nil
+}
+func (r *stubRepo) QueryExternalBySlug(ctx context.Context, slug string) (*data.External, error) {
+	return nil, nil
+}
+func (r *stubRepo) QueryPartnerByID(ctx context.Context, id string) (*data.Partner, error) {
+	return nil, nil
+}
+func (r *stubRepo) QueryTopics(ctx context.Context, where *data.TopicWhereInput, orders []data.OrderRule, take, skip int) ([]data.Topic, error) {
+	return nil, nil
+}
+func (r *stubRepo) QueryTopicsCount(ctx context.Context, where *data.TopicWhereInput) (int, error) {
+	return 0, nil
+}
+func (r *stubRepo) QueryTopicByUnique(ctx context.Context, where *data.TopicWhereUniqueInput) (*data.Topic, error) {
+	return nil, nil
+}
+func (r *stubRepo) QueryVideos(ctx context.Context, where *data.VideoWhereInput, orders []data.OrderRule, take, skip int) ([]data.Video, error) {
+	return nil, nil
+}
+func (r *stubRepo) QueryVideosCount(ctx context.Context, where *data.VideoWhereInput) (int, error) {
+	return 0, nil
+}
+func (r *stubRepo) QueryVideoByUnique(ctx context.Context, where *data.VideoWhereUniqueInput) (*data.Video, error) {
+	return nil, nil
+}
+
+// caller 以本機 JWKS 替身簽發 token 並驗證，回傳帶有 claims 的 context；claims 為 nil 時為匿名 caller
+func caller(t *testing.T, issuer *auth.LocalIssuer, claims *auth.Claims) context.Context {
+	t.Helper()
+	ctx := context.Background()
+	if claims == nil {
+		return ctx
+	}
+	token, err := issuer.Issue(*claims, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verified, err := issuer.Verifier().Verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return auth.NewContext(ctx, verified)
+}
+
+// run 以 repo 建立 schema 並執行 query，回傳 data 的 JSON
+func run(t *testing.T, repo schema.Repository, ctx context.Context, query string) map[string]interface{} {
+	t.Helper()
+	s, err := schema.Build(repo, schema.Options{TrimBlocks: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := graphql.Do(graphql.Params{Schema: s, RequestString: query, Context: ctx})
+	if len(res.Errors) > 0 {
+		t.Fatalf("query errors: %v", res.Errors)
+	}
+	b, _ := json.Marshal(res.Data)
+	var out map[string]interface{}
+	_ = json.Unmarshal(b, &out)
+	return out
+}
@@ -0,0 +1,75 @@
+package schema
+
+import (
+	"context"
+	"strconv"
+
+	"go-story/internal/auth"
+	"go-story/internal/data"
+)
+
+// defaultTrimBlocks 是未設定 Options.TrimBlocks 時 trimmedContent 保留的段落數
+const defaultTrimBlocks = 5
+
+// canReadFull 判斷 caller 是否可以讀取文章全文
+func canReadFull(ctx context.Context, p data.Post) bool {
+	return !p.Paywalled() || auth.FromContext(ctx).IsMember()
+}
+
+// trimDraftContent 保留 draft.js raw content 的前 n 個 blocks，
+// entityMap 只留下被保留 blocks 引用到的 entity。
+func trimDraftContent(content map[string]any, n int) map[string]any {
+	if content == nil {
+		return nil
+	}
+	blocks, _ := content["blocks"].([]interface{})
+	if len(blocks) > n {
+		blocks = blocks[:n]
+	}
+
+	entityMap, _ := content["entityMap"].(map[string]interface{})
+	trimmedEntities := map[string]interface{}{}
+	for _, b := range blocks {
+		block, _ := b.(map[string]interface{})
+		ranges, _ := block["entityRanges"].([]interface{})
+		for _, r := range ranges {
+			rng, _ := r.(map[string]interface{})
+			key := entityKey(rng["key"])
+			if entity, ok := entityMap[key]; ok {
+				trimmedEntities[key] = entity
+			}
+		}
+	}
+
+	result := map[string]any{}
+	for k, v := range content {
+		result[k] = v
+	}
+	result["blocks"] = blocks
+	result["entityMap"] = trimmedEntities
+	return result
+}
+
+// trimApiData 保留 apiData（draftConverter 產物）的前 n 個 blocks
+func trimApiData(apiData interface{}, n int) interface{} {
+	blocks, ok := apiData.([]interface{})
+	if !ok {
+		return apiData
+	}
+	if len(blocks) > n {
+		return blocks[:n]
+	}
+	return blocks
+}
+
+// entityKey 將 entityRanges 中的 key（JSON 數字或字串）轉為 entityMap 的 key
+func entityKey(v interface{}) string {
+	switch k := v.(type) {
+	case string:
+		return k
+	case float64:
+		return strconv.FormatFloat(k, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
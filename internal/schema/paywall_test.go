@@ -0,0 +1,101 @@
+package schema_test
+
+import (
+	"testing"
+
+	"go-story/internal/auth"
+	"go-story/internal/data"
+)
+
+func paywallPost(id string, isMember, categoryMemberOnly bool) data.Post {
+	blocks := []interface{}{}
+	for _, text := range []string{"one", "two", "three", "four"} {
+		blocks = append(blocks, map[string]interface{}{"type": "unstyled", "content": []interface{}{text}})
+	}
+	return data.Post{
+		ID:         id,
+		Slug:       "post-" + id,
+		Title:      "title " + id,
+		State:      "published",
+		IsMember:   isMember,
+		Categories: []data.Category{{ID: "1", Slug: "c", IsMemberOnly: categoryMemberOnly}},
+		ApiData:    blocks,
+	}
+}
+
+func TestPaywallTrimming(t *testing.T) {
+	issuer, err := auth.NewLocalIssuer("https://issuer.test", "go-story")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &stubRepo{posts: []data.Post{
+		paywallPost("1", false, false), // 免費文章
+		paywallPost("2", true, false),  // Post.isMember
+		paywallPost("3", false, true),  // 會員限定分類
+	}}
+	callers := map[string]*auth.Claims{
+		"anonymous":  nil,
+		"non-member": {Subject: "u1", MemberTier: "none"},
+		"member":     {Subject: "u2", MemberTier: "basic"},
+	}
+	for name, claims := range callers {
+		ctx := caller(t, issuer, claims)
+		for _, id := range []string{"1", "2", "3"} {
+			out := run(t, repo, ctx, `{ post(where: {id: "`+id+`"}) { apiData trimmedApiData } }`)
+			post := out["post"].(map[string]interface{})
+			full := id == "1" || name == "member"
+			if got := post["apiData"] != nil; got != full {
+				t.Errorf("%s post %s: apiData present = %v, want %v", name, id, got, full)
+			}
+			trimmed, _ := post["trimmedApiData"].([]interface{})
+			if len(trimmed) != 2 {
+				t.Errorf("%s post %s: trimmedApiData has %d blocks, want 2", name, id, len(trimmed))
+			}
+		}
+	}
+}
+
+func TestPaywalledByCategory(t *testing.T) {
+	if !paywallPost("1", false, true).Paywalled() {
+		t.Error("post in a member-only category is not paywalled")
+	}
+	if paywallPost("1", false, false).Paywalled() {
+		t.Error("free post is paywalled")
+	}
+}
+
+// 摘要取自全文時，非會員的 excerpt 只能來自 trimmedApiData 的段落
+func TestExcerptRespectsPaywall(t *testing.T) {
+	issuer, err := auth.NewLocalIssuer("https://issuer.test", "go-story")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withSource := func(p data.Post, fromBody bool) data.Post {
+		p.ExcerptSource = "one two three four"
+		p.ExcerptFromBody = fromBody
+		return p
+	}
+	repo := &stubRepo{posts: []data.Post{
+		withSource(paywallPost("1", false, false), true), // 免費文章
+		withSource(paywallPost("2", true, false), true),  // 付費文章，摘要取自全文
+		withSource(paywallPost("3", true, false), false), // 付費文章，摘要取自 apiDataBrief
+	}}
+	cases := []struct {
+		caller string
+		claims *auth.Claims
+		id     string
+		want   string
+	}{
+		{"anonymous", nil, "1", "one two three four"},
+		{"anonymous", nil, "2", "one two"},
+		{"non-member", &auth.Claims{Subject: "u1", MemberTier: "none"}, "2", "one two"},
+		{"member", &auth.Claims{Subject: "u2", MemberTier: "basic"}, "2", "one two three four"},
+		{"anonymous", nil, "3", "one two three four"},
+	}
+	for _, c := range cases {
+		out := run(t, repo, caller(t, issuer, c.claims), `{ post(where: {id: "`+c.id+`"}) { excerpt(length: 100) } }`)
+		if got := out["post"].(map[string]interface{})["excerpt"]; got != c.want {
+			t.Errorf("%s post %s: excerpt = %q, want %q", c.caller, c.id, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package schema
+
+import "github.com/graphql-go/graphql"
+
+var pathResolutionType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "PathResolution",
+	Description: "前台路徑的解析結果；status 為 edge 應使用的 HTTP 狀態（200 / 301 / 302 / 404 / 410 / 508）",
+	Fields: graphql.Fields{
+		"path":     &graphql.Field{Type: graphql.String},
+		"status":   &graphql.Field{Type: graphql.Int},
+		"kind":     &graphql.Field{Type: graphql.String},
+		"id":       &graphql.Field{Type: graphql.ID},
+		"slug":     &graphql.Field{Type: graphql.String},
+		"location": &graphql.Field{Type: graphql.String},
+		"chain":    &graphql.Field{Type: graphql.NewList(graphql.String)},
+		"loop":     &graphql.Field{Type: graphql.Boolean},
+	},
+})
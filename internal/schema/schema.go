@@ -1,17 +1,58 @@
 package schema
 
 import (
+	"context"
 	"fmt"
 	"go-story/internal/data"
+	"go-story/internal/preview"
+	"go-story/internal/redirect"
+	"go-story/internal/render"
+	"go-story/internal/rendition"
+	"go-story/internal/seo"
 
 	"github.com/graphql-go/graphql"
 	"github.com/graphql-go/graphql/language/ast"
 	"github.com/mitchellh/mapstructure"
 )
 
+// Options holds optional settings for Build.
+type Options struct {
+	// Preview 用來驗證單筆查詢的 preview 參數；nil 表示不啟用預覽模式
+	Preview *preview.Signer
+	// TrimBlocks 為 trimmedContent / trimmedApiData 保留的段落數，0 時使用預設值
+	TrimBlocks int
+	// SEO 產生 seo / structuredData 欄位；nil 時兩個欄位皆回傳 null
+	SEO *seo.Builder
+	// Redirects 提供 resolvePath 查詢；nil 時該查詢回傳錯誤
+	Redirects *redirect.Resolver
+}
+
+// Repository 提供 resolvers 所需的查詢，由 *data.Repo 實作；
+// 離線測試時可改用 repofake 以錄好的結果回應
+type Repository interface {
+	QueryPosts(ctx context.Context, where *data.PostWhereInput, orders []data.OrderRule, take, skip int) ([]data.Post, error)
+	QueryPostsCount(ctx context.Context, where *data.PostWhereInput) (int, error)
+	QueryPostByUnique(ctx context.Context, where *data.PostWhereUniqueInput) (*data.Post, error)
+	QueryExternals(ctx context.Context, where *data.ExternalWhereInput, orders []data.OrderRule, take, skip int) ([]data.External, error)
+	QueryExternalsCount(ctx context.Context, where *data.ExternalWhereInput) (int, error)
+	QueryExternalByID(ctx context.Context, id string) (*data.External, error)
+	QueryExternalBySlug(ctx context.Context, slug string) (*data.External, error)
+	QueryPartnerByID(ctx context.Context, id string) (*data.Partner, error)
+	QueryTopics(ctx context.Context, where *data.TopicWhereInput, orders []data.OrderRule, take, skip int) ([]data.Topic, error)
+	QueryTopicsCount(ctx context.Context, where *data.TopicWhereInput) (int, error)
+	QueryTopicByUnique(ctx context.Context, where *data.TopicWhereUniqueInput) (*data.Topic, error)
+	QueryVideos(ctx context.Context, where *data.VideoWhereInput, orders []data.OrderRule, take, skip int) ([]data.Video, error)
+	QueryVideosCount(ctx context.Context, where *data.VideoWhereInput) (int, error)
+	QueryVideoByUnique(ctx context.Context, where *data.VideoWhereUniqueInput) (*data.Video, error)
+}
+
 // Build constructs the GraphQL schema using provided repo.
-func Build(repo *data.Repo) (graphql.Schema, error) {
+func Build(repo Repository, opts Options) (graphql.Schema, error) {
 	jsonScalar := newJSONScalar()
+	trimBlocks := opts.TrimBlocks
+	if trimBlocks <= 0 {
+		trimBlocks = defaultTrimBlocks
+	}
 	dateTimeScalar := newDateTimeScalar()
 
 	// Input types
@@ -22,6 +63,7 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 	})
 	stringFilterFields["equals"] = &graphql.InputObjectFieldConfig{Type: graphql.String}
 	stringFilterFields["in"] = &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.String)}
+	stringFilterFields["contains"] = &graphql.InputObjectFieldConfig{Type: graphql.String}
 	stringFilterFields["not"] = &graphql.InputObjectFieldConfig{Type: stringFilterInput}
 
 	booleanFilterFields := graphql.InputObjectConfigFieldMap{}
@@ -37,8 +79,21 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 		Fields: dateTimeNullableFilterFields,
 	})
 	dateTimeNullableFilterFields["equals"] = &graphql.InputObjectFieldConfig{Type: dateTimeScalar}
+	dateTimeNullableFilterFields["lt"] = &graphql.InputObjectFieldConfig{Type: dateTimeScalar}
+	dateTimeNullableFilterFields["lte"] = &graphql.InputObjectFieldConfig{Type: dateTimeScalar}
+	dateTimeNullableFilterFields["gt"] = &graphql.InputObjectFieldConfig{Type: dateTimeScalar}
+	dateTimeNullableFilterFields["gte"] = &graphql.InputObjectFieldConfig{Type: dateTimeScalar}
 	dateTimeNullableFilterFields["not"] = &graphql.InputObjectFieldConfig{Type: dateTimeNullableFilter}
 
+	idFilterInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "IDFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"equals": &graphql.InputObjectFieldConfig{Type: graphql.ID},
+			"in":     &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.ID))},
+			"notIn":  &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.ID))},
+		},
+	})
+
 	sectionWhereInputType := graphql.NewInputObject(graphql.InputObjectConfig{
 		Name: "SectionWhereInput",
 		Fields: graphql.InputObjectConfigFieldMap{
@@ -77,20 +132,70 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 	partnerWhereInputType := graphql.NewInputObject(graphql.InputObjectConfig{
 		Name: "PartnerWhereInput",
 		Fields: graphql.InputObjectConfigFieldMap{
+			"slug":        &graphql.InputObjectFieldConfig{Type: stringFilterInput},
+			"showOnIndex": &graphql.InputObjectFieldConfig{Type: booleanFilterInput},
+		},
+	})
+
+	tagWhereInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "TagWhereInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"id":   &graphql.InputObjectFieldConfig{Type: idFilterInput},
 			"slug": &graphql.InputObjectFieldConfig{Type: stringFilterInput},
 		},
 	})
+	tagManyRelationFilterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "TagManyRelationFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"some": &graphql.InputObjectFieldConfig{Type: tagWhereInputType},
+		},
+	})
+
+	contactWhereInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ContactWhereInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"id": &graphql.InputObjectFieldConfig{Type: idFilterInput},
+		},
+	})
+	contactManyRelationFilterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ContactManyRelationFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"some": &graphql.InputObjectFieldConfig{Type: contactWhereInputType},
+		},
+	})
+
+	// TopicWhereInput（同時作為 PostWhereInput.topics 的關聯過濾）
+	topicWhereInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "TopicWhereInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"id":            &graphql.InputObjectFieldConfig{Type: idFilterInput},
+			"state":         &graphql.InputObjectFieldConfig{Type: stringFilterInput},
+			"type":          &graphql.InputObjectFieldConfig{Type: stringFilterInput},
+			"style":         &graphql.InputObjectFieldConfig{Type: stringFilterInput},
+			"isFeatured":    &graphql.InputObjectFieldConfig{Type: booleanFilterInput},
+			"sections":      &graphql.InputObjectFieldConfig{Type: sectionManyRelationFilterType},
+			"tags":          &graphql.InputObjectFieldConfig{Type: tagManyRelationFilterType},
+			"publishedDate": &graphql.InputObjectFieldConfig{Type: dateTimeNullableFilter},
+		},
+	})
 
 	// PostWhereInput: 根據 Lilith schema，不包含 slug，但包含 AND/OR/NOT
 	// 注意：graphql-go 可能不支援 InputObjectConfigFieldMapThunk，所以先不加入 AND/OR/NOT
 	// 如果 probe 測試需要這些，我們可以後續加入
 	var postWhereInputType *graphql.InputObject
 	postWhereInputFields := graphql.InputObjectConfigFieldMap{
-		"sections":   &graphql.InputObjectFieldConfig{Type: sectionManyRelationFilterType},
-		"categories": &graphql.InputObjectFieldConfig{Type: categoryManyRelationFilterType},
-		"state":      &graphql.InputObjectFieldConfig{Type: stringFilterInput},
-		"isAdult":    &graphql.InputObjectFieldConfig{Type: booleanFilterInput},
-		"isMember":   &graphql.InputObjectFieldConfig{Type: booleanFilterInput},
+		"id":            &graphql.InputObjectFieldConfig{Type: idFilterInput},
+		"sections":      &graphql.InputObjectFieldConfig{Type: sectionManyRelationFilterType},
+		"categories":    &graphql.InputObjectFieldConfig{Type: categoryManyRelationFilterType},
+		"tags":          &graphql.InputObjectFieldConfig{Type: tagManyRelationFilterType},
+		"writers":       &graphql.InputObjectFieldConfig{Type: contactManyRelationFilterType},
+		"topics":        &graphql.InputObjectFieldConfig{Type: topicWhereInputType},
+		"state":         &graphql.InputObjectFieldConfig{Type: stringFilterInput},
+		"style":         &graphql.InputObjectFieldConfig{Type: stringFilterInput},
+		"publishedDate": &graphql.InputObjectFieldConfig{Type: dateTimeNullableFilter},
+		"isAdult":       &graphql.InputObjectFieldConfig{Type: booleanFilterInput},
+		"isMember":      &graphql.InputObjectFieldConfig{Type: booleanFilterInput},
+		"isFeatured":    &graphql.InputObjectFieldConfig{Type: booleanFilterInput},
 	}
 	postWhereInputType = graphql.NewInputObject(graphql.InputObjectConfig{
 		Name:   "PostWhereInput",
@@ -100,6 +205,12 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 	postWhereInputFields["AND"] = &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(postWhereInputType))}
 	postWhereInputFields["OR"] = &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(postWhereInputType))}
 	postWhereInputFields["NOT"] = &graphql.InputObjectFieldConfig{Type: postWhereInputType}
+	postManyRelationFilterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "PostManyRelationFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"some": &graphql.InputObjectFieldConfig{Type: postWhereInputType},
+		},
+	})
 
 	postWhereUniqueInputType := graphql.NewInputObject(graphql.InputObjectConfig{
 		Name: "PostWhereUniqueInput",
@@ -114,7 +225,11 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 	externalWhereInputFields := graphql.InputObjectConfigFieldMap{
 		"state":         &graphql.InputObjectFieldConfig{Type: stringFilterInput},
 		"partner":       &graphql.InputObjectFieldConfig{Type: partnerWhereInputType},
+		"sections":      &graphql.InputObjectFieldConfig{Type: sectionManyRelationFilterType},
+		"categories":    &graphql.InputObjectFieldConfig{Type: categoryManyRelationFilterType},
+		"tags":          &graphql.InputObjectFieldConfig{Type: tagManyRelationFilterType},
 		"publishedDate": &graphql.InputObjectFieldConfig{Type: dateTimeNullableFilter},
+		"updatedAt":     &graphql.InputObjectFieldConfig{Type: dateTimeNullableFilter},
 	}
 	externalWhereInputType = graphql.NewInputObject(graphql.InputObjectConfig{
 		Name:   "ExternalWhereInput",
@@ -125,14 +240,6 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 	externalWhereInputFields["OR"] = &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(externalWhereInputType))}
 	externalWhereInputFields["NOT"] = &graphql.InputObjectFieldConfig{Type: externalWhereInputType}
 
-	// TopicWhereInput
-	topicWhereInputType := graphql.NewInputObject(graphql.InputObjectConfig{
-		Name: "TopicWhereInput",
-		Fields: graphql.InputObjectConfigFieldMap{
-			"state": &graphql.InputObjectFieldConfig{Type: stringFilterInput},
-		},
-	})
-
 	// TopicWhereUniqueInput
 	topicWhereUniqueInputType := graphql.NewInputObject(graphql.InputObjectConfig{
 		Name: "TopicWhereUniqueInput",
@@ -158,26 +265,15 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 	videoWhereInputType := graphql.NewInputObject(graphql.InputObjectConfig{
 		Name: "VideoWhereInput",
 		Fields: graphql.InputObjectConfigFieldMap{
-			"state":        &graphql.InputObjectFieldConfig{Type: stringFilterInput},
-			"isShorts":     &graphql.InputObjectFieldConfig{Type: booleanFilterInput},
-			"videoSection": &graphql.InputObjectFieldConfig{Type: stringFilterInput},
-			"youtubeUrl":   &graphql.InputObjectFieldConfig{Type: stringFilterInput},
-			"tags": &graphql.InputObjectFieldConfig{Type: graphql.NewInputObject(graphql.InputObjectConfig{
-				Name: "TagManyRelationFilter",
-				Fields: graphql.InputObjectConfigFieldMap{
-					"some": &graphql.InputObjectFieldConfig{Type: graphql.NewInputObject(graphql.InputObjectConfig{
-						Name: "TagWhereInput",
-						Fields: graphql.InputObjectConfigFieldMap{
-							"id": &graphql.InputObjectFieldConfig{Type: graphql.NewInputObject(graphql.InputObjectConfig{
-								Name: "IDFilter",
-								Fields: graphql.InputObjectConfigFieldMap{
-									"equals": &graphql.InputObjectFieldConfig{Type: graphql.ID},
-								},
-							})},
-						},
-					})},
-				},
-			})},
+			"name":          &graphql.InputObjectFieldConfig{Type: stringFilterInput},
+			"state":         &graphql.InputObjectFieldConfig{Type: stringFilterInput},
+			"isShorts":      &graphql.InputObjectFieldConfig{Type: booleanFilterInput},
+			"isFeed":        &graphql.InputObjectFieldConfig{Type: booleanFilterInput},
+			"videoSection":  &graphql.InputObjectFieldConfig{Type: stringFilterInput},
+			"youtubeUrl":    &graphql.InputObjectFieldConfig{Type: stringFilterInput},
+			"tags":          &graphql.InputObjectFieldConfig{Type: tagManyRelationFilterType},
+			"related_posts": &graphql.InputObjectFieldConfig{Type: postManyRelationFilterType},
+			"publishedDate": &graphql.InputObjectFieldConfig{Type: dateTimeNullableFilter},
 		},
 	})
 
@@ -206,6 +302,14 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 		},
 	})
 
+	contentFormatEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "ContentFormat",
+		Values: graphql.EnumValueConfigMap{
+			"HTML": &graphql.EnumValueConfig{Value: render.FormatHTML},
+			"AMP":  &graphql.EnumValueConfig{Value: render.FormatAMP},
+		},
+	})
+
 	postOrderByInput := graphql.NewInputObject(graphql.InputObjectConfig{
 		Name: "PostOrderByInput",
 		Fields: graphql.InputObjectConfigFieldMap{
@@ -218,6 +322,8 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 	externalOrderByInput := graphql.NewInputObject(graphql.InputObjectConfig{
 		Name: "ExternalOrderByInput",
 		Fields: graphql.InputObjectConfigFieldMap{
+			"id":            &graphql.InputObjectFieldConfig{Type: orderDirectionEnum},
+			"title":         &graphql.InputObjectFieldConfig{Type: orderDirectionEnum},
 			"publishedDate": &graphql.InputObjectFieldConfig{Type: orderDirectionEnum},
 			"updatedAt":     &graphql.InputObjectFieldConfig{Type: orderDirectionEnum},
 		},
@@ -320,6 +426,16 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 	// 先聲明 postType 變數，以便在 videoType 和 topicType 中使用
 	var postType *graphql.Object
 
+	renditionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ImageRendition",
+		Fields: graphql.Fields{
+			"format":   &graphql.Field{Type: graphql.String},
+			"width":    &graphql.Field{Type: graphql.Int},
+			"url":      &graphql.Field{Type: graphql.String},
+			"original": &graphql.Field{Type: graphql.Boolean},
+		},
+	})
+
 	photoType := graphql.NewObject(graphql.ObjectConfig{
 		Name: "Photo",
 		Fields: graphql.Fields{
@@ -367,6 +483,39 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 					return filterResizedByAspectRatio(photo.ResizedWebp, photo.ImageFile.Width, photo.ImageFile.Height), nil
 				},
 			},
+			"renditions": &graphql.Field{
+				Type: graphql.NewList(renditionType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return normalizePhoto(p.Source).Renditions, nil
+				},
+			},
+			"url": &graphql.Field{
+				Type:        graphql.String,
+				Description: "最接近 width 的 rendition 網址（不小於 width 的最小尺寸，都不足時取最大尺寸）；未指定 width 時回傳原尺寸",
+				Args: graphql.FieldConfigArgument{
+					"width":  &graphql.ArgumentConfig{Type: graphql.Int},
+					"format": &graphql.ArgumentConfig{Type: graphql.String, Description: "rendition profile 的格式名稱，例如 original、webp、avif"},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					width, _ := p.Args["width"].(int)
+					format, _ := p.Args["format"].(string)
+					r, ok := rendition.Pick(normalizePhoto(p.Source).Renditions, width, format)
+					if !ok {
+						return nil, nil
+					}
+					return r.URL, nil
+				},
+			},
+			"srcset": &graphql.Field{
+				Type: graphql.String,
+				Args: graphql.FieldConfigArgument{
+					"format": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					format, _ := p.Args["format"].(string)
+					return rendition.Srcset(normalizePhoto(p.Source).Renditions, format), nil
+				},
+			},
 		},
 	})
 
@@ -374,7 +523,7 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 	videoType = graphql.NewObject(graphql.ObjectConfig{
 		Name: "Video",
 		Fields: graphql.FieldsThunk(func() graphql.Fields {
-			return graphql.Fields{
+			return withFields(graphql.Fields{
 				"id":              &graphql.Field{Type: graphql.ID},
 				"name":            &graphql.Field{Type: graphql.String},
 				"isShorts":        &graphql.Field{Type: graphql.Boolean},
@@ -412,7 +561,7 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 				"tags":                &graphql.Field{Type: graphql.NewList(tagType)},
 				"related_posts":       &graphql.Field{Type: graphql.NewList(postType)},
 				"createdAt":           &graphql.Field{Type: dateTimeScalar},
-			}
+			}, videoSEOFields(opts.SEO, jsonScalar))
 		}),
 	})
 
@@ -431,7 +580,7 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 	topicType = graphql.NewObject(graphql.ObjectConfig{
 		Name: "Topic",
 		Fields: graphql.FieldsThunk(func() graphql.Fields {
-			return graphql.Fields{
+			return withFields(graphql.Fields{
 				"id":            &graphql.Field{Type: graphql.ID},
 				"name":          &graphql.Field{Type: graphql.String},
 				"slug":          &graphql.Field{Type: graphql.String},
@@ -513,7 +662,7 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 				"dfp":         &graphql.Field{Type: graphql.String},
 				"mobile_dfp":  &graphql.Field{Type: graphql.String},
 				"createdAt":   &graphql.Field{Type: dateTimeScalar},
-			}
+			}, topicSEOFields(opts.SEO, jsonScalar))
 		}),
 	})
 
@@ -528,7 +677,7 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 	postType = graphql.NewObject(graphql.ObjectConfig{
 		Name: "Post",
 		Fields: graphql.FieldsThunk(func() graphql.Fields {
-			return graphql.Fields{
+			return withFields(graphql.Fields{
 				"id":            &graphql.Field{Type: graphql.ID},
 				"slug":          &graphql.Field{Type: graphql.String},
 				"title":         &graphql.Field{Type: graphql.String},
@@ -668,32 +817,85 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 					},
 				},
 				"heroCaption": &graphql.Field{Type: graphql.String},
-				"brief":       &graphql.Field{Type: jsonScalar},
+				"brief": &graphql.Field{
+					Type: jsonScalar,
+					Resolve: guarded(adultGuard, func(p graphql.ResolveParams) (interface{}, error) {
+						return normalizePost(p.Source).Brief, nil
+					}),
+				},
 				"apiData": &graphql.Field{
 					Type: jsonScalar,
-					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-						// 直接回傳資料層從資料庫撈出的 apiData（Lilith draftConverter 產物）
+					// 直接回傳資料層從資料庫撈出的 apiData（Lilith draftConverter 產物）；
+					// 會員文章只回傳給會員，成人文章只回傳給完成年齡驗證的 caller
+					Resolve: guarded(allGuards(memberGuard, adultGuard), func(p graphql.ResolveParams) (interface{}, error) {
 						return normalizePost(p.Source).ApiData, nil
-					},
+					}),
 				},
 				"apiDataBrief": &graphql.Field{
 					Type: jsonScalar,
-					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-						// 直接回傳資料層從資料庫撈出的 apiDataBrief
+					// 直接回傳資料層從資料庫撈出的 apiDataBrief
+					Resolve: guarded(adultGuard, func(p graphql.ResolveParams) (interface{}, error) {
 						return normalizePost(p.Source).ApiDataBrief, nil
-					},
+					}),
 				},
 				"trimmedContent": &graphql.Field{
 					Type: jsonScalar,
-					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-						return normalizePost(p.Source).TrimmedContent, nil
-					},
+					Resolve: guarded(adultGuard, func(p graphql.ResolveParams) (interface{}, error) {
+						return trimDraftContent(normalizePost(p.Source).Content, trimBlocks), nil
+					}),
+				},
+				"trimmedApiData": &graphql.Field{
+					Type: jsonScalar,
+					Resolve: guarded(adultGuard, func(p graphql.ResolveParams) (interface{}, error) {
+						return trimApiData(normalizePost(p.Source).ApiData, trimBlocks), nil
+					}),
 				},
 				"content": &graphql.Field{
 					Type: jsonScalar,
-					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					// 會員文章只回傳給會員，成人文章只回傳給完成年齡驗證的 caller
+					Resolve: guarded(allGuards(memberGuard, adultGuard), func(p graphql.ResolveParams) (interface{}, error) {
 						return normalizePost(p.Source).Content, nil
+					}),
+				},
+				"contentHtml": &graphql.Field{
+					Type: graphql.String,
+					Args: graphql.FieldConfigArgument{
+						"format": &graphql.ArgumentConfig{Type: contentFormatEnum, DefaultValue: render.FormatHTML},
 					},
+					// apiData 轉成清理過的 HTML / AMP HTML，權限與 apiData 相同
+					Resolve: guarded(allGuards(memberGuard, adultGuard), func(p graphql.ResolveParams) (interface{}, error) {
+						format, _ := p.Args["format"].(render.Format)
+						return render.Render(normalizePost(p.Source).ApiData, format), nil
+					}),
+				},
+				"contentText": &graphql.Field{
+					Type: graphql.String,
+					Resolve: guarded(allGuards(memberGuard, adultGuard), func(p graphql.ResolveParams) (interface{}, error) {
+						return render.Text(normalizePost(p.Source).ApiData), nil
+					}),
+				},
+				"briefText": &graphql.Field{
+					Type: graphql.String,
+					Resolve: guarded(adultGuard, func(p graphql.ResolveParams) (interface{}, error) {
+						return render.Text(normalizePost(p.Source).ApiDataBrief), nil
+					}),
+				},
+				"readingTimeMinutes": &graphql.Field{Type: graphql.Int},
+				"wordCount":          &graphql.Field{Type: graphql.Int},
+				"excerpt": &graphql.Field{
+					Type: graphql.String,
+					Args: graphql.FieldConfigArgument{
+						"length": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 120},
+					},
+					Resolve: guarded(adultGuard, func(p graphql.ResolveParams) (interface{}, error) {
+						length, _ := p.Args["length"].(int)
+						post := normalizePost(p.Source)
+						if post.ExcerptFromBody && !canReadFull(p.Context, post) {
+							// 摘要取自全文時，非會員只能拿到 trimmedApiData 範圍內的摘要
+							return data.ExcerptText(render.Text(trimApiData(post.ApiData, trimBlocks)), length), nil
+						}
+						return post.Excerpt(length), nil
+					}),
 				},
 				"relateds": &graphql.Field{
 					Type: graphql.NewList(postType),
@@ -791,13 +993,13 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 						return result, nil
 					},
 				},
-			}
+			}, postSEOFields(opts.SEO, jsonScalar))
 		}),
 	})
 
 	externalType := graphql.NewObject(graphql.ObjectConfig{
 		Name: "External",
-		Fields: graphql.Fields{
+		Fields: withFields(graphql.Fields{
 			"id":            &graphql.Field{Type: graphql.ID},
 			"slug":          &graphql.Field{Type: graphql.String},
 			"title":         &graphql.Field{Type: graphql.String},
@@ -900,7 +1102,7 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 					return result, nil
 				},
 			},
-		},
+		}, externalSEOFields(opts.SEO, jsonScalar)),
 	})
 
 	rootQuery := graphql.NewObject(graphql.ObjectConfig{
@@ -940,9 +1142,13 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 			"post": &graphql.Field{
 				Type: postType,
 				Args: graphql.FieldConfigArgument{
-					"where": &graphql.ArgumentConfig{Type: postWhereUniqueInputType},
+					"where":   &graphql.ArgumentConfig{Type: postWhereUniqueInputType},
+					"preview": &graphql.ArgumentConfig{Type: graphql.String},
 				},
 				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := grantPreview(p, opts.Preview, "post"); err != nil {
+						return nil, err
+					}
 					where, err := data.DecodePostWhereUnique(p.Args["where"])
 					if err != nil {
 						return nil, err
@@ -975,25 +1181,28 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 						Type: graphql.NewInputObject(graphql.InputObjectConfig{
 							Name: "ExternalWhereUniqueInput",
 							Fields: graphql.InputObjectConfigFieldMap{
-								"id": &graphql.InputObjectFieldConfig{Type: graphql.ID},
+								"id":   &graphql.InputObjectFieldConfig{Type: graphql.ID},
+								"slug": &graphql.InputObjectFieldConfig{Type: graphql.String},
 							},
 						}),
 					},
+					"preview": &graphql.ArgumentConfig{Type: graphql.String},
 				},
 				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := grantPreview(p, opts.Preview, "external"); err != nil {
+						return nil, err
+					}
 					where, _ := p.Args["where"].(map[string]interface{})
 					if where == nil {
 						return nil, nil
 					}
-					rawID, ok := where["id"]
-					if !ok {
-						return nil, nil
+					if idStr, ok := where["id"].(string); ok && idStr != "" {
+						return repo.QueryExternalByID(p.Context, idStr)
 					}
-					idStr, ok := rawID.(string)
-					if !ok || idStr == "" {
-						return nil, nil
+					if slug, ok := where["slug"].(string); ok && slug != "" {
+						return repo.QueryExternalBySlug(p.Context, slug)
 					}
-					return repo.QueryExternalByID(p.Context, idStr)
+					return nil, nil
 				},
 			},
 			"externalsCount": &graphql.Field{
@@ -1051,9 +1260,13 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 			"topic": &graphql.Field{
 				Type: topicType,
 				Args: graphql.FieldConfigArgument{
-					"where": &graphql.ArgumentConfig{Type: topicWhereUniqueInputType},
+					"where":   &graphql.ArgumentConfig{Type: topicWhereUniqueInputType},
+					"preview": &graphql.ArgumentConfig{Type: graphql.String},
 				},
 				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := grantPreview(p, opts.Preview, "topic"); err != nil {
+						return nil, err
+					}
 					where, err := data.DecodeTopicWhereUnique(p.Args["where"])
 					if err != nil {
 						return nil, err
@@ -1103,9 +1316,13 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 			"video": &graphql.Field{
 				Type: videoType,
 				Args: graphql.FieldConfigArgument{
-					"where": &graphql.ArgumentConfig{Type: videoWhereUniqueInputType},
+					"where":   &graphql.ArgumentConfig{Type: videoWhereUniqueInputType},
+					"preview": &graphql.ArgumentConfig{Type: graphql.String},
 				},
 				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := grantPreview(p, opts.Preview, "video"); err != nil {
+						return nil, err
+					}
 					where, err := data.DecodeVideoWhereUnique(p.Args["where"])
 					if err != nil {
 						return nil, err
@@ -1113,6 +1330,19 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 					return repo.QueryVideoByUnique(p.Context, where)
 				},
 			},
+			"resolvePath": &graphql.Field{
+				Type: pathResolutionType,
+				Args: graphql.FieldConfigArgument{
+					"path": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if opts.Redirects == nil {
+						return nil, fmt.Errorf("resolvePath is not configured")
+					}
+					path, _ := p.Args["path"].(string)
+					return opts.Redirects.Resolve(p.Context, path)
+				},
+			},
 		},
 	})
 
@@ -1121,6 +1351,29 @@ func Build(repo *data.Repo) (graphql.Schema, error) {
 	})
 }
 
+// grantPreview 將預覽授權加入目前 request 的 context：
+// editor / admin 可直接預覽此次查詢的內容，其他 caller 需帶有效的 preview 參數。
+func grantPreview(p graphql.ResolveParams, signer *preview.Signer, kind string) error {
+	if editorGuard(p) {
+		where, _ := p.Args["where"].(map[string]interface{})
+		id, _ := where["id"].(string)
+		slug, _ := where["slug"].(string)
+		if id != "" || slug != "" {
+			preview.Grant(p.Context, preview.Claims{Kind: kind, ID: id, Slug: slug})
+		}
+	}
+	token, _ := p.Args["preview"].(string)
+	if token == "" {
+		return nil
+	}
+	claims, err := signer.Verify(token)
+	if err != nil {
+		return err
+	}
+	preview.Grant(p.Context, *claims)
+	return nil
+}
+
 // Scalars
 func newJSONScalar() *graphql.Scalar {
 	return graphql.NewScalar(graphql.ScalarConfig{
@@ -1372,6 +1625,48 @@ func normalizeTopic(src interface{}) data.Topic {
 	}
 }
 
+func normalizePhoto(src interface{}) data.Photo {
+	switch v := src.(type) {
+	case data.Photo:
+		return v
+	case *data.Photo:
+		if v == nil {
+			return data.Photo{}
+		}
+		return *v
+	default:
+		return data.Photo{}
+	}
+}
+
+func normalizeVideo(src interface{}) data.Video {
+	switch v := src.(type) {
+	case data.Video:
+		return v
+	case *data.Video:
+		if v == nil {
+			return data.Video{}
+		}
+		return *v
+	default:
+		return data.Video{}
+	}
+}
+
+func normalizeExternal(src interface{}) data.External {
+	switch v := src.(type) {
+	case data.External:
+		return v
+	case *data.External:
+		if v == nil {
+			return data.External{}
+		}
+		return *v
+	default:
+		return data.External{}
+	}
+}
+
 func normalizePost(src interface{}) data.Post {
 	switch v := src.(type) {
 	case data.Post:
@@ -0,0 +1,91 @@
+package schema
+
+import (
+	"go-story/internal/auth"
+	"go-story/internal/data"
+	"go-story/internal/seo"
+
+	"github.com/graphql-go/graphql"
+)
+
+var seoType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SEO",
+	Fields: graphql.Fields{
+		"title":        &graphql.Field{Type: graphql.String},
+		"description":  &graphql.Field{Type: graphql.String},
+		"canonicalUrl": &graphql.Field{Type: graphql.String},
+		"image":        &graphql.Field{Type: graphql.String},
+		"robots":       &graphql.Field{Type: graphql.String},
+	},
+})
+
+// seoFields 回傳 seo 與 structuredData 兩個欄位；b 為 nil 時兩者皆回傳 null
+func seoFields(b *seo.Builder, meta func(graphql.ResolveParams) seo.Meta, jsonLD func(graphql.ResolveParams) map[string]interface{}, jsonScalar *graphql.Scalar) graphql.Fields {
+	return graphql.Fields{
+		"seo": &graphql.Field{
+			Type: seoType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if b == nil {
+					return nil, nil
+				}
+				return meta(p), nil
+			},
+		},
+		"structuredData": &graphql.Field{
+			Type: jsonScalar,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if b == nil {
+					return nil, nil
+				}
+				return jsonLD(p), nil
+			},
+		},
+	}
+}
+
+// postRedacted 表示成人文章且 caller 未完成年齡驗證，SEO 欄位不得帶出內文摘要
+func postRedacted(p graphql.ResolveParams, post data.Post) bool {
+	return post.IsAdult && !auth.AgeVerified(p.Context)
+}
+
+func postSEOFields(b *seo.Builder, jsonScalar *graphql.Scalar) graphql.Fields {
+	return seoFields(b, func(p graphql.ResolveParams) seo.Meta {
+		post := normalizePost(p.Source)
+		return b.Post(post, postRedacted(p, post))
+	}, func(p graphql.ResolveParams) map[string]interface{} {
+		post := normalizePost(p.Source)
+		return b.PostJSONLD(post, postRedacted(p, post))
+	}, jsonScalar)
+}
+
+func topicSEOFields(b *seo.Builder, jsonScalar *graphql.Scalar) graphql.Fields {
+	return seoFields(b, func(p graphql.ResolveParams) seo.Meta {
+		return b.Topic(normalizeTopic(p.Source))
+	}, func(p graphql.ResolveParams) map[string]interface{} {
+		return b.TopicJSONLD(normalizeTopic(p.Source))
+	}, jsonScalar)
+}
+
+func videoSEOFields(b *seo.Builder, jsonScalar *graphql.Scalar) graphql.Fields {
+	return seoFields(b, func(p graphql.ResolveParams) seo.Meta {
+		return b.Video(normalizeVideo(p.Source))
+	}, func(p graphql.ResolveParams) map[string]interface{} {
+		return b.VideoJSONLD(normalizeVideo(p.Source))
+	}, jsonScalar)
+}
+
+func externalSEOFields(b *seo.Builder, jsonScalar *graphql.Scalar) graphql.Fields {
+	return seoFields(b, func(p graphql.ResolveParams) seo.Meta {
+		return b.External(normalizeExternal(p.Source))
+	}, func(p graphql.ResolveParams) map[string]interface{} {
+		return b.ExternalJSONLD(normalizeExternal(p.Source))
+	}, jsonScalar)
+}
+
+// withFields 將 extra 併入 fields 並回傳 fields
+func withFields(fields graphql.Fields, extra graphql.Fields) graphql.Fields {
+	for k, v := range extra {
+		fields[k] = v
+	}
+	return fields
+}
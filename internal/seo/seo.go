@@ -0,0 +1,317 @@
+// Package seo 以一致的 fallback 規則產生 Post、Topic、Video、External 的 meta 資訊與 JSON-LD，
+// 讓各前端不必各自從 og_title、og_image、writers、sections 拼裝。
+package seo
+
+import (
+	"strings"
+
+	"go-story/internal/data"
+	"go-story/internal/links"
+	"go-story/internal/render"
+)
+
+// descriptionLength 是 meta description 的最大字數
+const descriptionLength = 160
+
+const (
+	robotsIndex   = "index, follow, max-image-preview:large"
+	robotsNoIndex = "noindex, nofollow"
+)
+
+// Meta 對應 GraphQL 的 SEO 型別
+type Meta struct {
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	CanonicalURL string `json:"canonicalUrl"`
+	Image        string `json:"image"`
+	Robots       string `json:"robots"`
+}
+
+// Builder 組出 canonical URL 與 JSON-LD 中的 publisher 資訊
+type Builder struct {
+	links    *links.Builder
+	siteName string
+}
+
+// New 建立 Builder
+func New(lb *links.Builder, siteName string) *Builder {
+	return &Builder{links: lb, siteName: siteName}
+}
+
+// Post 產生文章的 meta；redacted 為 true 時（未驗證年齡的成人文章）不使用內文摘要，
+// 付費文章也不使用取自全文的摘要
+func (b *Builder) Post(p data.Post, redacted bool) Meta {
+	m := Meta{
+		Title:        firstNonEmpty(p.OgTitle, p.Title),
+		CanonicalURL: b.links.Post(p.Slug),
+		Image:        photoURL(p.OgImage, p.HeroImage),
+		Robots:       robots(p.State == "published" && !p.IsAdult),
+	}
+	switch {
+	case redacted:
+		m.Description = description(p.OgDescription)
+	case p.ExcerptFromBody && p.Paywalled():
+		m.Description = description(p.OgDescription, render.Text(p.ApiDataBrief))
+	default:
+		m.Description = description(p.OgDescription, render.Text(p.ApiDataBrief), p.ExcerptSource)
+	}
+	return m
+}
+
+// Topic 產生專題的 meta
+func (b *Builder) Topic(t data.Topic) Meta {
+	return Meta{
+		Title:        firstNonEmpty(t.OgTitle, t.Name),
+		Description:  description(t.OgDescription, render.Text(t.ApiDataBrief)),
+		CanonicalURL: b.links.Topic(t.Slug),
+		Image:        photoURL(t.OgImage, t.HeroImage),
+		Robots:       robots(t.State == "published"),
+	}
+}
+
+// Video 產生影音的 meta
+func (b *Builder) Video(v data.Video) Meta {
+	return Meta{
+		Title:        v.Name,
+		Description:  description(render.StripHTML(v.Content)),
+		CanonicalURL: b.links.Video(v.ID),
+		Image:        photoURL(v.HeroImage),
+		Robots:       robots(v.State == "published"),
+	}
+}
+
+// External 產生合作夥伴文章的 meta
+func (b *Builder) External(e data.External) Meta {
+	return Meta{
+		Title:        e.Title,
+		Description:  description(render.StripHTML(e.Brief), render.StripHTML(e.Content)),
+		CanonicalURL: b.links.External(e.Slug),
+		Image:        e.Thumb,
+		Robots:       robots(e.State == "published"),
+	}
+}
+
+// PostJSONLD 回傳 NewsArticle 與 BreadcrumbList 組成的 @graph
+func (b *Builder) PostJSONLD(p data.Post, redacted bool) map[string]interface{} {
+	m := b.Post(p, redacted)
+	article := map[string]interface{}{
+		"@type":               "NewsArticle",
+		"@id":                 m.CanonicalURL + "#article",
+		"headline":            truncate(m.Title, 110),
+		"mainEntityOfPage":    m.CanonicalURL,
+		"publisher":           b.publisher(),
+		"isAccessibleForFree": !p.Paywalled(),
+	}
+	setNonEmpty(article, "description", m.Description)
+	setNonEmpty(article, "datePublished", p.PublishedDate)
+	setNonEmpty(article, "dateModified", firstNonEmpty(p.UpdatedAt, p.PublishedDate))
+	if m.Image != "" {
+		article["image"] = []string{m.Image}
+	}
+	if p.Paywalled() {
+		// Google 付費內容標記：被截斷的內文區塊
+		article["hasPart"] = map[string]interface{}{
+			"@type":               "WebPageElement",
+			"isAccessibleForFree": false,
+			"cssSelector":         ".paywall",
+		}
+	}
+	var authors []map[string]interface{}
+	for _, w := range p.Writers {
+		authors = append(authors, map[string]interface{}{"@type": "Person", "name": w.Name})
+	}
+	if len(authors) > 0 {
+		article["author"] = authors
+	} else {
+		article["author"] = b.publisher()
+	}
+	var sections []string
+	for _, s := range p.Sections {
+		sections = append(sections, s.Name)
+	}
+	if len(sections) > 0 {
+		article["articleSection"] = sections
+	}
+	var keywords []string
+	for _, t := range p.Tags {
+		keywords = append(keywords, t.Name)
+	}
+	if len(keywords) > 0 {
+		article["keywords"] = keywords
+	}
+
+	crumbs := []crumb{}
+	if len(p.Sections) > 0 {
+		crumbs = append(crumbs, crumb{p.Sections[0].Name, b.links.Section(p.Sections[0].Slug)})
+	}
+	if len(p.Categories) > 0 {
+		crumbs = append(crumbs, crumb{p.Categories[0].Name, b.links.Category(p.Categories[0].Slug)})
+	}
+	crumbs = append(crumbs, crumb{p.Title, m.CanonicalURL})
+	return graph(article, b.breadcrumbs(crumbs))
+}
+
+// TopicJSONLD 回傳 CollectionPage 與 BreadcrumbList 組成的 @graph
+func (b *Builder) TopicJSONLD(t data.Topic) map[string]interface{} {
+	m := b.Topic(t)
+	page := map[string]interface{}{
+		"@type":     "CollectionPage",
+		"@id":       m.CanonicalURL,
+		"name":      m.Title,
+		"url":       m.CanonicalURL,
+		"publisher": b.publisher(),
+	}
+	setNonEmpty(page, "description", m.Description)
+	setNonEmpty(page, "datePublished", t.PublishedDate)
+	setNonEmpty(page, "image", m.Image)
+	var parts []map[string]interface{}
+	for _, p := range t.Posts {
+		parts = append(parts, map[string]interface{}{"@type": "NewsArticle", "headline": p.Title, "url": b.links.Post(p.Slug)})
+	}
+	if len(parts) > 0 {
+		page["hasPart"] = parts
+	}
+	return graph(page, b.breadcrumbs([]crumb{{t.Name, m.CanonicalURL}}))
+}
+
+// VideoJSONLD 回傳 VideoObject
+func (b *Builder) VideoJSONLD(v data.Video) map[string]interface{} {
+	m := b.Video(v)
+	obj := map[string]interface{}{
+		"@context":  "https://schema.org",
+		"@type":     "VideoObject",
+		"@id":       m.CanonicalURL,
+		"name":      m.Title,
+		"url":       m.CanonicalURL,
+		"publisher": b.publisher(),
+	}
+	setNonEmpty(obj, "description", firstNonEmpty(m.Description, m.Title))
+	setNonEmpty(obj, "uploadDate", firstNonEmpty(v.PublishedDate, v.CreatedAt))
+	setNonEmpty(obj, "thumbnailUrl", m.Image)
+	setNonEmpty(obj, "embedUrl", v.YoutubeUrl)
+	setNonEmpty(obj, "contentUrl", v.VideoSrc)
+	if d := firstNonEmpty(nonZeroDuration(v.FileDuration), nonZeroDuration(v.YoutubeDuration)); d != "" {
+		obj["duration"] = d
+	}
+	return obj
+}
+
+// ExternalJSONLD 回傳 NewsArticle；作者為 extend_byline，來源為合作夥伴
+func (b *Builder) ExternalJSONLD(e data.External) map[string]interface{} {
+	m := b.External(e)
+	article := map[string]interface{}{
+		"@context":         "https://schema.org",
+		"@type":            "NewsArticle",
+		"@id":              m.CanonicalURL + "#article",
+		"headline":         truncate(m.Title, 110),
+		"mainEntityOfPage": m.CanonicalURL,
+		"publisher":        b.publisher(),
+	}
+	setNonEmpty(article, "description", m.Description)
+	setNonEmpty(article, "datePublished", e.PublishedDate)
+	setNonEmpty(article, "dateModified", firstNonEmpty(e.UpdatedAt, e.PublishedDate))
+	if m.Image != "" {
+		article["image"] = []string{m.Image}
+	}
+	if e.ExtendByline != "" {
+		article["author"] = map[string]interface{}{"@type": "Person", "name": e.ExtendByline}
+	} else if e.Partner != nil {
+		article["author"] = map[string]interface{}{"@type": "Organization", "name": e.Partner.Name}
+	}
+	return article
+}
+
+type crumb struct {
+	name string
+	url  string
+}
+
+// breadcrumbs 以首頁為第一層組出 BreadcrumbList
+func (b *Builder) breadcrumbs(items []crumb) map[string]interface{} {
+	items = append([]crumb{{b.siteName, b.links.Home()}}, items...)
+	list := make([]map[string]interface{}, 0, len(items))
+	for i, c := range items {
+		list = append(list, map[string]interface{}{
+			"@type":    "ListItem",
+			"position": i + 1,
+			"name":     c.name,
+			"item":     c.url,
+		})
+	}
+	return map[string]interface{}{"@type": "BreadcrumbList", "itemListElement": list}
+}
+
+func (b *Builder) publisher() map[string]interface{} {
+	return map[string]interface{}{
+		"@type": "Organization",
+		"name":  b.siteName,
+		"url":   b.links.Home(),
+	}
+}
+
+func graph(nodes ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"@context": "https://schema.org",
+		"@graph":   nodes,
+	}
+}
+
+func robots(indexable bool) string {
+	if indexable {
+		return robotsIndex
+	}
+	return robotsNoIndex
+}
+
+// photoURL 依序取第一張有圖的 photo，優先使用 w1200
+func photoURL(photos ...*data.Photo) string {
+	for _, p := range photos {
+		if p == nil {
+			continue
+		}
+		if u := firstNonEmpty(p.Resized.W1200, p.Resized.Original); u != "" {
+			return u
+		}
+	}
+	return ""
+}
+
+// description 取第一個非空的候選並截斷為 descriptionLength 字
+func description(candidates ...string) string {
+	for _, c := range candidates {
+		if c = strings.Join(strings.Fields(c), " "); c != "" {
+			return truncate(c, descriptionLength)
+		}
+	}
+	return ""
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
+
+func nonZeroDuration(d string) string {
+	if d == "PT0S" {
+		return ""
+	}
+	return d
+}
+
+func setNonEmpty(m map[string]interface{}, key, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
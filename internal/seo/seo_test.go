@@ -0,0 +1,17 @@
+package seo
+
+import (
+	"testing"
+
+	"go-story/internal/data"
+	"go-story/internal/links"
+)
+
+// 影音的 content 是 HTML，description 只能使用純文字
+func TestVideoDescriptionStripsHTML(t *testing.T) {
+	b := New(links.New("https://www.example.com"), "範例新聞")
+	m := b.Video(data.Video{ID: "3", Name: "影音", State: "published", Content: `<p>第一段 <a href="https://x">連結</a></p><script>alert(1)</script>`})
+	if m.Description != "第一段 連結" {
+		t.Errorf("Description = %q", m.Description)
+	}
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-story/internal/data"
+)
+
+// cachedResponse 是存入 Redis 的 GET 回應（feed、sitemap）
+type cachedResponse struct {
+	Body         []byte    `json:"body"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// buildFunc 產生回應內容與最後更新時間；body 為 nil 代表找不到（404）。
+// 回傳 errSkipCache 時照常回應 body，但不存入 cache。
+type buildFunc func(ctx context.Context) (body []byte, lastModified time.Time, err error)
+
+// ttlFunc 回傳存入 cache 的 TTL；nil 時使用 cache 預設的 TTL
+type ttlFunc func(ctx context.Context) time.Duration
+
+// publishTTL 回傳以 tables 中下一筆排程內容上線時間截短 TTL 的 ttlFunc，
+// 讓輸出已發佈內容的 feed 與 sitemap 在排程內容上線時過期；cache 為 nil 時回傳 nil
+func publishTTL(repo *data.Repo, cache *data.Cache, tables ...string) ttlFunc {
+	if repo == nil || cache == nil {
+		return nil
+	}
+	return func(ctx context.Context) time.Duration {
+		return repo.CacheTTL(ctx, cache.TTL(), tables...)
+	}
+}
+
+// errSkipCache 讓 build 標記回應不存入 cache（例如找不到的路徑），避免任意輸入讓 cache 無限增長
+var errSkipCache = errors.New("skip cache")
+
+// serveCached 以 Redis cache 保存 build 的輸出，並處理 ETag / Last-Modified 條件式 GET。
+// cache 與 ttl 可為 nil。
+func serveCached(w http.ResponseWriter, r *http.Request, cache *data.Cache, ttl ttlFunc, key, contentType string, build buildFunc) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	var out cachedResponse
+	found := false
+	if cache != nil && cache.Enabled() {
+		found, _ = cache.Get(ctx, key, &out)
+	}
+	if !found {
+		body, lastModified, err := build(ctx)
+		store := !errors.Is(err, errSkipCache)
+		if !store {
+			err = nil
+		}
+		if err != nil {
+			log.Printf("[HTTP] build %s failed: %v", r.URL.Path, err)
+			http.Error(w, "failed to build response", http.StatusInternalServerError)
+			return
+		}
+		if body == nil {
+			http.NotFound(w, r)
+			return
+		}
+		sum := sha256.Sum256(body)
+		out = cachedResponse{
+			Body:         body,
+			ETag:         `"` + hex.EncodeToString(sum[:8]) + `"`,
+			LastModified: lastModified.UTC().Truncate(time.Second),
+		}
+		if store && cache != nil && cache.Enabled() {
+			if ttl != nil {
+				_ = cache.SetWithTTL(ctx, key, out, ttl(ctx))
+			} else {
+				_ = cache.Set(ctx, key, out)
+			}
+		}
+	}
+
+	w.Header().Set("ETag", out.ETag)
+	if !out.LastModified.IsZero() {
+		w.Header().Set("Last-Modified", out.LastModified.Format(http.TimeFormat))
+	}
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if notModified(r, out.ETag, out.LastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(out.Body)
+}
+
+// notModified 處理 If-None-Match / If-Modified-Since；有 If-None-Match 時以它為準
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == etag || tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil && !lastModified.After(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"go-story/internal/data"
+	"go-story/internal/feed"
+	"go-story/internal/links"
+	"go-story/internal/render"
+)
+
+// feedItemLimit 是每個 feed 輸出的項目數
+const feedItemLimit = 30
+
+// FeedHandler serves /feeds/{section|category|tag|partner}/{slug}.{rss,atom,json}
+// and the site-wide /feeds/all.{rss,atom,json}.
+type FeedHandler struct {
+	repo     *data.Repo
+	cache    *data.Cache
+	links    *links.Builder
+	siteName string
+}
+
+// NewFeedHandler 建立 feed handler；cache 可為 nil
+func NewFeedHandler(repo *data.Repo, cache *data.Cache, lb *links.Builder, siteName string) *FeedHandler {
+	return &FeedHandler{repo: repo, cache: cache, links: lb, siteName: siteName}
+}
+
+func (h *FeedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rel := strings.TrimPrefix(r.URL.Path, "/feeds/")
+	ext := path.Ext(rel)
+	format := feed.Format(strings.TrimPrefix(ext, "."))
+	switch format {
+	case feed.FormatRSS, feed.FormatAtom, feed.FormatJSON:
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	kind, slug, _ := strings.Cut(strings.TrimSuffix(rel, ext), "/")
+	if kind == "all" && slug == "" {
+		slug = "all"
+	}
+	if slug == "" || strings.Contains(slug, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	key := data.GenerateCacheKey("feed", r.URL.Path)
+	table := "Post"
+	if kind == "partner" {
+		table = "External"
+	}
+	serveCached(w, r, h.cache, publishTTL(h.repo, h.cache, table), key, format.ContentType(), func(ctx context.Context) ([]byte, time.Time, error) {
+		f, err := h.build(ctx, kind, slug, r.URL.Path)
+		if err != nil || f == nil {
+			return nil, time.Time{}, err
+		}
+		body, err := feed.Encode(f, format)
+		return body, f.Updated, err
+	})
+}
+
+// build 依 kind 查詢內容並組成 feed；找不到對應的分類時回傳 nil
+func (h *FeedHandler) build(ctx context.Context, kind, slug, feedPath string) (*feed.Feed, error) {
+	orders := []data.OrderRule{{Field: "publishedDate", Direction: "desc"}}
+	f := &feed.Feed{
+		Title:    h.siteName,
+		Link:     h.links.Home(),
+		FeedURL:  h.links.Abs(feedPath),
+		Language: "zh-TW",
+	}
+
+	if kind == "partner" {
+		name, err := h.repo.QueryNameBySlug(ctx, "Partner", slug)
+		if err != nil || name == "" {
+			return nil, err
+		}
+		externals, err := h.repo.QueryExternals(ctx, &data.ExternalWhereInput{
+			Partner: &data.PartnerWhereInput{Slug: equalsFilter(slug)},
+		}, orders, feedItemLimit, 0)
+		if err != nil {
+			return nil, err
+		}
+		f.Title = h.siteName + " - " + name
+		f.Link = h.links.Partner(slug)
+		for _, e := range externals {
+			f.Items = append(f.Items, h.externalItem(e))
+		}
+		setUpdated(f)
+		return f, nil
+	}
+
+	where := &data.PostWhereInput{}
+	switch kind {
+	case "all":
+		f.Description = h.siteName + " 最新文章"
+	case "section", "category", "tag":
+		table := map[string]string{"section": "Section", "category": "Category", "tag": "Tag"}[kind]
+		name, err := h.repo.QueryNameBySlug(ctx, table, slug)
+		if err != nil || name == "" {
+			return nil, err
+		}
+		f.Title = h.siteName + " - " + name
+		switch kind {
+		case "section":
+			where.Sections = &data.SectionManyRelationFilter{Some: &data.SectionWhereInput{Slug: equalsFilter(slug)}}
+			f.Link = h.links.Section(slug)
+		case "category":
+			where.Categories = &data.CategoryManyRelationFilter{Some: &data.CategoryWhereInput{Slug: equalsFilter(slug)}}
+			f.Link = h.links.Category(slug)
+		case "tag":
+			where.Tags = &data.TagManyRelationFilter{Some: &data.TagWhereInput{Slug: equalsFilter(slug)}}
+			f.Link = h.links.Tag(slug)
+		}
+	default:
+		return nil, nil
+	}
+
+	posts, err := h.repo.QueryPosts(ctx, where, orders, feedItemLimit, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range posts {
+		f.Items = append(f.Items, h.postItem(p))
+	}
+	setUpdated(f)
+	return f, nil
+}
+
+func (h *FeedHandler) postItem(p data.Post) feed.Item {
+	item := feed.Item{
+		ID:        p.ID,
+		Title:     p.Title,
+		Link:      h.links.Post(p.Slug),
+		Summary:   render.Text(p.ApiDataBrief),
+		Published: parseTime(p.PublishedDate),
+		Updated:   parseTime(p.UpdatedAt),
+	}
+	// feed 對所有讀者公開，會員文章只提供摘要
+	if !p.Paywalled() {
+		item.ContentHTML = render.HTML(p.ApiData)
+	}
+	for _, c := range p.Writers {
+		item.Authors = append(item.Authors, c.Name)
+	}
+	for _, c := range p.Categories {
+		item.Categories = append(item.Categories, c.Name)
+	}
+	for _, t := range p.Tags {
+		item.Categories = append(item.Categories, t.Name)
+	}
+	if p.HeroImage != nil {
+		item.Enclosure = imageEnclosure(firstNonEmpty(p.HeroImage.Resized.W800, p.HeroImage.Resized.Original))
+	}
+	return item
+}
+
+func (h *FeedHandler) externalItem(e data.External) feed.Item {
+	item := feed.Item{
+		ID:          e.ID,
+		Title:       e.Title,
+		Link:        h.links.External(e.Slug),
+		Summary:     e.Brief,
+		ContentHTML: render.SanitizeHTML(e.Content),
+		Published:   parseTime(e.PublishedDate),
+		Updated:     parseTime(e.UpdatedAt),
+	}
+	if e.ExtendByline != "" {
+		item.Authors = []string{e.ExtendByline}
+	}
+	for _, c := range e.Categories {
+		item.Categories = append(item.Categories, c.Name)
+	}
+	if e.Thumb != "" {
+		item.Enclosure = imageEnclosure(e.Thumb)
+	}
+	return item
+}
+
+// setUpdated 以最新一則的更新時間作為 feed 的更新時間
+func setUpdated(f *feed.Feed) {
+	for _, it := range f.Items {
+		t := it.Updated
+		if t.IsZero() {
+			t = it.Published
+		}
+		if t.After(f.Updated) {
+			f.Updated = t
+		}
+	}
+}
+
+func imageEnclosure(u string) *feed.Enclosure {
+	if u == "" {
+		return nil
+	}
+	mime := "image/jpeg"
+	switch strings.ToLower(path.Ext(strings.SplitN(u, "?", 2)[0])) {
+	case ".png":
+		mime = "image/png"
+	case ".webp":
+		mime = "image/webp"
+	case ".gif":
+		mime = "image/gif"
+	}
+	return &feed.Enclosure{URL: u, Type: mime}
+}
+
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+func equalsFilter(v string) *data.StringFilter {
+	return &data.StringFilter{Equals: &v}
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
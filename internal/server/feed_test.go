@@ -0,0 +1,48 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"go-story/internal/data"
+	"go-story/internal/feed"
+	"go-story/internal/links"
+)
+
+func TestExternalItemSanitizesContent(t *testing.T) {
+	h := NewFeedHandler(nil, nil, links.New("https://www.example.com"), "site")
+	item := h.externalItem(data.External{
+		ID:      "1",
+		Slug:    "ext",
+		Content: `<p onclick="x()">合作夥伴內文</p><script>alert(1)</script><img src="javascript:alert(1)"><a href="javascript:x">連結</a>`,
+	})
+	if item.ContentHTML != `<p>合作夥伴內文</p><a>連結</a>` {
+		t.Errorf("ContentHTML = %q", item.ContentHTML)
+	}
+}
+
+// RSS 的 author 必須是 email，作者名稱以 dc:creator 輸出；Atom 與 JSON Feed 直接使用名稱
+func TestFeedAuthorNames(t *testing.T) {
+	h := NewFeedHandler(nil, nil, links.New("https://www.example.com"), "site")
+	f := &feed.Feed{Title: "site", Link: "https://www.example.com", Items: []feed.Item{
+		h.externalItem(data.External{ID: "1", Slug: "ext", ExtendByline: "王小明"}),
+	}}
+	for format, want := range map[feed.Format][]string{
+		feed.FormatRSS:  {`xmlns:dc="http://purl.org/dc/elements/1.1/"`, `<dc:creator>王小明</dc:creator>`},
+		feed.FormatAtom: {"<author>", "<name>王小明</name>"},
+		feed.FormatJSON: {`"authors"`, `"name": "王小明"`},
+	} {
+		body, err := feed.Encode(f, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, w := range want {
+			if !strings.Contains(string(body), w) {
+				t.Errorf("%s: missing %s in %s", format, w, body)
+			}
+		}
+		if format == feed.FormatRSS && strings.Contains(string(body), "<author>") {
+			t.Errorf("rss: author must be an email: %s", body)
+		}
+	}
+}
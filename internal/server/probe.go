@@ -0,0 +1,140 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-story/internal/probe"
+)
+
+// maxProbeSamples 限制單次請求每個種類的 sample 數量，避免對 target 造成過多查詢
+const maxProbeSamples = 20
+
+// NewProbeHandler serves POST /probe: it runs a probe suite against the target URL and this service (selfURL),
+// and reports mismatches. store 不為 nil 時會保存每次執行的結果；commit 為本服務的版本。
+// payload 的 mode 為 schema 時改為回傳兩邊 introspection schema 的相容性報告（不保存）。
+// 請求需通過 guard 的 token 與速率檢查，url 必須在 allowlist 中，且只會連到公開位址。
+func NewProbeHandler(store probe.Store, commit, selfURL string, guard *ProbeGuard) http.Handler {
+	return guard.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "only POST", http.StatusMethodNotAllowed)
+			return
+		}
+		var payload struct {
+			URL     string `json:"url"`
+			Mode    string `json:"mode"`
+			Suite   string `json:"suite"`
+			Samples int    `json:"samples"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.URL == "" {
+			http.Error(w, "invalid payload, need {\"url\": \"https://original-gql\", \"suite\": \"default\"}", http.StatusBadRequest)
+			return
+		}
+		if err := guard.AllowTarget(payload.URL); err != nil {
+			guard.Reject(r, err.Error())
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		// target 來自外部輸入，使用只連公開位址、redirect 也要在 allowlist 中的 client；self 是設定好的本機位址
+		client := probe.NewGuardedClient(guard.AllowTarget)
+		opts := probe.Options{SelfClient: probe.NewClient()}
+
+		switch payload.Mode {
+		case "", "queries":
+		case "schema":
+			report, err := probe.RunSchemaDiff(client, payload.URL, selfURL, opts)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(report)
+			return
+		default:
+			http.Error(w, fmt.Sprintf("unknown mode %q (want queries or schema)", payload.Mode), http.StatusBadRequest)
+			return
+		}
+
+		if payload.Suite == "" {
+			payload.Suite = r.URL.Query().Get("suite")
+		}
+		if payload.Samples <= 0 {
+			payload.Samples = probe.DefaultSamples
+		}
+		if payload.Samples > maxProbeSamples {
+			payload.Samples = maxProbeSamples
+		}
+		suite, err := probe.LoadSuite(payload.Suite)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%v (available: %v)", err, probe.SuiteNames()), http.StatusBadRequest)
+			return
+		}
+
+		// report 輸出每個 test 的通過率、兩邊的延遲與不 match 的結果
+		started := time.Now()
+		opts.Samples = payload.Samples
+		report := probe.RunSuite(client, suite, payload.URL, selfURL, opts)
+		if store != nil {
+			if err := store.Save(r.Context(), probe.NewStoredRun(report, commit, started)); err != nil {
+				log.Printf("[Probe] save run failed: %v", err)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(report)
+	}))
+}
+
+// probeHistory 是 GET /probe/runs 的回應
+type probeHistory struct {
+	Runs   []probe.StoredRun `json:"runs"`
+	Trends []probe.Trend     `json:"trends"`
+}
+
+// loadProbeHistory 依 query string 的 suite、target、limit 讀取歷史紀錄
+func loadProbeHistory(r *http.Request, store probe.Store) (*probeHistory, error) {
+	q := r.URL.Query()
+	filter := probe.RunFilter{Suite: q.Get("suite"), Target: q.Get("target")}
+	if filter.Suite == "" {
+		filter.Suite = probe.DefaultSuite
+	}
+	if n, err := strconv.Atoi(q.Get("limit")); err == nil && n > 0 && n <= 500 {
+		filter.Limit = n
+	}
+	runs, err := store.List(r.Context(), filter)
+	if err != nil {
+		return nil, err
+	}
+	if runs == nil {
+		runs = []probe.StoredRun{}
+	}
+	return &probeHistory{Runs: runs, Trends: probe.Trends(runs)}, nil
+}
+
+// NewProbeRunsHandler serves GET /probe/runs?suite=&target=&limit=：最新的執行紀錄與每個 test 的趨勢。
+// 與 /probe 相同需要 admin token。
+func NewProbeRunsHandler(store probe.Store, guard *ProbeGuard) http.Handler {
+	return guard.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET", http.StatusMethodNotAllowed)
+			return
+		}
+		if store == nil {
+			http.Error(w, "probe store is not configured", http.StatusNotFound)
+			return
+		}
+		history, err := loadProbeHistory(r, store)
+		if err != nil {
+			log.Printf("[Probe] list runs failed: %v", err)
+			http.Error(w, "failed to list probe runs", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(history)
+	}))
+}
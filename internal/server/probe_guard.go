@@ -0,0 +1,171 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-story/internal/ratelimit"
+)
+
+// ProbeGuard 保護 /probe 系列端點：要求 admin token、依 caller 限速，
+// 並限制 /probe 只能對 allowlist 中的 target 發送請求
+type ProbeGuard struct {
+	token   string
+	allowed []*url.URL
+	limiter *ratelimit.Keyed
+}
+
+// NewProbeGuard 建立 ProbeGuard。allowed 的每一項為 URL（比對 scheme、host 與 path 前綴）
+// 或單純的 host（任何 http/https path 皆可）；perMinute 是每個 caller 每分鐘可呼叫的次數。
+// token 為空時所有請求都會被拒絕。
+func NewProbeGuard(token string, allowed []string, perMinute int) (*ProbeGuard, error) {
+	g := &ProbeGuard{token: token}
+	for _, raw := range allowed {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if !strings.Contains(raw, "://") {
+			raw = "//" + raw
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("invalid probe target %q", raw)
+		}
+		u.Host = strings.ToLower(u.Host)
+		g.allowed = append(g.allowed, u)
+	}
+	if perMinute > 0 {
+		g.limiter = ratelimit.NewKeyed(float64(perMinute)/60, perMinute)
+	}
+	return g, nil
+}
+
+// Wrap 在 next 之前檢查 caller 的速率與 admin token
+func (g *ProbeGuard) Wrap(next http.Handler) http.Handler {
+	return g.wrap(next, false)
+}
+
+// WrapSigned 與 Wrap 相同，但也接受 SignPath 產生的短期簽章連結，讓瀏覽器不需要 Authorization header 即可開啟。
+// 只用於唯讀的頁面。
+func (g *ProbeGuard) WrapSigned(next http.Handler) http.Handler {
+	return g.wrap(next, true)
+}
+
+// SignPath 為 path 產生 ttl 內有效的簽章，回傳附加在 query string 的 expires 與 sig
+func (g *ProbeGuard) SignPath(path string, ttl time.Duration) url.Values {
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	return url.Values{"expires": {expires}, "sig": {g.signature(path, expires)}}
+}
+
+// validSignature 檢查 r 的 expires / sig 是否為 r.URL.Path 的有效簽章
+func (g *ProbeGuard) validSignature(r *http.Request) bool {
+	q := r.URL.Query()
+	expires, sig := q.Get("expires"), q.Get("sig")
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(g.signature(r.URL.Path, expires)))
+}
+
+func (g *ProbeGuard) signature(path, expires string) string {
+	mac := hmac.New(sha256.New, []byte(g.token))
+	mac.Write([]byte("probe-link\n" + path + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (g *ProbeGuard) wrap(next http.Handler, allowSigned bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller := clientIP(r)
+		if g.token == "" {
+			g.reject(r, caller, "probe endpoints are disabled")
+			http.NotFound(w, r)
+			return
+		}
+		// 先限速再檢查 token，猜 token 的請求同樣受限
+		if g.limiter != nil && !g.limiter.Allow(caller) {
+			g.reject(r, caller, "rate limited")
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		if allowSigned && r.URL.Query().Has("sig") {
+			if !g.validSignature(r) {
+				g.reject(r, caller, "invalid or expired link")
+				http.Error(w, "link is invalid or expired", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		token, ok := bearerToken(r)
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(g.token)) != 1 {
+			g.reject(r, caller, "invalid admin token")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="probe"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AllowTarget 檢查 target 是否在 allowlist 中；probe.NewGuardedClient 以它檢查 redirect 的目的地，並在連線時檢查解析後的 IP 是否為公開位址
+func (g *ProbeGuard) AllowTarget(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid target url %q", target)
+	}
+	if u.User != nil {
+		return fmt.Errorf("target url must not contain credentials")
+	}
+	host := strings.ToLower(u.Host)
+	for _, a := range g.allowed {
+		if a.Scheme != "" && a.Scheme != u.Scheme {
+			continue
+		}
+		if a.Host != host && (a.Port() != "" || !strings.EqualFold(a.Hostname(), u.Hostname())) {
+			continue
+		}
+		if a.Path != "" && a.Path != "/" && u.Path != a.Path && !strings.HasPrefix(u.Path, strings.TrimSuffix(a.Path, "/")+"/") {
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("target %q is not in PROBE_ALLOWED_TARGETS", target)
+}
+
+// Reject 記錄被拒絕的請求；供 handler 在檢查 payload 後使用
+func (g *ProbeGuard) Reject(r *http.Request, reason string) {
+	g.reject(r, clientIP(r), reason)
+}
+
+func (g *ProbeGuard) reject(r *http.Request, caller, reason string) {
+	log.Printf("[Probe] rejected %s %s from %s: %s", r.Method, r.URL.Path, caller, reason)
+}
+
+// clientIP 回傳 caller 的 IP。Cloud Run 會把實際連線的來源附加在 X-Forwarded-For 的最後一項，
+// 前面的項目可由 client 任意偽造，因此只取最後一項。
+func clientIP(r *http.Request) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		parts := strings.Split(xff, ",")
+		if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProbeGuardSignedLinks(t *testing.T) {
+	guard, err := NewProbeGuard("secret", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	signed := guard.WrapSigned(ok)
+	bearerOnly := guard.Wrap(ok)
+
+	// 以 admin token 換取連結
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/probe/report/link?suite=smoke", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	NewProbeReportLinkHandler(guard).ServeHTTP(rec, req)
+	var link struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &link); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("link: status %d body %s", rec.Code, rec.Body)
+	}
+	if !strings.HasPrefix(link.URL, "/probe/report?") || !strings.Contains(link.URL, "suite=smoke") {
+		t.Fatalf("link url = %s", link.URL)
+	}
+
+	expired := guard.SignPath("/probe/report", -time.Minute)
+	otherPath := guard.SignPath("/probe/runs", time.Minute)
+	tampered, _ := url.Parse(link.URL)
+	q := tampered.Query()
+	q.Set("expires", q.Get("expires")+"0")
+	tampered.RawQuery = q.Encode()
+
+	cases := []struct {
+		name    string
+		handler http.Handler
+		target  string
+		bearer  string
+		want    int
+	}{
+		{"signed link", signed, link.URL, "", http.StatusOK},
+		{"bearer", signed, "/probe/report", "secret", http.StatusOK},
+		{"no credentials", signed, "/probe/report", "", http.StatusUnauthorized},
+		{"expired", signed, "/probe/report?" + expired.Encode(), "", http.StatusForbidden},
+		{"signed for another path", signed, "/probe/report?" + otherPath.Encode(), "", http.StatusForbidden},
+		{"tampered expiry", signed, tampered.String(), "", http.StatusForbidden},
+		{"link on bearer-only endpoint", bearerOnly, strings.Replace(link.URL, "/probe/report", "/probe/runs", 1), "", http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, c.target, nil)
+		if c.bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+c.bearer)
+		}
+		rec := httptest.NewRecorder()
+		c.handler.ServeHTTP(rec, req)
+		if rec.Code != c.want {
+			t.Errorf("%s: status %d, want %d", c.name, rec.Code, c.want)
+		}
+	}
+}
+
+func TestProbeGuardAllowTarget(t *testing.T) {
+	guard, err := NewProbeGuard("secret", []string{"https://gql.example.com/api", "legacy.example.com"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for target, want := range map[string]bool{
+		"https://gql.example.com/api/graphql": true,
+		"https://gql.example.com/apix":        false,
+		"http://gql.example.com/api/graphql":  false,
+		"http://legacy.example.com/graphql":   true,
+		"https://user:pw@legacy.example.com/": false,
+		"https://evil.example.com/api":        false,
+	} {
+		if got := guard.AllowTarget(target) == nil; got != want {
+			t.Errorf("AllowTarget(%s) = %v, want %v", target, got, want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go-story/internal/probe"
+)
+
+var probeReportTemplate = template.Must(template.New("probe-report").Funcs(template.FuncMap{
+	"percent": func(f float64) string { return fmt.Sprintf("%.0f%%", f*100) },
+	"short":   shortCommit,
+	"passed":  func(p probe.TrendPoint) bool { return p.Runs > 0 && p.Passed == p.Runs },
+	"last":    func(ps []probe.TrendPoint) probe.TrendPoint { return ps[len(ps)-1] },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-Hant">
+<head>
+<meta charset="utf-8">
+<title>probe report · {{.Suite}}</title>
+<style>
+body { font: 14px/1.5 system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { padding: 4px 10px; border-bottom: 1px solid #ddd; text-align: left; vertical-align: top; }
+th { background: #f5f5f5; }
+.cell { display: inline-block; width: 10px; height: 16px; margin-right: 1px; }
+.pass { background: #3a3; } .fail { background: #d33; } .partial { background: #e90; }
+.regressed { color: #d33; font-weight: bold; }
+.num { text-align: right; font-variant-numeric: tabular-nums; }
+</style>
+</head>
+<body>
+<h1>probe suite {{.Suite}}</h1>
+{{if not .History.Runs}}<p>尚無執行紀錄。</p>{{else}}
+<h2>Tests</h2>
+<table>
+<tr><th>test</th><th>最新通過率</th><th>歷史（舊 → 新）</th><th>開始失敗</th></tr>
+{{range .History.Trends}}{{$last := last .History}}{{$regressed := .Regressed}}
+<tr>
+<td>{{.Name}}</td>
+<td class="num">{{percent $last.PassRate}} ({{$last.Passed}}/{{$last.Runs}})</td>
+<td>{{range .History}}<span class="cell {{if passed .}}pass{{else if gt .Passed 0}}partial{{else}}fail{{end}}" title="run {{.RunID}} {{short .Commit}} {{.Passed}}/{{.Runs}}"></span>{{end}}</td>
+<td>{{with .FailingSince}}<span{{if $regressed}} class="regressed" title="此前曾全部通過"{{end}}>run {{.RunID}} · {{short .Commit}} · {{.StartedAt.Format "2006-01-02 15:04"}}</span>{{end}}</td>
+</tr>
+{{end}}
+</table>
+<h2>Runs</h2>
+<table>
+<tr><th>run</th><th>時間</th><th>commit</th><th>target</th><th class="num">通過</th><th class="num">target p50 / p95</th><th class="num">candidate p50 / p95</th></tr>
+{{range .History.Runs}}
+<tr>
+<td>{{.ID}}</td>
+<td>{{.StartedAt.Format "2006-01-02 15:04"}}</td>
+<td>{{short .Commit}}</td>
+<td>{{.Target}}</td>
+<td class="num">{{.Summary.Matched}}/{{.Summary.Total}}</td>
+<td class="num">{{printf "%.1f" .Latency.Target.P50.Float}} / {{printf "%.1f" .Latency.Target.P95.Float}} ms</td>
+<td class="num">{{printf "%.1f" .Latency.Self.P50.Float}} / {{printf "%.1f" .Latency.Self.P95.Float}} ms</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
+
+// probeReportLinkTTL 是 /probe/report/link 產生的連結有效時間
+const probeReportLinkTTL = 15 * time.Minute
+
+// NewProbeReportHandler serves GET /probe/report?suite=&target=&limit=：以 HTML 呈現歷史趨勢
+// 與每個 test 開始失敗的那一次執行。需要 admin token，或 /probe/report/link 產生的簽章連結。
+func NewProbeReportHandler(store probe.Store, guard *ProbeGuard) http.Handler {
+	return guard.WrapSigned(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET", http.StatusMethodNotAllowed)
+			return
+		}
+		if store == nil {
+			http.Error(w, "probe store is not configured", http.StatusNotFound)
+			return
+		}
+		history, err := loadProbeHistory(r, store)
+		if err != nil {
+			log.Printf("[Probe] list runs failed: %v", err)
+			http.Error(w, "failed to list probe runs", http.StatusInternalServerError)
+			return
+		}
+		suite := r.URL.Query().Get("suite")
+		if suite == "" {
+			suite = probe.DefaultSuite
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		// 簽章在網址中，不要經由 Referer 外流
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		if err := probeReportTemplate.Execute(w, struct {
+			Suite   string
+			History *probeHistory
+		}{suite, history}); err != nil {
+			log.Printf("[Probe] render report failed: %v", err)
+		}
+	}))
+}
+
+// NewProbeReportLinkHandler serves GET /probe/report/link?suite=&target=&limit=：
+// 以 admin token 換取 /probe/report 的短期簽章連結，供瀏覽器直接開啟
+func NewProbeReportLinkHandler(guard *ProbeGuard) http.Handler {
+	return guard.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET", http.StatusMethodNotAllowed)
+			return
+		}
+		q := url.Values{}
+		for _, k := range []string{"suite", "target", "limit"} {
+			if v := r.URL.Query().Get(k); v != "" {
+				q.Set(k, v)
+			}
+		}
+		for k, v := range guard.SignPath("/probe/report", probeReportLinkTTL) {
+			q[k] = v
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"url":       "/probe/report?" + q.Encode(),
+			"expiresAt": time.Now().Add(probeReportLinkTTL).UTC(),
+		})
+	}))
+}
+
+// shortCommit 回傳 commit 的前 7 碼
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
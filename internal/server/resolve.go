@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go-story/internal/data"
+	"go-story/internal/redirect"
+)
+
+// ResolveHandler serves GET /resolve?path=/story/{slug}/ for edge redirect handling.
+// 回應固定為 200 JSON，應使用的轉址狀態在 body 的 status 欄位。
+type ResolveHandler struct {
+	resolver *redirect.Resolver
+	cache    *data.Cache
+}
+
+// NewResolveHandler 建立 resolve handler；cache 可為 nil
+func NewResolveHandler(resolver *redirect.Resolver, cache *data.Cache) *ResolveHandler {
+	return &ResolveHandler{resolver: resolver, cache: cache}
+}
+
+func (h *ResolveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		http.Error(w, "missing path parameter", http.StatusBadRequest)
+		return
+	}
+	// 只以正規化後的路徑作為 cache key；無法解析的路徑不查 cache
+	cache := h.cache
+	if norm, ok := h.resolver.Normalize(path); ok {
+		path = norm
+	} else {
+		cache = nil
+	}
+	key := data.GenerateCacheKey("resolve", path)
+	serveCached(w, r, cache, nil, key, "application/json; charset=utf-8", func(ctx context.Context) ([]byte, time.Time, error) {
+		res, err := h.resolver.Resolve(ctx, path)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		body, err := json.Marshal(res)
+		if err == nil && res.Status == http.StatusNotFound {
+			// 找不到的路徑不存入 cache
+			err = errSkipCache
+		}
+		return body, time.Time{}, err
+	})
+}
@@ -4,16 +4,21 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"reflect"
-	"strconv"
+	"strings"
 	"time"
 
+	"go-story/internal/auth"
+	"go-story/internal/preview"
+	"go-story/internal/shadow"
+
 	"github.com/graphql-go/graphql"
 )
 
-func NewGraphQLHandler(schema graphql.Schema) http.Handler {
+// NewGraphQLHandler serves POST /api/graphql.
+// signer 用來驗證 X-Preview-Token header；為 nil 時 header 會被忽略。
+// mirror 不為 nil 時，匿名請求會依抽樣比例轉送到舊 GQL 服務比對（shadow 模式）。
+func NewGraphQLHandler(schema graphql.Schema, signer *preview.Signer, mirror *shadow.Mirror) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -32,1166 +37,82 @@ func NewGraphQLHandler(schema graphql.Schema) http.Handler {
 			return
 		}
 
+		ctx := preview.NewContext(r.Context())
+		if token := r.Header.Get(preview.HeaderName); token != "" && signer != nil {
+			claims, err := signer.Verify(token)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			preview.Grant(ctx, *claims)
+		}
+
+		start := time.Now()
 		result := graphql.Do(graphql.Params{
 			Schema:         schema,
 			RequestString:  payload.Query,
 			VariableValues: payload.Variables,
 			OperationName:  payload.OperationName,
-			Context:        r.Context(),
+			Context:        ctx,
 		})
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(result); err != nil {
-			http.Error(w, fmt.Sprintf("failed to encode response: %v", err), http.StatusInternalServerError)
-		}
-	})
-}
-
-type ProbeResult struct {
-	Name       string          `json:"name"`
-	Query      string          `json:"query,omitempty"` // 完整的 GraphQL query
-	StatusCode int             `json:"statusCode"`
-	Body       json.RawMessage `json:"body,omitempty"` // 完整的 response body
-	Error      string          `json:"error,omitempty"`
-	GQLErrors  []string        `json:"gqlErrors,omitempty"` // GraphQL errors 的簡要資訊
-}
-
-// ProbeHandler runs a set of built-in GQL queries against target URL.
-func ProbeHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "only POST", http.StatusMethodNotAllowed)
-		return
-	}
-	var payload struct {
-		URL string `json:"url"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.URL == "" {
-		http.Error(w, "invalid payload, need {\"url\": \"https://original-gql\"}", http.StatusBadRequest)
-		return
-	}
-
-	scheme := r.Header.Get("X-Forwarded-Proto")
-	if scheme == "" {
-		scheme = "http"
-	}
-	selfURL := fmt.Sprintf("%s://%s/api/graphql", scheme, r.Host)
-
-	targetResults := runProbeTests(payload.URL)
-	selfResults := runProbeTests(selfURL)
-
-	selfMap := map[string]ProbeResult{}
-	for _, r := range selfResults {
-		selfMap[r.Name] = r
-	}
-
-	type compare struct {
-		Name            string   `json:"name"`
-		Match           bool     `json:"match"`
-		TargetStatus    int      `json:"targetStatus,omitempty"`
-		SelfStatus      int      `json:"selfStatus,omitempty"`
-		TargetError     string   `json:"targetError,omitempty"`
-		SelfError       string   `json:"selfError,omitempty"`
-		TargetGQLErrors []string `json:"targetGQLErrors,omitempty"`
-		SelfGQLErrors   []string `json:"selfGQLErrors,omitempty"`
-		Note            string   `json:"note,omitempty"`
-	}
-
-	allResults := []compare{}
-	mismatches := []compare{}
-	for _, tr := range targetResults {
-		sr := selfMap[tr.Name]
-		match, note := compareBodies(tr, sr)
-		result := compare{
-			Name:            tr.Name,
-			Match:           match,
-			TargetStatus:    tr.StatusCode,
-			SelfStatus:      sr.StatusCode,
-			TargetError:     tr.Error,
-			SelfError:       sr.Error,
-			TargetGQLErrors: tr.GQLErrors,
-			SelfGQLErrors:   sr.GQLErrors,
-			Note:            note,
-		}
-		allResults = append(allResults, result)
-		if !match {
-			mismatches = append(mismatches, result)
+		// 預覽內容不可被任何中介快取保存；帶 JWT 或通過年齡驗證的回應依 caller 而異，不可被共用快取保存
+		w.Header().Add("Vary", "Authorization, "+auth.AgeVerifiedHeader)
+		if preview.Active(ctx) {
+			w.Header().Set("Cache-Control", "private, no-store")
+		} else if auth.FromContext(ctx) != nil || auth.AgeVerified(ctx) {
+			w.Header().Set("Cache-Control", "private")
 		}
-	}
-
-	total := len(allResults)
-	matched := total - len(mismatches)
-
-	response := map[string]any{
-		"target": payload.URL,
-		"self":   selfURL,
-		"summary": map[string]int{
-			"total":    total,
-			"matched":  matched,
-			"mismatch": len(mismatches),
-		},
-	}
-
-	// 只顯示不 match 的結果
-	if len(mismatches) > 0 {
-		response["mismatches"] = mismatches
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(response)
-}
-
-func runProbeTests(target string) []ProbeResult {
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	// 簡化版 postGQL：只保留 GetPostById，移除所有 fragments 和 OR 查詢
-	const postGQL = `query GetPostById($id: ID!) {
-  post(where: { id: $id }) {
-    id
-    title
-    subtitle
-    heroCaption
-    publishedDate
-    hiddenAdvertised
-    heroImage {
-      id
-      imageFile {
-        width
-        height
-      }
-      resized {
-        original
-        w480
-        w800
-        w1200
-        w1600
-        w2400
-      }
-      resizedWebp {
-        original
-        w480
-        w800
-        w1200
-        w1600
-        w2400
-      }
-    }
-    og_image {
-      id
-      imageFile {
-        width
-        height
-      }
-      resized {
-        original
-        w480
-        w800
-        w1200
-        w1600
-        w2400
-      }
-      resizedWebp {
-        original
-        w480
-        w800
-        w1200
-        w1600
-        w2400
-      }
-    }
-    tags {
-      slug
-      name
-    }
-    tags_algo {
-      slug
-      name
-    }
-    sections {
-      name
-      color
-      slug
-    }
-    categories {
-      name
-      slug
-    }
-    writers {
-      id
-      name
-    }
-    photographers {
-      id
-      name
-    }
-    designers {
-      id
-      name
-    }
-    engineers {
-      id
-      name
-    }
-    apiData
-    apiDataBrief
-    Warning {
-      id
-      content
-    }
-    Warnings {
-      id
-      content
-    }
-    isAdult
-  }
-}
-
-query GetRelatedPostsById($id: ID!) {
-  post(where: { id: $id }) {
-    relatedsOne {
-      id
-      slug
-      title
-      heroImage {
-        id
-        imageFile {
-          width
-          height
-        }
-        resized {
-          original
-          w480
-          w800
-          w1200
-          w1600
-          w2400
-        }
-      }
-    }
-    relatedsTwo {
-      id
-      slug
-      title
-      heroImage {
-        id
-        imageFile {
-          width
-          height
-        }
-        resized {
-          original
-          w480
-          w800
-          w1200
-          w1600
-          w2400
-        }
-      }
-    }
-    relateds {
-      id
-      slug
-      title
-      heroImage {
-        id
-        imageFile {
-          width
-          height
-        }
-        resized {
-          original
-          w480
-          w800
-          w1200
-          w1600
-          w2400
-        }
-      }
-    }
-  }
-}`
-
-	const externalGQL = `query GetExternalById($id: ID!) {
-  external(where: { id: $id }) {
-    id
-    title
-    thumb
-    thumbCaption
-    publishedDate
-    brief
-    content
-    tags {
-      name
-      slug
-    }
-    partner {
-      name
-      slug
-    }
-    sections {
-      name
-      color
-      slug
-    }
-    categories {
-      name
-      slug
-    }
-  }
-}
-
-query GetRelatedPostsByExternalId($id: ID!) {
-  external(where: { id: $id }) {
-    relateds {
-      id
-      slug
-      title
-      heroImage {
-        id
-        imageFile {
-          width
-          height
-        }
-        resized {
-          original
-          w480
-          w800
-          w1200
-          w1600
-          w2400
-        }
-      }
-    }
-  }
-}
-
-query GetExternalsByPartnerSlug(
-  $skip: Int!
-  $take: Int!
-  $slug: String!
-  $withAmount: Boolean = false
-) {
-  externals(
-    skip: $skip
-    take: $take
-    where: { partner: { slug: { equals: $slug } } }
-    orderBy: { publishedDate: desc }
-  ) {
-    id
-    title
-    brief
-    publishedDate
-    thumb
-  }
-  externalsCount(where: { partner: { slug: { equals: $slug } } })
-    @include(if: $withAmount)
-}`
-
-	const topicGQL = `query GetTopicBasicInfo($slug: String!) {
-  topic(where: { slug: $slug }) {
-    id
-    name
-    slug
-    sortOrder
-    state
-    publishedDate
-    brief
-    apiDataBrief
-    og_title
-    og_description
-    leading
-    type
-    style
-    heroUrl
-    heroImage {
-      id
-      imageFile {
-        width
-        height
-      }
-      resized {
-        original
-        w480
-        w800
-        w1200
-        w1600
-        w2400
-      }
-      resizedWebp {
-        original
-        w480
-        w800
-        w1200
-        w1600
-        w2400
-      }
-    }
-    heroVideo {
-      id
-      state
-      videoSrc
-      heroImage {
-        id
-        imageFile {
-          width
-          height
-        }
-        resized {
-          original
-          w480
-          w800
-          w1200
-          w1600
-          w2400
-        }
-      }
-    }
-    og_image {
-      id
-      imageFile {
-        width
-        height
-      }
-      resized {
-        original
-        w480
-        w800
-        w1200
-        w1600
-        w2400
-      }
-      resizedWebp {
-        original
-        w480
-        w800
-        w1200
-        w1600
-        w2400
-      }
-    }
-    slideshow_images {
-      id
-      name
-      topicKeywords
-      imageFile {
-        width
-        height
-      }
-      resized {
-        original
-        w480
-        w800
-        w1200
-        w1600
-        w2400
-      }
-      resizedWebp {
-        original
-        w480
-        w800
-        w1200
-        w1600
-        w2400
-      }
-    }
-    manualOrderOfSlideshowImages
-    tags {
-      id
-      name
-      slug
-    }
-    posts(take: 6, where: { state: { equals: "published" } }, orderBy: [{ publishedDate: desc }]) {
-      id
-      slug
-      title
-      publishedDate
-      heroImage {
-        id
-        imageFile {
-          width
-          height
-        }
-        resized {
-          original
-          w480
-          w800
-          w1200
-          w1600
-          w2400
-        }
-      }
-    }
-    postsCount(where: { state: { equals: "published" } })
-    sections {
-      id
-      name
-      slug
-      state
-      color
-    }
-    isFeatured
-    title_style
-    javascript
-    dfp
-    mobile_dfp
-    createdAt
-  }
-}`
-
-	const videoGQL = `query GetShortsData($id: ID!) {
-  video(where: { id: $id }) {
-    id
-    name
-    isShorts
-    youtubeUrl
-    fileDuration
-    youtubeDuration
-    videoSrc
-    content
-    heroImage {
-      id
-      imageFile {
-        width
-        height
-      }
-      resized {
-        original
-        w480
-        w800
-        w1200
-        w1600
-        w2400
-      }
-      resizedWebp {
-        original
-        w480
-        w800
-        w1200
-        w1600
-        w2400
-      }
-    }
-    uploader
-    uploaderEmail
-    isFeed
-    videoSection
-    state
-    publishedDate
-    publishedDateString
-    updateTimeStamp
-    tags {
-      id
-      name
-      slug
-    }
-    related_posts {
-      id
-      slug
-      title
-      heroImage {
-        id
-        imageFile {
-          width
-          height
-        }
-        resized {
-          original
-          w480
-          w800
-          w1200
-          w1600
-          w2400
-        }
-      }
-    }
-    createdAt
-  }
-}`
-
-	// probeSampleVars 會對 target GraphQL 發出簡單查詢，取得一組可用的 post / external / partner / topic / video 參考值
-	// 若失敗則回傳空字串，後續測試會用空變數，讓差異顯示在比對結果中。
-	samples := probeSampleVars(client, target)
-	postID := samples["postID"]
-	externalID := samples["externalID"]
-	topicSlug := samples["topicSlug"]
-	videoID := samples["videoID"]
-
-	// 將 ID 轉換為整數（target 需要整數格式）
-	// 如果轉換失敗，使用原始字串（讓錯誤顯示在比對結果中）
-	var postIDInt interface{} = postID
-	if id, err := strconv.Atoi(postID); err == nil {
-		postIDInt = id
-	}
-	var externalIDInt interface{} = externalID
-	if id, err := strconv.Atoi(externalID); err == nil {
-		externalIDInt = id
-	}
-	var videoIDInt interface{} = videoID
-	if id, err := strconv.Atoi(videoID); err == nil {
-		videoIDInt = id
-	}
-
-	tests := []struct {
-		name string
-		body map[string]any
-	}{
-		{
-			name: "posts_list",
-			body: map[string]any{
-				"query": `query ($take:Int,$skip:Int,$orderBy:[PostOrderByInput!]!,$filter:PostWhereInput!){
-					postsCount(where:$filter)
-					posts(take:$take,skip:$skip,orderBy:$orderBy,where:$filter){
-						id slug title publishedDate state
-					}
-				}`,
-				"variables": map[string]any{
-					"take":    3,
-					"skip":    0,
-					"orderBy": []map[string]string{{"publishedDate": "desc"}},
-					"filter": map[string]any{
-						"state": map[string]any{"equals": "published"},
-					},
-				},
-			},
-		},
-		// Full post.gql: use operation GetPostById to覆蓋所有 fragment / 欄位行為
-		{
-			name: "post_gql_GetPostById",
-			body: map[string]any{
-				"query":         postGQL,
-				"operationName": "GetPostById",
-				"variables": map[string]any{
-					"id": postIDInt,
-				},
-			},
-		},
-		// post_by_slug 測試已移除：target 不支援 PostWhereInput 中的 slug 欄位
-		{
-			name: "externals_list",
-			body: map[string]any{
-				"query": `query ($take:Int,$skip:Int,$orderBy:[ExternalOrderByInput!]!,$filter:ExternalWhereInput!){
-					externals(take:$take,skip:$skip,orderBy:$orderBy,where:$filter){
-						id slug title thumb brief publishedDate partner{ id slug name showOnIndex }
-					}
-				}`,
-				"variables": map[string]any{
-					"take":    3,
-					"skip":    0,
-					"orderBy": []map[string]string{{"publishedDate": "desc"}},
-					"filter": map[string]any{
-						"state":         map[string]any{"equals": "published"},
-						"publishedDate": map[string]any{"not": map[string]any{"equals": nil}},
-					},
-				},
-			},
-		},
-		// external_by_slug 測試已移除：target 不支援 ExternalWhereInput 中的 slug 欄位
-		// Full external.gql: use operation GetExternalById 覆蓋完整欄位
-		{
-			name: "external_gql_GetExternalById",
-			body: map[string]any{
-				"query":         externalGQL,
-				"operationName": "GetExternalById",
-				"variables": map[string]any{
-					"id": externalIDInt,
-				},
-			},
-		},
-		{
-			name: "topics_list",
-			body: map[string]any{
-				"query": `query ($take:Int,$skip:Int,$orderBy:[TopicOrderByInput!]!,$filter:TopicWhereInput!){
-					topicsCount(where:$filter)
-					topics(take:$take,skip:$skip,orderBy:$orderBy,where:$filter){
-						id name slug sortOrder state publishedDate heroImage{ id imageFile{ width height } resized{ original w480 w800 w1200 w1600 w2400 } resizedWebp{ original w480 w800 w1200 w1600 w2400 } }
-					}
-				}`,
-				"variables": map[string]any{
-					"take":    3,
-					"skip":    0,
-					"orderBy": []map[string]string{{"sortOrder": "asc"}, {"id": "desc"}},
-					"filter": map[string]any{
-						"state": map[string]any{"equals": "published"},
-					},
-				},
-			},
-		},
-		{
-			name: "topic_gql_GetTopicBasicInfo",
-			body: map[string]any{
-				"query":         topicGQL,
-				"operationName": "GetTopicBasicInfo",
-				"variables": map[string]any{
-					"slug": topicSlug,
-				},
-			},
-		},
-		{
-			name: "videos_list",
-			body: map[string]any{
-				"query": `query ($take:Int,$skip:Int,$orderBy:[VideoOrderByInput!]!,$filter:VideoWhereInput!){
-					videosCount(where:$filter)
-					videos(take:$take,skip:$skip,orderBy:$orderBy,where:$filter){
-						id name isShorts youtubeUrl fileDuration youtubeDuration videoSrc content heroImage{ id imageFile{ width height } resized{ original w480 w800 w1200 w1600 w2400 } resizedWebp{ original w480 w800 w1200 w1600 w2400 } } videoSection state publishedDate publishedDateString updateTimeStamp tags{ id name slug }
-					}
-				}`,
-				"variables": map[string]any{
-					"take":    3,
-					"skip":    0,
-					"orderBy": []map[string]string{{"publishedDate": "desc"}},
-					"filter": map[string]any{
-						"state": map[string]any{"equals": "published"},
-					},
-				},
-			},
-		},
-		{
-			name: "video_gql_GetShortsData",
-			body: map[string]any{
-				"query":         videoGQL,
-				"operationName": "GetShortsData",
-				"variables": map[string]any{
-					"id": videoIDInt,
-				},
-			},
-		},
-	}
-
-	results := make([]ProbeResult, 0, len(tests))
-	for _, t := range tests {
-		res := ProbeResult{Name: t.name}
-		// 保存完整的 GraphQL query
-		if query, ok := t.body["query"].(string); ok {
-			res.Query = query
-		}
-		b, _ := json.Marshal(t.body)
-		req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(b))
-		if err != nil {
-			res.Error = err.Error()
-			results = append(results, res)
-			continue
-		}
-		req.Header.Set("Content-Type", "application/json")
-
-		resp, err := client.Do(req)
-		if err != nil {
-			res.Error = err.Error()
-			results = append(results, res)
-			continue
-		}
-		res.StatusCode = resp.StatusCode
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			res.Error = err.Error()
-		} else {
-			res.Body = json.RawMessage(body)
-			// 嘗試解析 GraphQL errors
-			var gqlResp struct {
-				Errors []struct {
-					Message string      `json:"message"`
-					Path    interface{} `json:"path,omitempty"`
-				} `json:"errors"`
-			}
-			if json.Unmarshal(body, &gqlResp) == nil && len(gqlResp.Errors) > 0 {
-				res.GQLErrors = make([]string, 0, len(gqlResp.Errors))
-				for _, e := range gqlResp.Errors {
-					res.GQLErrors = append(res.GQLErrors, e.Message)
-				}
-			}
-		}
-		results = append(results, res)
-	}
-	return results
-}
-
-func compareBodies(target ProbeResult, self ProbeResult) (bool, string) {
-	// If either has transport error
-	if target.Error != "" || self.Error != "" {
-		return target.Error == "" && self.Error == "", "transport error"
-	}
-	if target.StatusCode != self.StatusCode {
-		return false, "status code differ"
-	}
-
-	// 解析 GraphQL response 結構
-	type gqlResponse struct {
-		Data   interface{} `json:"data"`
-		Errors interface{} `json:"errors"`
-	}
-
-	var targetResp, selfResp gqlResponse
-	if err := json.Unmarshal(target.Body, &targetResp); err != nil {
-		return false, fmt.Sprintf("target JSON parse error: %v", err)
-	}
-	if err := json.Unmarshal(self.Body, &selfResp); err != nil {
-		return false, fmt.Sprintf("self JSON parse error: %v", err)
-	}
-
-	// 檢查 errors：如果兩邊都有 errors 或都沒有 errors，繼續比對 data
-	// 如果一邊有 errors 另一邊沒有，則不 match
-	targetHasErrors := targetResp.Errors != nil && !isEmptyValue(targetResp.Errors)
-	selfHasErrors := selfResp.Errors != nil && !isEmptyValue(selfResp.Errors)
-	if targetHasErrors != selfHasErrors {
-		return false, fmt.Sprintf("errors mismatch: target has errors=%v, self has errors=%v", targetHasErrors, selfHasErrors)
-	}
-
-	// 比對 data 部分（使用深度比對，但忽略順序差異）
-	if deepEqualData(targetResp.Data, selfResp.Data) {
-		return true, ""
-	}
-
-	// 如果 data 不同，嘗試提供更詳細的差異資訊
-	diff := findDataDifference(targetResp.Data, selfResp.Data)
-	if diff != "" {
-		return false, fmt.Sprintf("data differ: %s", diff)
-	}
-	return false, "data structure differs"
-}
-
-// isEmptyValue 檢查值是否為空（nil, 空陣列, 空 map）
-func isEmptyValue(v interface{}) bool {
-	if v == nil {
-		return true
-	}
-	rv := reflect.ValueOf(v)
-	switch rv.Kind() {
-	case reflect.Slice, reflect.Array, reflect.Map:
-		return rv.Len() == 0
-	case reflect.String:
-		return rv.Len() == 0
-	}
-	return false
-}
-
-// deepEqualData 比對兩個 data 物件，忽略 JSON 欄位順序
-func deepEqualData(a, b interface{}) bool {
-	// 先做標準深度比對
-	if reflect.DeepEqual(a, b) {
-		return true
-	}
-
-	// 如果都是 map，遞迴比對每個 key
-	aMap, aIsMap := a.(map[string]interface{})
-	bMap, bIsMap := b.(map[string]interface{})
-	if aIsMap && bIsMap {
-		if len(aMap) != len(bMap) {
-			return false
-		}
-		for k, av := range aMap {
-			bv, ok := bMap[k]
-			if !ok {
-				return false
-			}
-			if !deepEqualData(av, bv) {
-				return false
-			}
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(result); err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode response: %v", err), http.StatusInternalServerError)
+			return
 		}
-		return true
-	}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(buf.Bytes())
 
-	// 如果都是 slice，比對每個元素
-	aSlice, aIsSlice := a.([]interface{})
-	bSlice, bIsSlice := b.([]interface{})
-	if aIsSlice && bIsSlice {
-		if len(aSlice) != len(bSlice) {
-			return false
+		// 預覽、帶 JWT 與通過年齡驗證的回應依 caller 而異，舊服務收不到這些憑證，不轉送
+		if mirror != nil && !preview.Active(ctx) && auth.FromContext(ctx) == nil && !auth.AgeVerified(ctx) {
+			mirror.Observe(shadow.Request{
+				Query:         payload.Query,
+				OperationName: payload.OperationName,
+				Variables:     payload.Variables,
+			}, http.StatusOK, buf.Bytes(), time.Since(start))
 		}
-		// 對 slice 做寬鬆比對：允許順序不同（如果元素可比較）
-		matched := make([]bool, len(bSlice))
-		for _, ae := range aSlice {
-			found := false
-			for i, be := range bSlice {
-				if !matched[i] && deepEqualData(ae, be) {
-					matched[i] = true
-					found = true
-					break
-				}
-			}
-			if !found {
-				return false
-			}
-		}
-		return true
-	}
-
-	return false
-}
-
-// findDataDifference 找出 data 差異的簡要描述
-func findDataDifference(a, b interface{}) string {
-	return findDataDifferenceRecursive(a, b, "")
+	})
 }
 
-func findDataDifferenceRecursive(a, b interface{}, path string) string {
-	// 如果相同，返回空字串
-	if deepEqualData(a, b) {
-		return ""
-	}
-
-	// 處理 map
-	aMap, aIsMap := a.(map[string]interface{})
-	bMap, bIsMap := b.(map[string]interface{})
-	if aIsMap && bIsMap {
-		// 檢查缺少的 key
-		for k := range aMap {
-			if _, ok := bMap[k]; !ok {
-				return fmt.Sprintf("missing key in self: %s", buildPath(path, k))
-			}
-		}
-		// 檢查多餘的 key
-		for k := range bMap {
-			if _, ok := aMap[k]; !ok {
-				return fmt.Sprintf("extra key in self: %s", buildPath(path, k))
-			}
-		}
-		// 遞迴檢查每個 key 的值
-		for k, av := range aMap {
-			if bv, ok := bMap[k]; ok {
-				if diff := findDataDifferenceRecursive(av, bv, buildPath(path, k)); diff != "" {
-					return diff
-				}
+// WithAuth 驗證 Authorization: Bearer <JWT>，並將 claims 放進 request context。
+// verifier 為 nil 或沒有帶 token 時視為匿名 caller；token 無效時回 401。
+// X-Age-Verified header 只有在 age 驗證簽章通過時才記錄在 context 中，之後一律從 request 移除。
+func WithAuth(verifier *auth.Verifier, age *auth.AgeSigner, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.Header.Get(auth.AgeVerifiedHeader); v != "" {
+			if age.Verify(v) {
+				r = r.WithContext(auth.WithAgeVerified(r.Context()))
 			}
+			r.Header.Del(auth.AgeVerifiedHeader)
 		}
-		return "structure or value differs"
-	}
-
-	// 處理 slice
-	aSlice, aIsSlice := a.([]interface{})
-	bSlice, bIsSlice := b.([]interface{})
-	if aIsSlice && bIsSlice {
-		if len(aSlice) != len(bSlice) {
-			// 陣列長度不同時，顯示更詳細的資訊
-			targetPreview := ""
-			selfPreview := ""
-			if len(aSlice) > 0 {
-				if preview, err := json.Marshal(aSlice[0]); err == nil {
-					if len(preview) > 100 {
-						targetPreview = string(preview[:100]) + "..."
-					} else {
-						targetPreview = string(preview)
-					}
-				}
-			}
-			if len(bSlice) > 0 {
-				if preview, err := json.Marshal(bSlice[0]); err == nil {
-					if len(preview) > 100 {
-						selfPreview = string(preview[:100]) + "..."
-					} else {
-						selfPreview = string(preview)
-					}
-				}
-			}
-			if targetPreview != "" || selfPreview != "" {
-				return fmt.Sprintf("array length differs at %s: target=%d (first: %s), self=%d (first: %s)", path, len(aSlice), targetPreview, len(bSlice), selfPreview)
-			}
-			return fmt.Sprintf("array length differs at %s: target=%d, self=%d", path, len(aSlice), len(bSlice))
+		token, ok := bearerToken(r)
+		if !ok || verifier == nil {
+			next.ServeHTTP(w, r)
+			return
 		}
-		// 檢查每個元素
-		for i := 0; i < len(aSlice) && i < 3; i++ { // 只檢查前 3 個元素避免輸出過長
-			if diff := findDataDifferenceRecursive(aSlice[i], bSlice[i], fmt.Sprintf("%s[%d]", path, i)); diff != "" {
-				return diff
-			}
+		claims, err := verifier.Verify(token)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
 		}
-		return "array elements differ"
-	}
-
-	// 基本類型比較
-	if path != "" {
-		return fmt.Sprintf("value differs at %s: target=%v, self=%v", path, a, b)
-	}
-	return "structure or value differs"
-}
-
-func buildPath(base, key string) string {
-	if base == "" {
-		return key
-	}
-	return fmt.Sprintf("%s.%s", base, key)
-}
-
-func normalizeJSON(raw []byte) (interface{}, error) {
-	var v interface{}
-	if err := json.Unmarshal(raw, &v); err != nil {
-		return nil, err
-	}
-	return v, nil
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), claims)))
+	})
 }
 
-// probeSampleVars 從 target GraphQL 取一組實際存在的 post / external / partner 參考值，
-// 以避免硬編 id / slug 造成 400 或比對失敗。
-func probeSampleVars(client *http.Client, target string) map[string]string {
-	out := map[string]string{}
-
-	type gqlPayload struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables,omitempty"`
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	if len(h) < 7 || !strings.EqualFold(h[:7], "Bearer ") {
+		return "", false
 	}
-	type gqlResponse struct {
-		Data   map[string]json.RawMessage `json:"data"`
-		Errors interface{}                `json:"errors"`
-	}
-
-	// 1) 抓一篇 post（已發佈）
-	postReqBody := gqlPayload{
-		Query: `query ($take:Int,$skip:Int,$orderBy:[PostOrderByInput!]!,$filter:PostWhereInput!){
-  posts(take:$take,skip:$skip,orderBy:$orderBy,where:$filter){
-    id
-    slug
-  }
-}`,
-		Variables: map[string]interface{}{
-			"take":    1,
-			"skip":    0,
-			"orderBy": []map[string]string{{"publishedDate": "desc"}},
-			"filter": map[string]interface{}{
-				"state": map[string]interface{}{"equals": "published"},
-			},
-		},
-	}
-	if b, err := json.Marshal(postReqBody); err == nil {
-		if req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(b)); err == nil {
-			req.Header.Set("Content-Type", "application/json")
-			if resp, err := client.Do(req); err == nil {
-				body, _ := io.ReadAll(resp.Body)
-				resp.Body.Close()
-				var gr gqlResponse
-				if err := json.Unmarshal(body, &gr); err == nil && gr.Data != nil {
-					if rawPosts, ok := gr.Data["posts"]; ok {
-						var posts []struct {
-							ID   string `json:"id"`
-							Slug string `json:"slug"`
-						}
-						if err := json.Unmarshal(rawPosts, &posts); err == nil && len(posts) > 0 {
-							out["postID"] = posts[0].ID
-							out["postSlug"] = posts[0].Slug
-						}
-					}
-				}
-			}
-		}
-	}
-
-	// 2) 抓一篇 external（已發佈，且有 partner）
-	extReqBody := gqlPayload{
-		Query: `query ($take:Int,$skip:Int,$orderBy:[ExternalOrderByInput!]!,$filter:ExternalWhereInput!){
-  externals(take:$take,skip:$skip,orderBy:$orderBy,where:$filter){
-    id
-    slug
-    partner{ slug }
-  }
-}`,
-		Variables: map[string]interface{}{
-			"take":    1,
-			"skip":    0,
-			"orderBy": []map[string]string{{"publishedDate": "desc"}},
-			"filter": map[string]interface{}{
-				"state":         map[string]interface{}{"equals": "published"},
-				"publishedDate": map[string]interface{}{"not": map[string]interface{}{"equals": nil}},
-			},
-		},
-	}
-	if b, err := json.Marshal(extReqBody); err == nil {
-		if req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(b)); err == nil {
-			req.Header.Set("Content-Type", "application/json")
-			if resp, err := client.Do(req); err == nil {
-				body, _ := io.ReadAll(resp.Body)
-				resp.Body.Close()
-				var gr gqlResponse
-				if err := json.Unmarshal(body, &gr); err == nil && gr.Data != nil {
-					if rawExts, ok := gr.Data["externals"]; ok {
-						var exts []struct {
-							ID      string `json:"id"`
-							Slug    string `json:"slug"`
-							Partner *struct {
-								Slug string `json:"slug"`
-							} `json:"partner"`
-						}
-						if err := json.Unmarshal(rawExts, &exts); err == nil && len(exts) > 0 {
-							out["externalID"] = exts[0].ID
-							out["externalSlug"] = exts[0].Slug
-							if exts[0].Partner != nil {
-								out["partnerSlug"] = exts[0].Partner.Slug
-							}
-						}
-					}
-				}
-			}
-		}
-	}
-
-	// 3) 抓一個 topic（已發佈）
-	topicReqBody := gqlPayload{
-		Query: `query ($take:Int,$skip:Int,$orderBy:[TopicOrderByInput!]!,$filter:TopicWhereInput!){
-  topics(take:$take,skip:$skip,orderBy:$orderBy,where:$filter){
-    slug
-  }
-}`,
-		Variables: map[string]interface{}{
-			"take":    1,
-			"skip":    0,
-			"orderBy": []map[string]string{{"sortOrder": "asc"}},
-			"filter": map[string]interface{}{
-				"state": map[string]interface{}{"equals": "published"},
-			},
-		},
-	}
-	if b, err := json.Marshal(topicReqBody); err == nil {
-		if req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(b)); err == nil {
-			req.Header.Set("Content-Type", "application/json")
-			if resp, err := client.Do(req); err == nil {
-				body, _ := io.ReadAll(resp.Body)
-				resp.Body.Close()
-				var gr gqlResponse
-				if err := json.Unmarshal(body, &gr); err == nil && gr.Data != nil {
-					if rawTopics, ok := gr.Data["topics"]; ok {
-						var topics []struct {
-							Slug string `json:"slug"`
-						}
-						if err := json.Unmarshal(rawTopics, &topics); err == nil && len(topics) > 0 {
-							out["topicSlug"] = topics[0].Slug
-						}
-					}
-				}
-			}
-		}
-	}
-
-	// 4) 抓一個 video（已發佈）
-	videoReqBody := gqlPayload{
-		Query: `query ($take:Int,$skip:Int,$orderBy:[VideoOrderByInput!]!,$filter:VideoWhereInput!){
-  videos(take:$take,skip:$skip,orderBy:$orderBy,where:$filter){
-    id
-  }
-}`,
-		Variables: map[string]interface{}{
-			"take":    1,
-			"skip":    0,
-			"orderBy": []map[string]string{{"publishedDate": "desc"}},
-			"filter": map[string]interface{}{
-				"state": map[string]interface{}{"equals": "published"},
-			},
-		},
-	}
-	if b, err := json.Marshal(videoReqBody); err == nil {
-		if req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(b)); err == nil {
-			req.Header.Set("Content-Type", "application/json")
-			if resp, err := client.Do(req); err == nil {
-				body, _ := io.ReadAll(resp.Body)
-				resp.Body.Close()
-				var gr gqlResponse
-				if err := json.Unmarshal(body, &gr); err == nil && gr.Data != nil {
-					if rawVideos, ok := gr.Data["videos"]; ok {
-						var videos []struct {
-							ID string `json:"id"`
-						}
-						if err := json.Unmarshal(rawVideos, &videos); err == nil && len(videos) > 0 {
-							out["videoID"] = videos[0].ID
-						}
-					}
-				}
-			}
-		}
-	}
-
-	return out
+	token := strings.TrimSpace(h[7:])
+	return token, token != ""
 }
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-story/internal/auth"
+	"go-story/internal/shadow"
+
+	"github.com/graphql-go/graphql"
+)
+
+// ageSchema 回傳只有 ageVerified 欄位的 schema，讓測試觀察 context 中的年齡驗證狀態
+func ageSchema(t *testing.T) graphql.Schema {
+	t.Helper()
+	s, err := graphql.NewSchema(graphql.SchemaConfig{Query: graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{"ageVerified": &graphql.Field{
+			Type: graphql.Boolean,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return auth.AgeVerified(p.Context), nil
+			},
+		}},
+	})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestWithAuthAgeVerifiedHeader(t *testing.T) {
+	signer := auth.NewAgeSigner("edge-secret")
+	other := auth.NewAgeSigner("other-secret")
+	var seen string
+	gql := NewGraphQLHandler(ageSchema(t), nil, nil)
+	h := WithAuth(nil, signer, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get(auth.AgeVerifiedHeader)
+		gql.ServeHTTP(w, r)
+	}))
+
+	cases := []struct {
+		name    string
+		header  string
+		want    bool
+		private bool
+	}{
+		{"none", "", false, false},
+		{"plain true", "true", false, false},
+		{"wrong secret", other.Sign(time.Hour), false, false},
+		{"expired", signer.Sign(-time.Minute), false, false},
+		{"signed", signer.Sign(time.Hour), true, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(`{"query":"{ ageVerified }"}`))
+			if tc.header != "" {
+				req.Header.Set(auth.AgeVerifiedHeader, tc.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if got := strings.Contains(rec.Body.String(), `"ageVerified":true`); got != tc.want {
+				t.Errorf("ageVerified = %v, want %v (body %s)", got, tc.want, rec.Body.String())
+			}
+			if seen != "" {
+				t.Errorf("header %q was not stripped", seen)
+			}
+			if got := rec.Header().Get("Cache-Control") == "private"; got != tc.private {
+				t.Errorf("Cache-Control = %q, want private=%v", rec.Header().Get("Cache-Control"), tc.private)
+			}
+			if !strings.Contains(rec.Header().Get("Vary"), auth.AgeVerifiedHeader) {
+				t.Errorf("Vary = %q, want %s", rec.Header().Get("Vary"), auth.AgeVerifiedHeader)
+			}
+		})
+	}
+}
+
+func TestWithAuthIgnoresHeaderWithoutSecret(t *testing.T) {
+	h := WithAuth(nil, nil, NewGraphQLHandler(ageSchema(t), nil, nil))
+	req := httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(`{"query":"{ ageVerified }"}`))
+	req.Header.Set(auth.AgeVerifiedHeader, auth.NewAgeSigner("x").Sign(time.Hour))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `"ageVerified":false`) {
+		t.Errorf("header trusted without AGE_VERIFY_SECRET: %s", rec.Body.String())
+	}
+}
+
+// 舊服務收不到 X-Age-Verified，轉送通過年齡驗證的請求只會得到假的不一致
+func TestGraphQLHandlerSkipsMirrorForAgeVerified(t *testing.T) {
+	var mirrored atomic.Int32
+	legacy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrored.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"ageVerified":false}}`))
+	}))
+	defer legacy.Close()
+	signer := auth.NewAgeSigner("edge-secret")
+	mirror := shadow.New(shadow.Config{URL: legacy.URL, SampleRate: 1})
+	h := WithAuth(nil, signer, NewGraphQLHandler(ageSchema(t), nil, mirror))
+
+	for _, header := range []string{"", signer.Sign(time.Hour)} {
+		req := httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(`{"query":"{ ageVerified }"}`))
+		if header != "" {
+			req.Header.Set(auth.AgeVerifiedHeader, header)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	// Close 等待佇列中的轉送完成
+	mirror.Close()
+	if n := mirrored.Load(); n != 1 {
+		t.Errorf("mirrored %d requests, want only the anonymous one", n)
+	}
+}
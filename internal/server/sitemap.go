@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-story/internal/data"
+	"go-story/internal/links"
+	"go-story/internal/sitemap"
+)
+
+const (
+	// sitemapPageSize 是每個子 sitemap 的網址數（低於 sitemap.MaxURLs 以控制回應大小）
+	sitemapPageSize = 10000
+	// newsWindow / newsLimit 依 Google News sitemap 規則：最近 48 小時、最多 1000 則
+	newsWindow = 48 * time.Hour
+	newsLimit  = 1000
+	// sitemapCursorTTL 是 index 產生的 cursor 清單在記憶體中的有效時間
+	sitemapCursorTTL = 10 * time.Minute
+)
+
+const xmlContentType = "application/xml; charset=utf-8"
+
+// sitemapScheduledTables 是有排程發佈內容的 sitemap 種類對應的 table，cache 會在下一筆內容上線時過期
+var sitemapScheduledTables = map[string]string{"posts": "Post", "externals": "External", "topics": "Topic", "videos": "Video"}
+
+// SitemapHandler serves /sitemap.xml (index), /sitemaps/{kind}-{after}.xml and /sitemap-news.xml.
+type SitemapHandler struct {
+	repo     *data.Repo
+	cache    *data.Cache
+	links    *links.Builder
+	siteName string
+
+	// cursors 記錄各 kind 在 index 中出現的 cursor，只有這些子 sitemap 會存入 cache
+	mu      sync.Mutex
+	cursors map[string]cursorSet
+}
+
+type cursorSet struct {
+	after     map[int]bool
+	fetchedAt time.Time
+}
+
+// NewSitemapHandler 建立 sitemap handler；cache 可為 nil
+func NewSitemapHandler(repo *data.Repo, cache *data.Cache, lb *links.Builder, siteName string) *SitemapHandler {
+	return &SitemapHandler{repo: repo, cache: cache, links: lb, siteName: siteName, cursors: map[string]cursorSet{}}
+}
+
+func (h *SitemapHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := data.GenerateCacheKey("sitemap", r.URL.Path)
+	switch {
+	case r.URL.Path == "/sitemap.xml":
+		serveCached(w, r, h.cache, publishTTL(h.repo, h.cache, "Post", "External", "Topic", "Video"), key, xmlContentType, h.buildIndex)
+	case r.URL.Path == "/sitemap-news.xml":
+		serveCached(w, r, h.cache, publishTTL(h.repo, h.cache, "Post"), key, xmlContentType, h.buildNews)
+	case strings.HasPrefix(r.URL.Path, "/sitemaps/") && strings.HasSuffix(r.URL.Path, ".xml"):
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/sitemaps/"), ".xml")
+		dash := strings.LastIndex(name, "-")
+		if dash < 0 {
+			http.NotFound(w, r)
+			return
+		}
+		kind, raw := name[:dash], name[dash+1:]
+		after, err := strconv.Atoi(raw)
+		// 只接受 index 使用的十進位寫法，避免同一頁有多個網址（例如前置 0）
+		if err != nil || after < 0 || strconv.Itoa(after) != raw || !isSitemapKind(kind) {
+			http.NotFound(w, r)
+			return
+		}
+		var ttl ttlFunc
+		if table, ok := sitemapScheduledTables[kind]; ok {
+			ttl = publishTTL(h.repo, h.cache, table)
+		}
+		serveCached(w, r, h.cache, ttl, key, xmlContentType, func(ctx context.Context) ([]byte, time.Time, error) {
+			known, err := h.knownCursor(ctx, kind, after)
+			if err != nil {
+				return nil, time.Time{}, err
+			}
+			body, updated, err := h.buildURLSet(ctx, kind, after)
+			if err == nil && !known {
+				// 不是 index 產生的 cursor（舊的 index 或任意值）：照常回應但不存入 cache
+				err = errSkipCache
+			}
+			return body, updated, err
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// buildIndex 產生 sitemap index；單一種類查詢失敗時記錄 log 並略過該種類，
+// 不讓整個 index 回傳 500，略過種類的 index 也不存入 cache。
+func (h *SitemapHandler) buildIndex(ctx context.Context) ([]byte, time.Time, error) {
+	var (
+		refs    []sitemap.Ref
+		updated time.Time
+		partial bool
+	)
+	for _, kind := range data.SitemapKinds() {
+		pages, err := h.repo.SitemapPages(ctx, kind, sitemapPageSize)
+		if err != nil {
+			log.Printf("[Sitemap] skip %s in index: %v", kind, err)
+			partial = true
+			continue
+		}
+		h.setCursors(kind, pages)
+		for _, p := range pages {
+			refs = append(refs, sitemap.Ref{
+				Loc:     h.links.Abs("/sitemaps/" + kind + "-" + strconv.Itoa(p.After) + ".xml"),
+				LastMod: p.LastMod,
+			})
+			if p.LastMod.After(updated) {
+				updated = p.LastMod
+			}
+		}
+	}
+	refs = append(refs, sitemap.Ref{Loc: h.links.Abs("/sitemap-news.xml"), LastMod: updated})
+	body, err := sitemap.Index(refs)
+	if err == nil && partial {
+		err = errSkipCache
+	}
+	return body, updated, err
+}
+
+// knownCursor 回傳 after 是否為 index 目前產生的 cursor；清單過期時重新計算
+func (h *SitemapHandler) knownCursor(ctx context.Context, kind string, after int) (bool, error) {
+	h.mu.Lock()
+	cs, ok := h.cursors[kind]
+	h.mu.Unlock()
+	if !ok || time.Since(cs.fetchedAt) > sitemapCursorTTL {
+		pages, err := h.repo.SitemapPages(ctx, kind, sitemapPageSize)
+		if err != nil {
+			return false, err
+		}
+		cs = h.setCursors(kind, pages)
+	}
+	return cs.after[after], nil
+}
+
+func (h *SitemapHandler) setCursors(kind string, pages []data.SitemapPage) cursorSet {
+	cs := cursorSet{after: make(map[int]bool, len(pages)), fetchedAt: time.Now()}
+	for _, p := range pages {
+		cs.after[p.After] = true
+	}
+	h.mu.Lock()
+	h.cursors[kind] = cs
+	h.mu.Unlock()
+	return cs
+}
+
+func (h *SitemapHandler) buildURLSet(ctx context.Context, kind string, after int) ([]byte, time.Time, error) {
+	entries, err := h.repo.ScanSitemap(ctx, kind, after, sitemapPageSize)
+	if err != nil || len(entries) == 0 {
+		return nil, time.Time{}, err
+	}
+	var updated time.Time
+	urls := make([]sitemap.URL, 0, len(entries))
+	for _, e := range entries {
+		urls = append(urls, sitemap.URL{Loc: h.sitemapLoc(kind, e.Key), LastMod: e.LastMod})
+		if e.LastMod.After(updated) {
+			updated = e.LastMod
+		}
+	}
+	body, err := sitemap.URLSet(urls)
+	return body, updated, err
+}
+
+func (h *SitemapHandler) buildNews(ctx context.Context) ([]byte, time.Time, error) {
+	entries, err := h.repo.ScanNewsSitemap(ctx, newsWindow, newsLimit)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var updated time.Time
+	urls := make([]sitemap.NewsURL, 0, len(entries))
+	for _, e := range entries {
+		urls = append(urls, sitemap.NewsURL{Loc: h.links.Post(e.Slug), Title: e.Title, PublicationDate: e.PublishedDate})
+		if e.PublishedDate.After(updated) {
+			updated = e.PublishedDate
+		}
+	}
+	body, err := sitemap.News(h.siteName, "zh-tw", urls)
+	return body, updated, err
+}
+
+func (h *SitemapHandler) sitemapLoc(kind, key string) string {
+	switch kind {
+	case "posts":
+		return h.links.Post(key)
+	case "externals":
+		return h.links.External(key)
+	case "topics":
+		return h.links.Topic(key)
+	case "videos":
+		return h.links.Video(key)
+	case "sections":
+		return h.links.Section(key)
+	default:
+		return h.links.Tag(key)
+	}
+}
+
+func isSitemapKind(kind string) bool {
+	for _, k := range data.SitemapKinds() {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-story/internal/data"
+	"go-story/internal/links"
+)
+
+func TestSitemapRejectsMalformedCursors(t *testing.T) {
+	// repo 為 nil：這些路徑必須在查詢資料庫前就回應 404
+	h := NewSitemapHandler(nil, nil, links.New("https://www.example.com"), "site")
+	for _, path := range []string{
+		"/sitemaps/posts-007.xml",
+		"/sitemaps/posts-+1.xml",
+		"/sitemaps/posts--1.xml",
+		"/sitemaps/posts-abc.xml",
+		"/sitemaps/unknown-0.xml",
+		"/sitemaps/posts.xml",
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, want 404", path, rec.Code)
+		}
+	}
+}
+
+func TestSitemapKnownCursor(t *testing.T) {
+	h := NewSitemapHandler(nil, nil, links.New("https://www.example.com"), "site")
+	h.setCursors("posts", []data.SitemapPage{{After: 0}, {After: 10233}})
+	for after, want := range map[int]bool{0: true, 10233: true, 1: false, 10000: false} {
+		got, err := h.knownCursor(context.Background(), "posts", after)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("knownCursor(posts, %d) = %v, want %v", after, got, want)
+		}
+	}
+}
+
+// downConnector 模擬無法連線的資料庫
+type downConnector struct{}
+
+func (downConnector) Connect(context.Context) (driver.Conn, error) { return nil, errors.New("db down") }
+func (downConnector) Driver() driver.Driver                        { return downDriver{} }
+
+type downDriver struct{}
+
+func (downDriver) Open(string) (driver.Conn, error) { return nil, errors.New("db down") }
+
+// 種類查詢失敗時 index 仍然回應，只是略過該種類
+func TestSitemapIndexSkipsFailingKinds(t *testing.T) {
+	db := sql.OpenDB(downConnector{})
+	defer db.Close()
+	h := NewSitemapHandler(data.NewRepo(db, nil, nil), nil, links.New("https://www.example.com"), "site")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "https://www.example.com/sitemap-news.xml") || strings.Contains(body, "/sitemaps/") {
+		t.Errorf("index = %s, want only the news sitemap", body)
+	}
+}
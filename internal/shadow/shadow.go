@@ -0,0 +1,259 @@
+// Package shadow 將抽樣的線上 GraphQL 請求非同步轉送到舊的 GQL 服務，
+// 以 probe 的比對規則比較兩邊回應，並把不一致記錄到 Sink。
+// 轉送不影響原請求的回應；佇列已滿或超過速率限制時直接捨棄。
+package shadow
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-story/internal/probe"
+	"go-story/internal/ratelimit"
+)
+
+const (
+	defaultWorkers   = 4
+	defaultQueueSize = 256
+	defaultTimeout   = 10 * time.Second
+)
+
+// Config 是 shadow 模式的設定
+type Config struct {
+	// URL 是舊 GQL 服務的 endpoint
+	URL string
+	// SampleRate 是轉送的請求比例（0–1）
+	SampleRate float64
+	// RPS 與 Burst 限制送往舊服務的請求速率；RPS <= 0 時不限制
+	RPS   float64
+	Burst int
+	// Compare 是比對規則，通常沿用 probe default suite 的設定
+	Compare probe.CompareOptions
+	// Sink 記錄不一致的請求；nil 時使用 LogSink
+	Sink Sink
+	// Timeout 是單一轉送請求的逾時；0 時使用 10 秒
+	Timeout time.Duration
+}
+
+// Request 是要轉送的 GraphQL 請求
+type Request struct {
+	Query         string
+	OperationName string
+	Variables     map[string]interface{}
+}
+
+// Record 是一筆不一致的紀錄
+type Record struct {
+	Time          time.Time              `json:"time"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Operation     string                 `json:"operation"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	LegacyStatus  int                    `json:"legacyStatus"`
+	SelfStatus    int                    `json:"selfStatus"`
+	LegacyLatency probe.Millis           `json:"legacyLatencyMs"`
+	SelfLatency   probe.Millis           `json:"selfLatencyMs"`
+	Error         string                 `json:"error,omitempty"`
+	Note          string                 `json:"note"`
+	Diff          []probe.PatchOp        `json:"diff,omitempty"`
+}
+
+// Stats 是 shadow 模式的累計計數
+type Stats struct {
+	Sampled     int64 `json:"sampled"`
+	RateLimited int64 `json:"rateLimited"`
+	Dropped     int64 `json:"dropped"`
+	Compared    int64 `json:"compared"`
+	Mismatched  int64 `json:"mismatched"`
+}
+
+type job struct {
+	req         Request
+	selfStatus  int
+	selfBody    []byte
+	selfLatency time.Duration
+}
+
+// Mirror 將請求轉送到舊服務並比對
+type Mirror struct {
+	cfg    Config
+	client *http.Client
+	bucket *ratelimit.Bucket
+	queue  chan job
+	wg     sync.WaitGroup
+
+	sampled, rateLimited, dropped, compared, mismatched atomic.Int64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+
+	// closeMu 保護 queue 的關閉：Observe 持有讀鎖送入佇列，Close 持有寫鎖關閉佇列
+	closeMu sync.RWMutex
+	closed  bool
+}
+
+// New 建立 Mirror 並啟動背景 worker；cfg.URL 為空時回傳 nil（停用 shadow 模式）
+func New(cfg Config) *Mirror {
+	if cfg.URL == "" || cfg.SampleRate <= 0 {
+		return nil
+	}
+	if cfg.SampleRate > 1 {
+		cfg.SampleRate = 1
+	}
+	if cfg.Sink == nil {
+		cfg.Sink = LogSink{}
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	m := &Mirror{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		queue:  make(chan job, defaultQueueSize),
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if cfg.RPS > 0 {
+		m.bucket = ratelimit.NewBucket(cfg.RPS, cfg.Burst)
+	}
+	for i := 0; i < defaultWorkers; i++ {
+		m.wg.Add(1)
+		go m.worker()
+	}
+	return m
+}
+
+// Observe 依抽樣比例與速率限制決定是否轉送 req；selfBody 為本服務已送出的回應。
+// 不會阻塞呼叫端。m 為 nil 時不做任何事。
+func (m *Mirror) Observe(req Request, selfStatus int, selfBody []byte, selfLatency time.Duration) {
+	if m == nil || !m.sample() {
+		return
+	}
+	m.sampled.Add(1)
+	if m.bucket != nil && !m.bucket.Allow() {
+		m.rateLimited.Add(1)
+		return
+	}
+	m.closeMu.RLock()
+	defer m.closeMu.RUnlock()
+	if m.closed {
+		m.dropped.Add(1)
+		return
+	}
+	select {
+	case m.queue <- job{req: req, selfStatus: selfStatus, selfBody: selfBody, selfLatency: selfLatency}:
+	default:
+		m.dropped.Add(1)
+	}
+}
+
+// Close 停止接收新的請求並等待佇列中的比對完成；之後的 Observe 一律捨棄。可重複呼叫。
+func (m *Mirror) Close() {
+	if m == nil {
+		return
+	}
+	m.closeMu.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.queue)
+	}
+	m.closeMu.Unlock()
+	m.wg.Wait()
+}
+
+// Stats 回傳目前的累計計數
+func (m *Mirror) Stats() Stats {
+	if m == nil {
+		return Stats{}
+	}
+	return Stats{
+		Sampled:     m.sampled.Load(),
+		RateLimited: m.rateLimited.Load(),
+		Dropped:     m.dropped.Load(),
+		Compared:    m.compared.Load(),
+		Mismatched:  m.mismatched.Load(),
+	}
+}
+
+func (m *Mirror) sample() bool {
+	if m.cfg.SampleRate >= 1 {
+		return true
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rand.Float64() < m.cfg.SampleRate
+}
+
+func (m *Mirror) worker() {
+	defer m.wg.Done()
+	for j := range m.queue {
+		m.process(j)
+	}
+}
+
+func (m *Mirror) process(j job) {
+	legacy := m.forward(j.req)
+	self := probe.Result{StatusCode: j.selfStatus, Body: j.selfBody, Latency: j.selfLatency}
+	match, note, diff := probe.Compare(legacy, self, m.cfg.Compare)
+	m.compared.Add(1)
+	if match {
+		return
+	}
+	m.mismatched.Add(1)
+	rec := Record{
+		Time:          time.Now().UTC(),
+		OperationName: j.req.OperationName,
+		Operation:     probe.NormalizeQuery(j.req.Query),
+		Variables:     j.req.Variables,
+		LegacyStatus:  legacy.StatusCode,
+		SelfStatus:    j.selfStatus,
+		LegacyLatency: probe.Millis(legacy.Latency),
+		SelfLatency:   probe.Millis(j.selfLatency),
+		Error:         legacy.Error,
+		Note:          note,
+		Diff:          diff,
+	}
+	if err := m.cfg.Sink.Write(rec); err != nil {
+		log.Printf("[Shadow] write mismatch failed: %v", err)
+	}
+}
+
+// forward 將請求送到舊服務；只轉送 query 本身，不帶任何 header 中的身分資訊
+func (m *Mirror) forward(req Request) probe.Result {
+	var res probe.Result
+	body := map[string]interface{}{"query": req.Query}
+	if req.OperationName != "" {
+		body["operationName"] = req.OperationName
+	}
+	if req.Variables != nil {
+		body["variables"] = req.Variables
+	}
+	b, _ := json.Marshal(body)
+	httpReq, err := http.NewRequest(http.MethodPost, m.cfg.URL, bytes.NewReader(b))
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	start := time.Now()
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		res.Latency = time.Since(start)
+		res.Error = err.Error()
+		return res
+	}
+	raw, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	res.Latency = time.Since(start)
+	res.StatusCode = resp.StatusCode
+	res.Body = raw
+	res.Size = len(raw)
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
@@ -0,0 +1,184 @@
+package shadow
+
+import (
+	"bufio"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memorySink 將紀錄保存在記憶體中
+type memorySink struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func (s *memorySink) Write(rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, rec)
+	return nil
+}
+
+// legacyServer 模擬舊 GQL 服務，固定回應 body；gate 不為 nil 時每個請求都等到 gate 關閉
+func legacyServer(t *testing.T, body string, gate chan struct{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if gate != nil {
+			<-gate
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+const selfBody = `{"data":{"post":{"id":"1","title":"t"}}}`
+
+func observeN(m *Mirror, n int) {
+	for i := 0; i < n; i++ {
+		m.Observe(Request{Query: `{ post(where: {id: "1"}) { id title } }`}, http.StatusOK, []byte(selfBody), time.Millisecond)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	if m := New(Config{URL: "http://legacy.test", SampleRate: 0}); m != nil {
+		t.Fatal("SampleRate 0 should disable shadow mode")
+	}
+	if m := New(Config{SampleRate: 1}); m != nil {
+		t.Fatal("empty URL should disable shadow mode")
+	}
+	var m *Mirror
+	observeN(m, 1)
+	m.Close()
+	if m.Stats() != (Stats{}) {
+		t.Error("nil Mirror should report zero stats")
+	}
+}
+
+func TestSampling(t *testing.T) {
+	srv := legacyServer(t, selfBody, nil)
+	m := New(Config{URL: srv.URL, SampleRate: 0.25, Sink: &memorySink{}})
+	m.rand = rand.New(rand.NewSource(1))
+	observeN(m, 400)
+	m.Close()
+
+	st := m.Stats()
+	if st.Sampled < 60 || st.Sampled > 140 {
+		t.Errorf("sampled %d of 400 at rate 0.25", st.Sampled)
+	}
+	if st.Compared != st.Sampled || st.Mismatched != 0 {
+		t.Errorf("stats = %+v, want every sampled request compared without mismatch", st)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	srv := legacyServer(t, selfBody, nil)
+	m := New(Config{URL: srv.URL, SampleRate: 1, RPS: 0.001, Burst: 3, Sink: &memorySink{}})
+	observeN(m, 10)
+	m.Close()
+
+	st := m.Stats()
+	if st.Sampled != 10 || st.RateLimited != 7 || st.Compared != 3 {
+		t.Errorf("stats = %+v, want sampled 10, rateLimited 7, compared 3", st)
+	}
+}
+
+func TestQueueFullDrops(t *testing.T) {
+	gate := make(chan struct{})
+	srv := legacyServer(t, selfBody, gate)
+	m := New(Config{URL: srv.URL, SampleRate: 1, Sink: &memorySink{}})
+
+	const n = defaultQueueSize + defaultWorkers + 50
+	start := time.Now()
+	observeN(m, n)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Observe blocked for %v while the legacy service was stalled", elapsed)
+	}
+	st := m.Stats()
+	if st.Dropped < 50 {
+		t.Errorf("dropped %d, want at least 50 once the queue is full", st.Dropped)
+	}
+	close(gate)
+	m.Close()
+
+	st = m.Stats()
+	if st.Compared+st.Dropped != n {
+		t.Errorf("stats = %+v, want compared + dropped = %d", st, n)
+	}
+	// Close 之後的請求直接捨棄，不會 panic
+	observeN(m, 1)
+	if m.Stats().Dropped != st.Dropped+1 {
+		t.Error("Observe after Close was not counted as dropped")
+	}
+}
+
+func TestMismatchRecords(t *testing.T) {
+	srv := legacyServer(t, `{"data":{"post":{"id":"1","title":"legacy"}}}`, nil)
+	sink := &memorySink{}
+	m := New(Config{URL: srv.URL, SampleRate: 1, Sink: sink})
+	m.Observe(Request{
+		Query:         "query Post($id: ID) {\n  post(where: {id: $id}) { id title }\n}",
+		OperationName: "Post",
+		Variables:     map[string]interface{}{"id": "1"},
+	}, http.StatusOK, []byte(selfBody), 5*time.Millisecond)
+	m.Close()
+
+	if len(sink.records) != 1 {
+		t.Fatalf("got %d records, want 1", len(sink.records))
+	}
+	rec := sink.records[0]
+	if rec.OperationName != "Post" || rec.LegacyStatus != http.StatusOK || rec.SelfStatus != http.StatusOK {
+		t.Errorf("record = %+v", rec)
+	}
+	if rec.Operation != "query Post($id:ID){post(where:{id:$id}){id title}}" {
+		t.Errorf("operation not normalized: %q", rec.Operation)
+	}
+	if len(rec.Diff) == 0 || rec.Note == "" {
+		t.Errorf("record has no diff or note: %+v", rec)
+	}
+	if st := m.Stats(); st.Compared != 1 || st.Mismatched != 1 {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shadow.jsonl")
+	sink, err := OpenFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range []string{"a", "b"} {
+		if err := sink.Write(Record{Operation: op, Note: "status differs"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var ops []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		ops = append(ops, rec.Operation)
+	}
+	if len(ops) != 2 || ops[0] != "a" || ops[1] != "b" {
+		t.Errorf("operations = %v", ops)
+	}
+}
@@ -0,0 +1,59 @@
+package shadow
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// Sink 記錄不一致的請求
+type Sink interface {
+	Write(rec Record) error
+}
+
+// LogSink 以單行 JSON 寫入標準 log
+type LogSink struct{}
+
+// Write 實作 Sink
+func (LogSink) Write(rec Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	log.Printf("[Shadow] mismatch %s", b)
+	return nil
+}
+
+// FileSink 將紀錄以 JSON Lines 格式附加到本機檔案
+type FileSink struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// OpenFileSink 開啟（或建立）path 作為 FileSink
+func OpenFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open shadow log: %w", err)
+	}
+	return &FileSink{f: f}, nil
+}
+
+// Write 實作 Sink
+func (s *FileSink) Write(rec Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.f.Write(append(b, '\n'))
+	return err
+}
+
+// Close 關閉檔案
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
@@ -0,0 +1,123 @@
+// Package sitemap 輸出 sitemaps.org 的 sitemap index、urlset 與 Google News sitemap。
+package sitemap
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// MaxURLs 是單一 sitemap 檔案允許的網址數上限
+const MaxURLs = 50000
+
+// Ref 是 sitemap index 中的一個子 sitemap
+type Ref struct {
+	Loc     string
+	LastMod time.Time
+}
+
+// URL 是 urlset 中的一筆網址
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+// NewsURL 是 Google News sitemap 中的一筆文章
+type NewsURL struct {
+	Loc             string
+	Title           string
+	PublicationDate time.Time
+}
+
+type indexDoc struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	NS       string   `xml:"xmlns,attr"`
+	Sitemaps []locMod `xml:"sitemap"`
+}
+
+type urlsetDoc struct {
+	XMLName xml.Name `xml:"urlset"`
+	NS      string   `xml:"xmlns,attr"`
+	URLs    []locMod `xml:"url"`
+}
+
+type locMod struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type newsDoc struct {
+	XMLName xml.Name  `xml:"urlset"`
+	NS      string    `xml:"xmlns,attr"`
+	NewsNS  string    `xml:"xmlns:news,attr"`
+	URLs    []newsURL `xml:"url"`
+}
+
+type newsURL struct {
+	Loc  string   `xml:"loc"`
+	News newsBody `xml:"news:news"`
+}
+
+type newsBody struct {
+	Publication     newsPublication `xml:"news:publication"`
+	PublicationDate string          `xml:"news:publication_date"`
+	Title           string          `xml:"news:title"`
+}
+
+type newsPublication struct {
+	Name     string `xml:"news:name"`
+	Language string `xml:"news:language"`
+}
+
+const (
+	nsSitemap = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	nsNews    = "http://www.google.com/schemas/sitemap-news/0.9"
+)
+
+// Index 輸出 sitemap index
+func Index(refs []Ref) ([]byte, error) {
+	doc := indexDoc{NS: nsSitemap, Sitemaps: []locMod{}}
+	for _, r := range refs {
+		doc.Sitemaps = append(doc.Sitemaps, locMod{Loc: r.Loc, LastMod: formatTime(r.LastMod)})
+	}
+	return marshal(doc)
+}
+
+// URLSet 輸出一般 sitemap
+func URLSet(urls []URL) ([]byte, error) {
+	doc := urlsetDoc{NS: nsSitemap, URLs: []locMod{}}
+	for _, u := range urls {
+		doc.URLs = append(doc.URLs, locMod{Loc: u.Loc, LastMod: formatTime(u.LastMod)})
+	}
+	return marshal(doc)
+}
+
+// News 輸出 Google News sitemap；publication 為媒體名稱，language 為 ISO 639 語言代碼（例如 zh-tw）
+func News(publication, language string, urls []NewsURL) ([]byte, error) {
+	doc := newsDoc{NS: nsSitemap, NewsNS: nsNews, URLs: []newsURL{}}
+	for _, u := range urls {
+		doc.URLs = append(doc.URLs, newsURL{
+			Loc: u.Loc,
+			News: newsBody{
+				Publication:     newsPublication{Name: publication, Language: language},
+				PublicationDate: u.PublicationDate.Format(time.RFC3339),
+				Title:           u.Title,
+			},
+		})
+	}
+	return marshal(doc)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
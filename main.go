@@ -1,16 +1,40 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"go-story/internal/auth"
 	"go-story/internal/config"
 	"go-story/internal/data"
+	"go-story/internal/links"
+	"go-story/internal/preview"
+	"go-story/internal/probe"
+	"go-story/internal/redirect"
+	"go-story/internal/rendition"
 	"go-story/internal/schema"
+	"go-story/internal/seo"
 	"go-story/internal/server"
+	"go-story/internal/shadow"
 )
 
 func main() {
+	// go-story migrate slug-history
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrateCommand(os.Args[2:], os.Stderr))
+	}
+
+	// go-story probe --target URL --candidate URL|local [--suite NAME] [--format text|junit] [--record DIR | --replay DIR]
+	if len(os.Args) > 1 && os.Args[1] == "probe" {
+		os.Exit(runProbeCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("config error: %v", err)
@@ -39,19 +63,131 @@ func main() {
 		}
 	}
 
-	repo := data.NewRepo(db, cfg.StaticsHost, cache)
-	gqlSchema, err := schema.Build(repo)
+	previewSigner := preview.NewSigner(cfg.PreviewSecret)
+	if previewSigner == nil && cfg.GoEnv != "prod" {
+		log.Printf("Preview mode disabled (PREVIEW_SECRET not set)")
+	}
+
+	var verifier *auth.Verifier
+	switch {
+	case cfg.JWKSFile != "":
+		keys, err := auth.LoadJWKSFile(cfg.JWKSFile)
+		if err != nil {
+			log.Fatalf("failed to load JWKS: %v", err)
+		}
+		verifier = auth.NewVerifier(keys, cfg.JWTIssuer, cfg.JWTAudience)
+	case cfg.JWKSURL != "":
+		keys, err := auth.NewRemoteKeySet(cfg.JWKSURL, time.Hour)
+		if err != nil {
+			log.Fatalf("failed to load JWKS: %v", err)
+		}
+		verifier = auth.NewVerifier(keys, cfg.JWTIssuer, cfg.JWTAudience)
+	default:
+		if cfg.GoEnv != "prod" {
+			log.Printf("JWT auth disabled (JWKS_FILE / JWKS_URL not set)")
+		}
+	}
+
+	repo := data.NewRepo(db, rendition.New(cfg.StaticsHost, cfg.ImageRenditions), cache)
+	if ok, err := repo.DetectCategoryMemberOnly(context.Background()); err != nil {
+		log.Printf("failed to check Category.isMemberOnly, member posts are detected by Post.isMember only: %v", err)
+	} else if ok && cfg.GoEnv != "prod" {
+		log.Printf("Category.isMemberOnly found, member-only categories are paywalled")
+	}
+	if ok, err := repo.DetectSlugHistory(context.Background()); err != nil {
+		log.Printf("failed to check slug history: %v", err)
+	} else if !ok {
+		log.Printf("SlugHistory table not found, /resolve will not follow renamed slugs (see internal/data/migrations/slug_history.sql)")
+	}
+	siteLinks := links.New(cfg.SiteURL)
+	redirects := redirect.New(repo, siteLinks)
+	gqlSchema, err := schema.Build(repo, schema.Options{
+		Preview:    previewSigner,
+		TrimBlocks: cfg.PaywallTrimBlocks,
+		SEO:        seo.New(siteLinks, cfg.SiteName),
+		Redirects:  redirects,
+	})
 	if err != nil {
 		log.Fatalf("failed to build schema: %v", err)
 	}
 
-	http.Handle("/api/graphql", server.NewGraphQLHandler(gqlSchema))
-	http.HandleFunc("/probe", server.ProbeHandler)
+	var mirror *shadow.Mirror
+	if cfg.ShadowURL != "" {
+		var sink shadow.Sink
+		if cfg.ShadowLogFile != "" {
+			fileSink, err := shadow.OpenFileSink(cfg.ShadowLogFile)
+			if err != nil {
+				log.Fatalf("failed to open shadow log: %v", err)
+			}
+			defer fileSink.Close()
+			sink = fileSink
+		}
+		compare := probe.CompareOptions{}
+		if suite, err := probe.LoadSuite(probe.DefaultSuite); err == nil {
+			compare = suite.Compare
+		}
+		mirror = shadow.New(shadow.Config{
+			URL:        cfg.ShadowURL,
+			SampleRate: cfg.ShadowSampleRate,
+			RPS:        cfg.ShadowRPS,
+			Burst:      cfg.ShadowBurst,
+			Compare:    compare,
+			Sink:       sink,
+		})
+		if mirror != nil {
+			log.Printf("Shadow mode enabled: %.2f%% of anonymous requests mirrored to %s", cfg.ShadowSampleRate*100, cfg.ShadowURL)
+		}
+	}
+
+	http.Handle("/api/graphql", server.WithAuth(verifier, auth.NewAgeSigner(cfg.AgeVerifySecret), server.NewGraphQLHandler(gqlSchema, previewSigner, mirror)))
+	http.Handle("/feeds/", server.NewFeedHandler(repo, cache, siteLinks, cfg.SiteName))
+	sitemaps := server.NewSitemapHandler(repo, cache, siteLinks, cfg.SiteName)
+	http.Handle("/sitemap.xml", sitemaps)
+	http.Handle("/sitemap-news.xml", sitemaps)
+	http.Handle("/sitemaps/", sitemaps)
+	http.Handle("/resolve", server.NewResolveHandler(redirects, cache))
+	probeStore, err := openProbeStore(cfg.ProbeStore, db)
+	if err != nil {
+		log.Fatalf("failed to open probe store: %v", err)
+	}
+	probeGuard, err := server.NewProbeGuard(cfg.ProbeAdminToken, cfg.ProbeAllowedTargets, cfg.ProbeRateLimit)
+	if err != nil {
+		log.Fatalf("invalid PROBE_ALLOWED_TARGETS: %v", err)
+	}
+	if cfg.ProbeAdminToken == "" {
+		log.Printf("PROBE_ADMIN_TOKEN not set, /probe endpoints are disabled")
+	}
+	http.Handle("/probe", server.NewProbeHandler(probeStore, cfg.CommitSHA, cfg.ProbeSelfURL, probeGuard))
+	http.Handle("/probe/runs", server.NewProbeRunsHandler(probeStore, probeGuard))
+	http.Handle("/probe/report", server.NewProbeReportHandler(probeStore, probeGuard))
+	http.Handle("/probe/report/link", server.NewProbeReportLinkHandler(probeGuard))
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte("GraphQL endpoint is available at POST /api/graphql"))
 	})
 
 	addr := ":" + cfg.Port
+	srv := &http.Server{Addr: addr}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// done 在 Shutdown 等完進行中的請求（或逾時）後關閉
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("server shutdown: %v", err)
+		}
+	}()
+
 	log.Printf("GraphQL server listening on %s (POST /api/graphql)", addr)
-	log.Fatal(http.ListenAndServe(addr, nil))
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+	// ListenAndServe 在 Shutdown 開始時就返回，等 Shutdown 完成後才不再有進行中的請求
+	<-done
+	// 等待 shadow 佇列中的比對寫入 sink 後才關閉 sink
+	mirror.Close()
+	log.Printf("GraphQL server stopped")
 }